@@ -0,0 +1,368 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/safe_msvc_city/insfratructure/database"
+	"github.com/safe_msvc_city/insfratructure/entities"
+	"github.com/safe_msvc_city/insfratructure/ui/uicore"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// errBulkMissing indica que una fila validada desapareció antes de aplicar el lote
+var errBulkMissing = errors.New("el registro ya no existe")
+
+// bulkConnection aplica lotes de operaciones con inserciones y upserts por lotes;
+// registra los mismos eventos que los repositorios de ciudades y barrios
+type bulkConnection struct {
+	connection *gorm.DB
+}
+
+var (
+	_BULK      *bulkConnection
+	_BULK_ONCE sync.Once
+)
+
+// GetBulkInstance devuelve la instancia única que implementa UIBulkCore
+func GetBulkInstance() uicore.UIBulkCore {
+	_BULK_ONCE.Do(func() {
+		_BULK = &bulkConnection{
+			connection: database.GetDatabaseInstance(),
+		}
+	})
+	return _BULK
+}
+
+func (db *bulkConnection) GetCitiesByIds(ctx context.Context, ids []uint) ([]entities.City, error) {
+	ctx, end := observe(ctx, "bulk", "GetCitiesByIds")
+	defer end()
+	var cities []entities.City
+	if len(ids) == 0 {
+		return cities, nil
+	}
+	result := db.connection.WithContext(ctx).Where("id IN ?", ids).Find(&cities)
+	return cities, result.Error
+}
+
+func (db *bulkConnection) GetCitiesByNames(ctx context.Context, names []string) ([]entities.City, error) {
+	ctx, end := observe(ctx, "bulk", "GetCitiesByNames")
+	defer end()
+	var cities []entities.City
+	if len(names) == 0 {
+		return cities, nil
+	}
+	result := db.connection.WithContext(ctx).Where("name IN ?", names).Find(&cities)
+	return cities, result.Error
+}
+
+func (db *bulkConnection) GetStatesByIds(ctx context.Context, ids []uint) ([]entities.States, error) {
+	ctx, end := observe(ctx, "bulk", "GetStatesByIds")
+	defer end()
+	var states []entities.States
+	if len(ids) == 0 {
+		return states, nil
+	}
+	result := db.connection.WithContext(ctx).Where("id IN ?", ids).Find(&states)
+	return states, result.Error
+}
+
+// GetStatesByNames busca los barrios por nombre sin distinguir mayúsculas, la misma
+// regla de GetStatesFindByName y MoveState
+func (db *bulkConnection) GetStatesByNames(ctx context.Context, names []string) ([]entities.States, error) {
+	ctx, end := observe(ctx, "bulk", "GetStatesByNames")
+	defer end()
+	var states []entities.States
+	if len(names) == 0 {
+		return states, nil
+	}
+	lowered := make([]string, len(names))
+	for i, name := range names {
+		lowered[i] = strings.ToLower(name)
+	}
+	result := db.connection.WithContext(ctx).Where("LOWER(name) IN ?", lowered).Find(&states)
+	return states, result.Error
+}
+
+func (db *bulkConnection) BulkCities(ctx context.Context, ops []uicore.BulkCityOperation, atomic bool) ([]error, error) {
+	ctx, end := observe(ctx, "bulk", "BulkCities")
+	defer end()
+	errs := make([]error, len(ops))
+	err := db.applyCities(ctx, ops)
+	if err == nil || atomic {
+		return errs, err
+	}
+	isolate(ops, errs, err, func(part []uicore.BulkCityOperation) error {
+		return db.applyCities(ctx, part)
+	})
+	return errs, nil
+}
+
+func (db *bulkConnection) BulkStates(ctx context.Context, ops []uicore.BulkStateOperation, atomic bool) ([]error, error) {
+	ctx, end := observe(ctx, "bulk", "BulkStates")
+	defer end()
+	errs := make([]error, len(ops))
+	err := db.applyStates(ctx, ops)
+	if err == nil || atomic {
+		return errs, err
+	}
+	isolate(ops, errs, err, func(part []uicore.BulkStateOperation) error {
+		return db.applyStates(ctx, part)
+	})
+	return errs, nil
+}
+
+// isolate recibe un lote cuya aplicación falló con err y lo parte en mitades que se
+// aplican cada una en su transacción, hasta dejar solas las operaciones que fallan. Una
+// operación mala entre n cuesta unas 2·log2(n) transacciones en lugar de n; errs queda
+// con el error de cada operación que no se pudo aplicar
+func isolate[T any](ops []T, errs []error, err error, apply func([]T) error) {
+	if len(ops) == 1 {
+		errs[0] = err
+		return
+	}
+	middle := len(ops) / 2
+	for _, bounds := range [][2]int{{0, middle}, {middle, len(ops)}} {
+		part := ops[bounds[0]:bounds[1]]
+		if err := apply(part); err != nil {
+			isolate(part, errs[bounds[0]:bounds[1]], err, apply)
+		}
+	}
+}
+
+// applyCities aplica ops en una transacción: primero las creaciones, luego las
+// actualizaciones como upsert por id y al final las eliminaciones. Los ids de las
+// creadas solo se copian a ops si la transacción se confirma
+func (db *bulkConnection) applyCities(ctx context.Context, ops []uicore.BulkCityOperation) error {
+	now := time.Now()
+	var keys []string
+	var created []entities.City
+	var createdIndex []int
+	err := db.connection.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		keys, created, createdIndex = keys[:0], created[:0], createdIndex[:0]
+		var updated []entities.City
+		var ids, deleted []uint
+		for i, op := range ops {
+			city := op.City
+			city.UpdatedAt = &now
+			switch op.Op {
+			case uicore.BulkCreate:
+				city.Id = 0
+				created = append(created, city)
+				createdIndex = append(createdIndex, i)
+			case uicore.BulkUpdate:
+				updated = append(updated, city)
+				ids = append(ids, city.Id)
+			case uicore.BulkDelete:
+				deleted = append(deleted, city.Id)
+				ids = append(ids, city.Id)
+			}
+		}
+		previous, err := findByIds[entities.City](tx, ids, func(city entities.City) uint { return city.Id })
+		if err != nil {
+			return err
+		}
+
+		if len(created) > 0 {
+			if err := tx.CreateInBatches(&created, importBatchSize).Error; err != nil {
+				return err
+			}
+			for i := range created {
+				if err := recordCityChange(tx, nil, &created[i]); err != nil {
+					return err
+				}
+				keys = append(keys, cityCacheKeys(created[i].Id)...)
+			}
+		}
+
+		if len(updated) > 0 {
+			for _, city := range updated {
+				if _, ok := previous[city.Id]; !ok {
+					return errBulkMissing
+				}
+			}
+			err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "id"}},
+				DoUpdates: clause.AssignmentColumns([]string{"name", "active", "updated_at"}),
+			}).CreateInBatches(&updated, importBatchSize).Error
+			if err != nil {
+				return err
+			}
+			current, err := findByIds[entities.City](tx, ids, func(city entities.City) uint { return city.Id })
+			if err != nil {
+				return err
+			}
+			for _, city := range updated {
+				before, after := previous[city.Id], current[city.Id]
+				if err := recordCityChange(tx, &before, &after); err != nil {
+					return err
+				}
+				keys = append(keys, cityCacheKeys(city.Id)...)
+			}
+		}
+
+		if len(deleted) > 0 {
+			var children []entities.States
+			if err := tx.Where("city_id IN ?", deleted).Find(&children).Error; err != nil {
+				return err
+			}
+			if err := tx.Unscoped().Where("id IN ?", deleted).Delete(&entities.City{}).Error; err != nil {
+				return err
+			}
+			// Igual que DeleteCity: los StateDeleted de los hijos antes del CityDeleted
+			for i := range children {
+				if err := recordStateChange(tx, &children[i], nil); err != nil {
+					return err
+				}
+				keys = append(keys, stateCacheKeys(children[i].Id, children[i].CityId)...)
+			}
+			for _, id := range deleted {
+				city, ok := previous[id]
+				if !ok {
+					return errBulkMissing
+				}
+				if err := recordCityChange(tx, &city, nil); err != nil {
+					return err
+				}
+				keys = append(keys, cityCacheKeys(id)...)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for i, index := range createdIndex {
+		ops[index].City.Id = created[i].Id
+	}
+	if cacheBackend != nil {
+		invalidate(ctx, keys...)
+	}
+	return nil
+}
+
+// applyStates es el equivalente de applyCities para barrios
+func (db *bulkConnection) applyStates(ctx context.Context, ops []uicore.BulkStateOperation) error {
+	now := time.Now()
+	var keys []string
+	var created []entities.States
+	var createdIndex []int
+	err := db.connection.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		keys, created, createdIndex = keys[:0], created[:0], createdIndex[:0]
+		var updated []entities.States
+		var ids, deleted []uint
+		for i, op := range ops {
+			state := op.State
+			state.UpdatedAt = &now
+			state.City = entities.City{}
+			switch op.Op {
+			case uicore.BulkCreate:
+				state.Id = 0
+				created = append(created, state)
+				createdIndex = append(createdIndex, i)
+			case uicore.BulkUpdate:
+				updated = append(updated, state)
+				ids = append(ids, state.Id)
+			case uicore.BulkDelete:
+				deleted = append(deleted, state.Id)
+				ids = append(ids, state.Id)
+			}
+		}
+		previous, err := findByIds[entities.States](tx, ids, func(state entities.States) uint { return state.Id })
+		if err != nil {
+			return err
+		}
+
+		if len(created) > 0 {
+			if err := tx.Omit(clause.Associations).CreateInBatches(&created, importBatchSize).Error; err != nil {
+				return err
+			}
+			for i := range created {
+				if err := recordStateChange(tx, nil, &created[i]); err != nil {
+					return err
+				}
+				keys = append(keys, stateCacheKeys(created[i].Id, created[i].CityId)...)
+			}
+		}
+
+		if len(updated) > 0 {
+			for _, state := range updated {
+				if _, ok := previous[state.Id]; !ok {
+					return errBulkMissing
+				}
+			}
+			err := tx.Omit(clause.Associations).Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "id"}},
+				DoUpdates: clause.AssignmentColumns([]string{"name", "zip_code", "city_id", "active", "updated_at"}),
+			}).CreateInBatches(&updated, importBatchSize).Error
+			if err != nil {
+				return err
+			}
+			current, err := findByIds[entities.States](tx, ids, func(state entities.States) uint { return state.Id })
+			if err != nil {
+				return err
+			}
+			for _, state := range updated {
+				before, after := previous[state.Id], current[state.Id]
+				if err := recordStateMove(tx, before, after, ""); err != nil {
+					return err
+				}
+				if err := recordStateChange(tx, &before, &after); err != nil {
+					return err
+				}
+				keys = append(keys, stateCacheKeys(state.Id, state.CityId)...)
+				keys = append(keys, cacheKeyStatesOfCity(before.CityId))
+			}
+		}
+
+		if len(deleted) > 0 {
+			if err := tx.Unscoped().Where("id IN ?", deleted).Delete(&entities.States{}).Error; err != nil {
+				return err
+			}
+			for _, id := range deleted {
+				state, ok := previous[id]
+				if !ok {
+					return errBulkMissing
+				}
+				if err := recordStateChange(tx, &state, nil); err != nil {
+					return err
+				}
+				keys = append(keys, stateCacheKeys(id, state.CityId)...)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for i, index := range createdIndex {
+		ops[index].State.Id = created[i].Id
+	}
+	if cacheBackend != nil {
+		invalidate(ctx, keys...)
+	}
+	return nil
+}
+
+// findByIds carga las filas de ids dentro de tx, indexadas por id, y las bloquea con
+// FOR UPDATE en orden de id. Así un borrado concurrente espera al lote, o el lote ve
+// que la fila falta, en lugar de que el upsert de las actualizaciones la vuelva a crear
+func findByIds[T any](tx *gorm.DB, ids []uint, id func(T) uint) (map[uint]T, error) {
+	rows := map[uint]T{}
+	if len(ids) == 0 {
+		return rows, nil
+	}
+	var list []T
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id IN ?", ids).Order("id").Find(&list).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range list {
+		rows[id(row)] = row
+	}
+	return rows, nil
+}
@@ -0,0 +1,67 @@
+package core
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestIsolate(t *testing.T) {
+	tests := []struct {
+		name string
+		ops  int
+		bad  []int
+	}{
+		{"una", 1, []int{0}},
+		{"la primera", 8, []int{0}},
+		{"la última", 1000, []int{999}},
+		{"varias", 10, []int{2, 3, 7}},
+		{"todas", 4, []int{0, 1, 2, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ops := make([]int, tt.ops)
+			for i := range ops {
+				ops[i] = i
+			}
+			applied := map[int]int{}
+			calls := 0
+			apply := func(part []int) error {
+				calls++
+				for _, op := range part {
+					if slices.Contains(tt.bad, op) {
+						return errors.New("falla")
+					}
+				}
+				for _, op := range part {
+					applied[op]++
+				}
+				return nil
+			}
+			errs := make([]error, len(ops))
+			if err := apply(ops); err != nil {
+				isolate(ops, errs, err, apply)
+			}
+			for i, err := range errs {
+				bad := slices.Contains(tt.bad, i)
+				if bad != (err != nil) {
+					t.Errorf("operación %d: error %v", i, err)
+				}
+				if !bad && applied[i] != 1 {
+					t.Errorf("operación %d aplicada %d veces", i, applied[i])
+				}
+			}
+			if len(tt.bad) == 1 && tt.ops > 1 && calls > 2*bitsLen(tt.ops)+1 {
+				t.Errorf("%d transacciones para %d operaciones", calls, tt.ops)
+			}
+		})
+	}
+}
+
+func bitsLen(n int) int {
+	bits := 0
+	for ; n > 0; n >>= 1 {
+		bits++
+	}
+	return bits
+}
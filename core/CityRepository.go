@@ -1,13 +1,18 @@
 package core
 
 import (
+	"context"
+	"fmt"
 	"sync"
+	"time"
 
 	constants "github.com/flabio/safe_constants"
+	var_db "github.com/flabio/safe_var_db"
 	"github.com/safe_msvc_city/insfratructure/database"
 	"github.com/safe_msvc_city/insfratructure/entities"
 	"github.com/safe_msvc_city/insfratructure/ui/uicore"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type OpenConnection struct {
@@ -25,58 +30,137 @@ func GetCityInstance() uicore.UICityCore {
 			connection: database.GetDatabaseInstance(),
 		}
 	})
-	return _OPEN
+	return citySnapshot{withCityCache(_OPEN)}
 }
 
-func (db *OpenConnection) GetCityFindAll() ([]entities.City, error) {
+func (db *OpenConnection) GetCityFindAll(ctx context.Context) ([]entities.City, error) {
+	ctx, end := observe(ctx, "city", "GetCityFindAll")
+	defer end()
 	var cities []entities.City
 	db.mux.Lock()
 	defer db.mux.Unlock()
 
-	result := db.connection.Order(constants.DB_ORDER_DESC).Find(&cities)
+	result := db.connection.WithContext(ctx).Order(constants.DB_ORDER_DESC).Find(&cities)
 	//defer database.CloseConnection()
 	return cities, result.Error
 }
-func (db *OpenConnection) GetCityFindById(id uint) (entities.City, error) {
+func (db *OpenConnection) GetCityFindById(ctx context.Context, id uint) (entities.City, error) {
+	ctx, end := observe(ctx, "city", "GetCityFindById")
+	defer end()
 	var city entities.City
 	db.mux.Lock()
 	defer db.mux.Unlock()
 
-	result := db.connection.Where(constants.DB_EQUAL_ID, id).Find(&city)
+	result := db.connection.WithContext(ctx).Where(constants.DB_EQUAL_ID, id).Find(&city)
 	//defer database.CloseConnection()
 	return city, result.Error
 }
-func (db *OpenConnection) CreateCity(city entities.City) (entities.City, error) {
+
+// CreateCity crea la ciudad y, si city.States trae barrios, también los barrios
+func (db *OpenConnection) CreateCity(ctx context.Context, city entities.City) (entities.City, error) {
+	ctx, end := observe(ctx, "city", "CreateCity")
+	defer end()
 	db.mux.Lock()
 	defer db.mux.Unlock()
-	err := db.connection.Create(&city).Error
+	// Un trigger también fija updated_at; se asigna aquí para devolverlo en la respuesta
+	now := time.Now()
+	city.UpdatedAt = &now
+	err := db.connection.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("States").Create(&city).Error; err != nil {
+			return err
+		}
+		if err := recordCityChange(tx, nil, &city); err != nil {
+			return err
+		}
+		// Los barrios anidados se crean en la misma transacción, cada uno con su evento
+		if city.States == nil || len(*city.States) == 0 {
+			return nil
+		}
+		states := *city.States
+		for i := range states {
+			states[i].CityId, states[i].UpdatedAt = city.Id, &now
+		}
+		if err := tx.Omit("City").CreateInBatches(&states, importBatchSize).Error; err != nil {
+			return err
+		}
+		for i := range states {
+			if err := recordStateChange(tx, nil, &states[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 
 	//defer database.CloseConnection()
 	return city, err
 }
-func (db *OpenConnection) UpdateCity(id uint, city entities.City) (entities.City, error) {
+func (db *OpenConnection) UpdateCity(ctx context.Context, id uint, city entities.City) (entities.City, error) {
+	ctx, end := observe(ctx, "city", "UpdateCity")
+	defer end()
 	db.mux.Lock()
 	defer db.mux.Unlock()
-	err := db.connection.Where(constants.DB_EQUAL_ID, id).Updates(&city).Error
+	now := time.Now()
+	city.UpdatedAt = &now
+	err := db.connection.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var previous, current entities.City
+		if err := tx.Where(constants.DB_EQUAL_ID, id).Find(&previous).Error; err != nil {
+			return err
+		}
+		if err := tx.Where(constants.DB_EQUAL_ID, id).Updates(&city).Error; err != nil {
+			return err
+		}
+		if previous.Id == 0 {
+			return nil
+		}
+		if err := tx.Where(constants.DB_EQUAL_ID, id).Find(&current).Error; err != nil {
+			return err
+		}
+		return recordCityChange(tx, &previous, &current)
+	})
 
 	//defer database.CloseConnection()
 	return city, err
 }
-func (db *OpenConnection) DeleteCity(id uint) (bool, error) {
+func (db *OpenConnection) DeleteCity(ctx context.Context, id uint) (bool, error) {
+	ctx, end := observe(ctx, "city", "DeleteCity")
+	defer end()
 	db.mux.Lock()
 	defer db.mux.Unlock()
-	var city entities.City
-	err := db.connection.Where(constants.DB_EQUAL_ID, id).Delete(&city).Error
+	err := db.connection.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var city entities.City
+		var states []entities.States
+		if err := tx.Where(constants.DB_EQUAL_ID, id).Find(&city).Error; err != nil {
+			return err
+		}
+		if err := tx.Where(var_db.DB_EQUAL_CITY_ID, id).Find(&states).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where(constants.DB_EQUAL_ID, id).Delete(&entities.City{}).Error; err != nil {
+			return err
+		}
+		if city.Id == 0 {
+			return nil
+		}
+		// Los barrios se borran en cascada; cada uno publica su StateDeleted antes del CityDeleted
+		for i := range states {
+			if err := recordStateChange(tx, &states[i], nil); err != nil {
+				return err
+			}
+		}
+		return recordCityChange(tx, &city, nil)
+	})
 
 	//defer database.CloseConnection()
 	return err == nil, err
 
 }
-func (db *OpenConnection) GetCityFindByName(id uint, name string) (bool, error) {
+func (db *OpenConnection) GetCityFindByName(ctx context.Context, id uint, name string) (bool, error) {
+	ctx, end := observe(ctx, "city", "GetCityFindByName")
+	defer end()
 	db.mux.Lock()
 	defer db.mux.Unlock()
 	var city entities.City
-	query := db.connection.Where(constants.DB_EQUAL_NAME, name)
+	query := db.connection.WithContext(ctx).Where(constants.DB_EQUAL_NAME, name)
 	if id > 0 {
 		query = query.Where(constants.DB_DIFF_ID, id)
 	}
@@ -86,11 +170,13 @@ func (db *OpenConnection) GetCityFindByName(id uint, name string) (bool, error)
 	return query.RowsAffected > 0, query.Error
 
 }
-func (db *OpenConnection) GetCityFindByCode(id uint, name string) (bool, error) {
+func (db *OpenConnection) GetCityFindByCode(ctx context.Context, id uint, name string) (bool, error) {
+	ctx, end := observe(ctx, "city", "GetCityFindByCode")
+	defer end()
 	db.mux.Lock()
 	defer db.mux.Unlock()
 	var city entities.City
-	query := db.connection.Where(constants.DB_EQUAL_NAME, name)
+	query := db.connection.WithContext(ctx).Where(constants.DB_EQUAL_NAME, name)
 	if id > 0 {
 		query = query.Where(constants.DB_DIFF_ID, id)
 	}
@@ -99,3 +185,118 @@ func (db *OpenConnection) GetCityFindByCode(id uint, name string) (bool, error)
 	//defer database.CloseConnection()
 	return city.Id > 0, query.Error
 }
+
+// mergeReason es el motivo que queda en el historial de los barrios de una ciudad fusionada
+const mergeReason = "fusión de la ciudad %d"
+
+// MergeCities fusiona source en target en una transacción: pasa los barrios a target con
+// su historial, le cede el código DIVIPOLA y los datos de GeoNames si target no los tiene,
+// apunta a target las redirecciones que llegaban a source y borra source lógicamente
+// dejando merged_into. Source publica CityDeleted con merged_into en el contenido
+func (db *OpenConnection) MergeCities(ctx context.Context, sourceId uint, targetId uint) (entities.City, error) {
+	ctx, end := observe(ctx, "city", "MergeCities")
+	defer end()
+	db.mux.Lock()
+	defer db.mux.Unlock()
+
+	var target entities.City
+	now := time.Now()
+	err := db.connection.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var source, previous entities.City
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where(constants.DB_EQUAL_ID, sourceId).First(&source).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where(constants.DB_EQUAL_ID, targetId).First(&previous).Error
+		if err != nil {
+			return err
+		}
+
+		var states []entities.States
+		if err := tx.Where(var_db.DB_EQUAL_CITY_ID, sourceId).Find(&states).Error; err != nil {
+			return err
+		}
+		if len(states) > 0 {
+			err := tx.Model(&entities.States{}).Where(var_db.DB_EQUAL_CITY_ID, sourceId).
+				Updates(map[string]interface{}{"city_id": targetId, "updated_at": now}).Error
+			if err != nil {
+				return err
+			}
+		}
+		for i := range states {
+			moved := states[i]
+			moved.CityId, moved.UpdatedAt = targetId, &now
+			if err := recordStateMove(tx, states[i], moved, fmt.Sprintf(mergeReason, sourceId)); err != nil {
+				return err
+			}
+			if err := recordStateChange(tx, &states[i], &moved); err != nil {
+				return err
+			}
+		}
+
+		err = tx.Unscoped().Model(&entities.City{}).Where("merged_into = ?", sourceId).Update("merged_into", targetId).Error
+		if err != nil {
+			return err
+		}
+		// Source suelta el código y el geonameid antes de cederlos: ambos son únicos
+		err = tx.Model(&entities.City{}).Where(constants.DB_EQUAL_ID, sourceId).Updates(map[string]interface{}{
+			"merged_into": targetId,
+			"active":      false,
+			"code":        nil,
+			"geoname_id":  nil,
+			"updated_at":  now,
+			"deleted_at":  now,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		target = previous
+		var columns []string
+		if source.Code != nil && previous.Code == nil {
+			target.Code, target.DepartmentCode, target.Department = source.Code, source.DepartmentCode, source.Department
+			columns = append(columns, "code", "department_code", "department")
+		}
+		if source.GeonameId != nil && previous.GeonameId == nil {
+			target.GeonameId, target.CountryCode, target.Timezone = source.GeonameId, source.CountryCode, source.Timezone
+			target.Latitude, target.Longitude, target.Population = source.Latitude, source.Longitude, source.Population
+			target.AlternateNames = source.AlternateNames
+			columns = append(columns, "geoname_id", "country_code", "latitude", "longitude", "population", "timezone", "alternate_names")
+		}
+		if len(columns) > 0 {
+			target.UpdatedAt = &now
+			err := tx.Model(&entities.City{}).Where(constants.DB_EQUAL_ID, targetId).
+				Select(append(columns, "updated_at")).Updates(&target).Error
+			if err != nil {
+				return err
+			}
+			if err := tx.Where(constants.DB_EQUAL_ID, targetId).First(&target).Error; err != nil {
+				return err
+			}
+			if err := recordCityChange(tx, &previous, &target); err != nil {
+				return err
+			}
+		}
+
+		source.MergedInto, source.Active = &targetId, false
+		return recordCityChange(tx, &source, nil)
+	})
+	return target, err
+}
+
+// GetCityMergedInto devuelve la ciudad en la que se fusionó id; cero si id no se fusionó
+// o si su destino ya no existe
+func (db *OpenConnection) GetCityMergedInto(ctx context.Context, id uint) (uint, error) {
+	ctx, end := observe(ctx, "city", "GetCityMergedInto")
+	defer end()
+	db.mux.Lock()
+	defer db.mux.Unlock()
+
+	var merged, target entities.City
+	err := db.connection.WithContext(ctx).Unscoped().Where(constants.DB_EQUAL_ID, id).Find(&merged).Error
+	if err != nil || merged.MergedInto == nil {
+		return 0, err
+	}
+	err = db.connection.WithContext(ctx).Where(constants.DB_EQUAL_ID, *merged.MergedInto).Find(&target).Error
+	return target.Id, err
+}
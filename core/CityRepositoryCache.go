@@ -0,0 +1,94 @@
+package core
+
+import (
+	"context"
+
+	"github.com/safe_msvc_city/insfratructure/entities"
+	"github.com/safe_msvc_city/insfratructure/ui/uicore"
+)
+
+// cityCache es la caché de lectura delante del repositorio de ciudades
+type cityCache struct {
+	next   uicore.UICityCore
+	states uicore.UIStatesCore
+}
+
+// withCityCache envuelve el repositorio si hay una caché configurada
+func withCityCache(next uicore.UICityCore) uicore.UICityCore {
+	if cacheBackend == nil {
+		return next
+	}
+	return &cityCache{next: next, states: statesConnection()}
+}
+
+func (r *cityCache) GetCityFindAll(ctx context.Context) ([]entities.City, error) {
+	return cached(ctx, "city", cacheKeyCities, func() ([]entities.City, error) {
+		return r.next.GetCityFindAll(ctx)
+	}, always[[]entities.City])
+}
+
+func (r *cityCache) GetCityFindById(ctx context.Context, id uint) (entities.City, error) {
+	return cached(ctx, "city", cacheKeyCity(id), func() (entities.City, error) {
+		return r.next.GetCityFindById(ctx, id)
+	}, func(city entities.City) bool { return city.Id > 0 })
+}
+
+// GetCityFindByName no usa la caché: valida nombres únicos y debe ver el último estado
+func (r *cityCache) GetCityFindByName(ctx context.Context, id uint, name string) (bool, error) {
+	return r.next.GetCityFindByName(ctx, id, name)
+}
+
+func (r *cityCache) CreateCity(ctx context.Context, city entities.City) (entities.City, error) {
+	result, err := r.next.CreateCity(ctx, city)
+	if err == nil {
+		invalidate(ctx, cityCacheKeys(result.Id)...)
+	}
+	return result, err
+}
+
+func (r *cityCache) UpdateCity(ctx context.Context, id uint, city entities.City) (entities.City, error) {
+	result, err := r.next.UpdateCity(ctx, id, city)
+	if err == nil {
+		invalidate(ctx, cityCacheKeys(id)...)
+	}
+	return result, err
+}
+
+// DeleteCity también invalida los barrios de la ciudad, que se borran en cascada
+func (r *cityCache) DeleteCity(ctx context.Context, id uint) (bool, error) {
+	states, err := r.states.GetStatesFindByIdOfCity(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	result, err := r.next.DeleteCity(ctx, id)
+	if err == nil {
+		keys := cityCacheKeys(id)
+		for _, state := range states {
+			keys = append(keys, cacheKeyState(state.Id))
+		}
+		invalidate(ctx, keys...)
+	}
+	return result, err
+}
+
+// MergeCities invalida las dos ciudades y los barrios que pasan de source a target
+func (r *cityCache) MergeCities(ctx context.Context, sourceId uint, targetId uint) (entities.City, error) {
+	states, err := r.states.GetStatesFindByIdOfCity(ctx, sourceId)
+	if err != nil {
+		return entities.City{}, err
+	}
+	result, err := r.next.MergeCities(ctx, sourceId, targetId)
+	if err == nil {
+		keys := append(cityCacheKeys(sourceId), cityCacheKeys(targetId)...)
+		for _, state := range states {
+			keys = append(keys, cacheKeyState(state.Id))
+		}
+		invalidate(ctx, keys...)
+	}
+	return result, err
+}
+
+// GetCityMergedInto no usa la caché: solo se consulta cuando un id ya no existe
+func (r *cityCache) GetCityMergedInto(ctx context.Context, id uint) (uint, error) {
+	return r.next.GetCityMergedInto(ctx, id)
+}
@@ -0,0 +1,88 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+
+	"github.com/safe_msvc_city/insfratructure/database"
+	"github.com/safe_msvc_city/insfratructure/entities"
+	"github.com/safe_msvc_city/insfratructure/ui/uicore"
+	"gorm.io/gorm"
+)
+
+// exportConnection lee el catálogo fila a fila desde un cursor para no cargarlo
+// completo en memoria; no pasa por la caché ni bloquea los repositorios
+type exportConnection struct {
+	connection *gorm.DB
+}
+
+var (
+	_EXPORT      *exportConnection
+	_EXPORT_ONCE sync.Once
+)
+
+// GetExportInstance devuelve la instancia única que implementa UIExportCore
+func GetExportInstance() uicore.UIExportCore {
+	_EXPORT_ONCE.Do(func() {
+		_EXPORT = &exportConnection{
+			connection: database.GetDatabaseInstance(),
+		}
+	})
+	return _EXPORT
+}
+
+func (db *exportConnection) StreamCities(ctx context.Context, filter uicore.ExportFilter, fn func(entities.City) error) error {
+	ctx, end := observe(ctx, "export", "StreamCities")
+	defer end()
+	query := db.connection.WithContext(ctx).Model(&entities.City{}).Order("cities.id")
+	if filter.ActiveOnly {
+		query = query.Where("cities.active = ?", true)
+	}
+	if len(filter.CityIds) > 0 {
+		query = query.Where("cities.id IN ?", filter.CityIds)
+	}
+	rows, err := query.Rows()
+	if err != nil {
+		return err
+	}
+	return scanExport(db.connection, rows, fn)
+}
+
+// StreamStates exige que la ciudad también esté activa cuando se piden solo activos
+func (db *exportConnection) StreamStates(ctx context.Context, filter uicore.ExportFilter, fn func(entities.StateExport) error) error {
+	ctx, end := observe(ctx, "export", "StreamStates")
+	defer end()
+	query := db.connection.WithContext(ctx).Table("states").
+		Select("states.id, states.name, states.zip_code, states.city_id, cities.name AS city_name, " +
+			"states.active, states.created_at, states.updated_at").
+		Joins("JOIN cities ON cities.id = states.city_id").
+		Where("states.deleted_at IS NULL AND cities.deleted_at IS NULL").
+		Order("states.id")
+	if filter.ActiveOnly {
+		query = query.Where("states.active = ? AND cities.active = ?", true, true)
+	}
+	if len(filter.CityIds) > 0 {
+		query = query.Where("states.city_id IN ?", filter.CityIds)
+	}
+	rows, err := query.Rows()
+	if err != nil {
+		return err
+	}
+	return scanExport(db.connection, rows, fn)
+}
+
+// scanExport recorre el cursor y lo cierra; se detiene en el primer error de fn
+func scanExport[T any](connection *gorm.DB, rows *sql.Rows, fn func(T) error) error {
+	defer rows.Close()
+	for rows.Next() {
+		var row T
+		if err := connection.ScanRows(rows, &row); err != nil {
+			return err
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,158 @@
+package core
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	var_db "github.com/flabio/safe_var_db"
+	"github.com/safe_msvc_city/insfratructure/database"
+	"github.com/safe_msvc_city/insfratructure/entities"
+	"github.com/safe_msvc_city/insfratructure/ui/uicore"
+	"gorm.io/gorm"
+)
+
+// importConnection aplica importaciones masivas en una sola transacción; registra los
+// mismos eventos que los repositorios de ciudades y barrios e invalida la caché
+type importConnection struct {
+	connection *gorm.DB
+}
+
+// importBatchSize es cuántas filas se insertan por sentencia
+const importBatchSize = 500
+
+var (
+	_IMPORT      *importConnection
+	_IMPORT_ONCE sync.Once
+)
+
+// GetImportInstance devuelve la instancia única que implementa UIImportCore
+func GetImportInstance() uicore.UIImportCore {
+	_IMPORT_ONCE.Do(func() {
+		_IMPORT = &importConnection{
+			connection: database.GetDatabaseInstance(),
+		}
+	})
+	return _IMPORT
+}
+
+// ApplyCities devuelve las ciudades creadas con su id. En las actualizaciones se
+// escriben name y active aunque active sea false
+func (db *importConnection) ApplyCities(ctx context.Context, created []entities.City, updated []entities.City) ([]entities.City, error) {
+	ctx, end := observe(ctx, "import", "ApplyCities")
+	defer end()
+	return db.applyCities(ctx, created, updated, "name", "active")
+}
+
+// ApplyOfficialCities es ApplyCities para los municipios oficiales: las
+// actualizaciones también escriben el código y el departamento
+func (db *importConnection) ApplyOfficialCities(ctx context.Context, created []entities.City, updated []entities.City) ([]entities.City, error) {
+	ctx, end := observe(ctx, "import", "ApplyOfficialCities")
+	defer end()
+	return db.applyCities(ctx, created, updated, "name", "active", "code", "department_code", "department")
+}
+
+// ApplyGeoCities es ApplyCities para las ciudades de GeoNames: las actualizaciones
+// escriben las coordenadas, la población, la zona horaria y los nombres alternativos.
+// Las creaciones se insertan por lotes porque un volcado puede traer miles de ciudades
+func (db *importConnection) ApplyGeoCities(ctx context.Context, created []entities.City, updated []entities.City) ([]entities.City, error) {
+	ctx, end := observe(ctx, "import", "ApplyGeoCities")
+	defer end()
+	return db.applyCities(ctx, created, updated, "name", "department_code", "department", "geoname_id",
+		"country_code", "latitude", "longitude", "population", "timezone", "alternate_names")
+}
+
+// applyCities crea y actualiza en una transacción; columns son las que se escriben
+// en cada actualización además de updated_at
+func (db *importConnection) applyCities(ctx context.Context, created []entities.City, updated []entities.City, columns ...string) ([]entities.City, error) {
+	now := time.Now()
+	var keys []string
+	err := db.connection.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		keys = keys[:0]
+		for i := range created {
+			created[i].UpdatedAt = &now
+		}
+		if len(created) > 0 {
+			if err := tx.CreateInBatches(&created, importBatchSize).Error; err != nil {
+				return err
+			}
+		}
+		for i := range created {
+			if err := recordCityChange(tx, nil, &created[i]); err != nil {
+				return err
+			}
+			keys = append(keys, cityCacheKeys(created[i].Id)...)
+		}
+		for _, city := range updated {
+			var previous, current entities.City
+			if err := tx.Where(var_db.DB_EQUAL_ID, city.Id).Find(&previous).Error; err != nil {
+				return err
+			}
+			city.UpdatedAt = &now
+			err := tx.Model(&entities.City{}).Where(var_db.DB_EQUAL_ID, city.Id).
+				Select(append(columns, "updated_at")).Updates(&city).Error
+			if err != nil {
+				return err
+			}
+			if err := tx.Where(var_db.DB_EQUAL_ID, city.Id).Find(&current).Error; err != nil {
+				return err
+			}
+			if err := recordCityChange(tx, &previous, &current); err != nil {
+				return err
+			}
+			keys = append(keys, cityCacheKeys(city.Id)...)
+		}
+		return nil
+	})
+	if err == nil && cacheBackend != nil {
+		invalidate(ctx, keys...)
+	}
+	return created, err
+}
+
+// ApplyStates es el equivalente de ApplyCities para barrios; una actualización puede
+// cambiar el barrio de ciudad
+func (db *importConnection) ApplyStates(ctx context.Context, created []entities.States, updated []entities.States) ([]entities.States, error) {
+	ctx, end := observe(ctx, "import", "ApplyStates")
+	defer end()
+	now := time.Now()
+	var keys []string
+	err := db.connection.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		keys = keys[:0]
+		for i := range created {
+			created[i].UpdatedAt = &now
+			if err := tx.Omit("City").Create(&created[i]).Error; err != nil {
+				return err
+			}
+			if err := recordStateChange(tx, nil, &created[i]); err != nil {
+				return err
+			}
+			keys = append(keys, stateCacheKeys(created[i].Id, created[i].CityId)...)
+		}
+		for _, state := range updated {
+			var previous, current entities.States
+			if err := tx.Where(var_db.DB_EQUAL_ID, state.Id).Find(&previous).Error; err != nil {
+				return err
+			}
+			state.UpdatedAt = &now
+			err := tx.Model(&entities.States{}).Where(var_db.DB_EQUAL_ID, state.Id).
+				Select("name", "zip_code", "city_id", "active", "updated_at").Updates(&state).Error
+			if err != nil {
+				return err
+			}
+			if err := tx.Where(var_db.DB_EQUAL_ID, state.Id).Find(&current).Error; err != nil {
+				return err
+			}
+			if err := recordStateChange(tx, &previous, &current); err != nil {
+				return err
+			}
+			keys = append(keys, stateCacheKeys(state.Id, state.CityId)...)
+			keys = append(keys, cacheKeyStatesOfCity(previous.CityId))
+		}
+		return nil
+	})
+	if err == nil && cacheBackend != nil {
+		invalidate(ctx, keys...)
+	}
+	return created, err
+}
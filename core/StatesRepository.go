@@ -1,7 +1,10 @@
 package core
 
 import (
+	"context"
+	"errors"
 	"sync"
+	"time"
 
 	var_db "github.com/flabio/safe_var_db"
 	"github.com/safe_msvc_city/insfratructure/database"
@@ -9,6 +12,16 @@ import (
 	"github.com/safe_msvc_city/insfratructure/ui/uicore"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Rechazos de MoveState, comprobados dentro de la transacción con la ciudad de destino
+// bloqueada para que no cambie entre la validación y la escritura
+var (
+	ErrMoveSameCity     = errors.New("el barrio ya pertenece a la ciudad")
+	ErrMoveCityMissing  = errors.New("la ciudad de destino no existe")
+	ErrMoveCityInactive = errors.New("la ciudad de destino está inactiva")
+	ErrMoveNameTaken    = errors.New("la ciudad de destino ya tiene un barrio con ese nombre")
 )
 
 // OpenConnection representa una conexión abierta a la base de datos con un mutex para sincronización
@@ -23,8 +36,15 @@ var (
 	_ONCE sync.Once
 )
 
-// GetStatesInstance devuelve una instancia única de OpenConnection que implementa UIStatesCore
+// GetStatesInstance devuelve una instancia única de OpenConnection que implementa UIStatesCore,
+// detrás de la caché de lectura si está configurada y del snapshot para cuando la base
+// de datos no esté disponible
 func GetStatesInstance() uicore.UIStatesCore {
+	return statesSnapshot{withStatesCache(statesConnection())}
+}
+
+// statesConnection devuelve la instancia única sin caché
+func statesConnection() *openConnection {
 	_ONCE.Do(func() {
 		_OPEN = &openConnection{
 			connection: database.GetDatabaseInstance(),
@@ -34,72 +54,265 @@ func GetStatesInstance() uicore.UIStatesCore {
 }
 
 // GetStatesFindAll obtiene todos los estados
-func (db *openConnection) GetStatesFindAll() ([]entities.States, error) {
+func (db *openConnection) GetStatesFindAll(ctx context.Context) ([]entities.States, error) {
+	ctx, end := observe(ctx, "states", "GetStatesFindAll")
+	defer end()
 	var states []entities.States
 	db.mux.Lock()
 	defer db.mux.Unlock()
 
-	result := db.connection.Preload("City").Order(var_db.DB_ORDER_DESC).Find(&states)
+	result := db.connection.WithContext(ctx).Preload("City").Order(var_db.DB_ORDER_DESC).Find(&states)
 	return states, result.Error
 }
 
 // GetStatesFindById obtiene un estado por su ID
-func (db *openConnection) GetStatesFindById(id uint) (entities.States, error) {
+func (db *openConnection) GetStatesFindById(ctx context.Context, id uint) (entities.States, error) {
+	ctx, end := observe(ctx, "states", "GetStatesFindById")
+	defer end()
 	var state entities.States
 	db.mux.Lock()
 	defer db.mux.Unlock()
 
-	result := db.connection.Where(var_db.DB_EQUAL_ID, id).First(&state)
+	result := db.connection.WithContext(ctx).Where(var_db.DB_EQUAL_ID, id).First(&state)
 	return state, result.Error
 }
 
 // GetStatesFindByIdOfCity obtiene estados por el ID de la ciudad
-func (db *openConnection) GetStatesFindByIdOfCity(id uint) ([]entities.States, error) {
+func (db *openConnection) GetStatesFindByIdOfCity(ctx context.Context, id uint) ([]entities.States, error) {
+	ctx, end := observe(ctx, "states", "GetStatesFindByIdOfCity")
+	defer end()
 	var states []entities.States
 	db.mux.Lock()
 	defer db.mux.Unlock()
 
-	result := db.connection.Preload("City").Where(var_db.DB_EQUAL_CITY_ID, id).Find(&states)
+	result := db.connection.WithContext(ctx).Preload("City").Where(var_db.DB_EQUAL_CITY_ID, id).Find(&states)
 	return states, result.Error
 }
 
 // CreateStates crea un nuevo estado
-func (db *openConnection) CreateStates(state entities.States) (entities.States, error) {
+func (db *openConnection) CreateStates(ctx context.Context, state entities.States) (entities.States, error) {
+	ctx, end := observe(ctx, "states", "CreateStates")
+	defer end()
 	db.mux.Lock()
 	defer db.mux.Unlock()
 
-	err := db.connection.Create(&state).Error
+	// Un trigger también fija updated_at; se asigna aquí para devolverlo en la respuesta
+	now := time.Now()
+	state.UpdatedAt = &now
+	err := db.connection.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&state).Error; err != nil {
+			return err
+		}
+		return recordStateChange(tx, nil, &state)
+	})
 	return state, err
 }
 
 // UpdateStates actualiza un estado existente
-func (db *openConnection) UpdateStates(id uint, state entities.States) (entities.States, error) {
+func (db *openConnection) UpdateStates(ctx context.Context, id uint, state entities.States) (entities.States, error) {
+	ctx, end := observe(ctx, "states", "UpdateStates")
+	defer end()
 	db.mux.Lock()
 	defer db.mux.Unlock()
 
-	err := db.connection.Where(var_db.DB_EQUAL_ID, id).Updates(&state).Error
+	now := time.Now()
+	state.UpdatedAt = &now
+	err := db.connection.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var previous, current entities.States
+		if err := tx.Where(var_db.DB_EQUAL_ID, id).Find(&previous).Error; err != nil {
+			return err
+		}
+		if err := tx.Where(var_db.DB_EQUAL_ID, id).Updates(&state).Error; err != nil {
+			return err
+		}
+		if previous.Id == 0 {
+			return nil
+		}
+		if err := tx.Where(var_db.DB_EQUAL_ID, id).Find(&current).Error; err != nil {
+			return err
+		}
+		if err := recordStateMove(tx, previous, current, ""); err != nil {
+			return err
+		}
+		return recordStateChange(tx, &previous, &current)
+	})
 	return state, err
 }
 
 // DeleteStates elimina un estado por su ID
-func (db *openConnection) DeleteStates(id uint) (bool, error) {
+func (db *openConnection) DeleteStates(ctx context.Context, id uint) (bool, error) {
+	ctx, end := observe(ctx, "states", "DeleteStates")
+	defer end()
 	db.mux.Lock()
 	defer db.mux.Unlock()
 
-	result := db.connection.Where(var_db.DB_EQUAL_ID, id).Delete(&entities.States{})
-	return result.RowsAffected > 0, result.Error
+	deleted := false
+	err := db.connection.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var previous entities.States
+		if err := tx.Where(var_db.DB_EQUAL_ID, id).Find(&previous).Error; err != nil {
+			return err
+		}
+		result := tx.Unscoped().Where(var_db.DB_EQUAL_ID, id).Delete(&entities.States{})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		deleted = true
+		return recordStateChange(tx, &previous, nil)
+	})
+	return deleted && err == nil, err
 }
 
-// GetStatesFindByName verifica si existe un estado por nombre, excluyendo un ID específico si se proporciona
-func (db *openConnection) GetStatesFindByName(id uint, name string) (bool, error) {
+// GetStatesFindByName verifica si existe un estado por nombre, sin distinguir mayúsculas,
+// excluyendo un ID específico si se proporciona
+func (db *openConnection) GetStatesFindByName(ctx context.Context, id uint, name string) (bool, error) {
+	ctx, end := observe(ctx, "states", "GetStatesFindByName")
+	defer end()
 	var state entities.States
 	db.mux.Lock()
 	defer db.mux.Unlock()
 
-	query := db.connection.Where(var_db.DB_EQUAL_NAME, name)
+	query := db.connection.WithContext(ctx).Where("LOWER(name) = LOWER(?)", name)
 	if id > 0 {
 		query = query.Where(var_db.DB_DIFF_ID, id)
 	}
 	result := query.First(&state)
 	return result.RowsAffected > 0, result.Error
 }
+
+// MoveState cambia el barrio de ciudad en una transacción que guarda el registro del
+// historial y los eventos StateUpdated y StateMoved. La ciudad de destino se bloquea
+// con FOR UPDATE, que también espera a las altas y cambios de barrios que la
+// referencian, y se vuelve a revisar que exista, esté activa y no tenga un barrio con
+// el mismo nombre; si no, devuelve uno de los ErrMove*
+func (db *openConnection) MoveState(ctx context.Context, id uint, cityId uint, reason string) (entities.States, error) {
+	ctx, end := observe(ctx, "states", "MoveState")
+	defer end()
+	db.mux.Lock()
+	defer db.mux.Unlock()
+
+	var current entities.States
+	now := time.Now()
+	err := db.connection.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var previous entities.States
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where(var_db.DB_EQUAL_ID, id).First(&previous).Error; err != nil {
+			return err
+		}
+		if previous.CityId == cityId {
+			return ErrMoveSameCity
+		}
+		var city entities.City
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where(var_db.DB_EQUAL_ID, cityId).Find(&city).Error; err != nil {
+			return err
+		}
+		switch {
+		case city.Id == 0:
+			return ErrMoveCityMissing
+		case !city.Active:
+			return ErrMoveCityInactive
+		}
+		var sibling entities.States
+		err := tx.Where("city_id = ? AND LOWER(name) = LOWER(?) AND id <> ?", cityId, previous.Name, id).
+			Limit(1).Find(&sibling).Error
+		if err != nil {
+			return err
+		}
+		if sibling.Id != 0 {
+			return ErrMoveNameTaken
+		}
+		err = tx.Model(&entities.States{}).Where(var_db.DB_EQUAL_ID, id).
+			Updates(map[string]interface{}{"city_id": cityId, "updated_at": now}).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Where(var_db.DB_EQUAL_ID, id).First(&current).Error; err != nil {
+			return err
+		}
+		if err := recordStateMove(tx, previous, current, reason); err != nil {
+			return err
+		}
+		return recordStateChange(tx, &previous, &current)
+	})
+	return current, err
+}
+
+// GetStateMoves devuelve el historial de cambios de ciudad del barrio, del más reciente
+// al más antiguo
+func (db *openConnection) GetStateMoves(ctx context.Context, id uint) ([]entities.StateMove, error) {
+	ctx, end := observe(ctx, "states", "GetStateMoves")
+	defer end()
+	moves := []entities.StateMove{}
+	result := db.connection.WithContext(ctx).Where("state_id = ?", id).Order("moved_at DESC, id DESC").Find(&moves)
+	return moves, result.Error
+}
+
+// recordStateMove guarda en el historial el cambio de ciudad de un barrio, si lo hubo;
+// también lo usan las actualizaciones que cambian city_id sin pasar por MoveState
+func recordStateMove(tx *gorm.DB, previous entities.States, current entities.States, reason string) error {
+	if previous.CityId == current.CityId {
+		return nil
+	}
+	move := entities.StateMove{
+		StateId:    current.Id,
+		FromCityId: previous.CityId,
+		ToCityId:   current.CityId,
+		Reason:     reason,
+		MovedAt:    time.Now(),
+	}
+	return tx.Create(&move).Error
+}
+
+// MergeStates fusiona source en target: apunta a target las redirecciones que llegaban a
+// source y borra source lógicamente dejando merged_into. Source publica StateDeleted con
+// merged_into en el contenido
+func (db *openConnection) MergeStates(ctx context.Context, sourceId uint, targetId uint) (entities.States, error) {
+	ctx, end := observe(ctx, "states", "MergeStates")
+	defer end()
+	db.mux.Lock()
+	defer db.mux.Unlock()
+
+	var target entities.States
+	now := time.Now()
+	err := db.connection.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var source entities.States
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where(var_db.DB_EQUAL_ID, sourceId).First(&source).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where(var_db.DB_EQUAL_ID, targetId).First(&target).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Unscoped().Model(&entities.States{}).Where("merged_into = ?", sourceId).Update("merged_into", targetId).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Model(&entities.States{}).Where(var_db.DB_EQUAL_ID, sourceId).Updates(map[string]interface{}{
+			"merged_into": targetId,
+			"active":      false,
+			"updated_at":  now,
+			"deleted_at":  now,
+		}).Error
+		if err != nil {
+			return err
+		}
+		source.MergedInto, source.Active = &targetId, false
+		return recordStateChange(tx, &source, nil)
+	})
+	return target, err
+}
+
+// GetStateMergedInto es el equivalente de GetCityMergedInto para barrios
+func (db *openConnection) GetStateMergedInto(ctx context.Context, id uint) (uint, error) {
+	ctx, end := observe(ctx, "states", "GetStateMergedInto")
+	defer end()
+	db.mux.Lock()
+	defer db.mux.Unlock()
+
+	var merged, target entities.States
+	err := db.connection.WithContext(ctx).Unscoped().Where(var_db.DB_EQUAL_ID, id).Find(&merged).Error
+	if err != nil || merged.MergedInto == nil {
+		return 0, err
+	}
+	err = db.connection.WithContext(ctx).Where(var_db.DB_EQUAL_ID, *merged.MergedInto).Find(&target).Error
+	return target.Id, err
+}
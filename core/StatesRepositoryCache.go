@@ -0,0 +1,116 @@
+package core
+
+import (
+	"context"
+
+	"github.com/safe_msvc_city/insfratructure/entities"
+	"github.com/safe_msvc_city/insfratructure/ui/uicore"
+)
+
+// statesCache es la caché de lectura delante del repositorio de barrios
+type statesCache struct {
+	next uicore.UIStatesCore
+}
+
+// withStatesCache envuelve el repositorio si hay una caché configurada
+func withStatesCache(next uicore.UIStatesCore) uicore.UIStatesCore {
+	if cacheBackend == nil {
+		return next
+	}
+	return &statesCache{next: next}
+}
+
+func (r *statesCache) GetStatesFindAll(ctx context.Context) ([]entities.States, error) {
+	return cached(ctx, "states", cacheKeyStates, func() ([]entities.States, error) {
+		return r.next.GetStatesFindAll(ctx)
+	}, always[[]entities.States])
+}
+
+func (r *statesCache) GetStatesFindById(ctx context.Context, id uint) (entities.States, error) {
+	return cached(ctx, "states", cacheKeyState(id), func() (entities.States, error) {
+		return r.next.GetStatesFindById(ctx, id)
+	}, func(state entities.States) bool { return state.Id > 0 })
+}
+
+func (r *statesCache) GetStatesFindByIdOfCity(ctx context.Context, id uint) ([]entities.States, error) {
+	return cached(ctx, "states", cacheKeyStatesOfCity(id), func() ([]entities.States, error) {
+		return r.next.GetStatesFindByIdOfCity(ctx, id)
+	}, always[[]entities.States])
+}
+
+// GetStatesFindByName no usa la caché: valida nombres únicos y debe ver el último estado
+func (r *statesCache) GetStatesFindByName(ctx context.Context, id uint, name string) (bool, error) {
+	return r.next.GetStatesFindByName(ctx, id, name)
+}
+
+func (r *statesCache) CreateStates(ctx context.Context, state entities.States) (entities.States, error) {
+	result, err := r.next.CreateStates(ctx, state)
+	if err == nil {
+		invalidate(ctx, stateCacheKeys(result.Id, result.CityId)...)
+	}
+	return result, err
+}
+
+// UpdateStates invalida la ciudad anterior y la nueva, por si el barrio cambió de ciudad
+func (r *statesCache) UpdateStates(ctx context.Context, id uint, state entities.States) (entities.States, error) {
+	previous, err := r.next.GetStatesFindById(ctx, id)
+	if err != nil && !IsNotFound(err) {
+		return state, err
+	}
+	result, err := r.next.UpdateStates(ctx, id, state)
+	if err == nil {
+		keys := stateCacheKeys(id, state.CityId)
+		if previous.CityId != state.CityId {
+			keys = append(keys, cacheKeyStatesOfCity(previous.CityId))
+		}
+		invalidate(ctx, keys...)
+	}
+	return result, err
+}
+
+func (r *statesCache) DeleteStates(ctx context.Context, id uint) (bool, error) {
+	previous, err := r.next.GetStatesFindById(ctx, id)
+	if err != nil && !IsNotFound(err) {
+		return false, err
+	}
+	result, err := r.next.DeleteStates(ctx, id)
+	if err == nil {
+		invalidate(ctx, stateCacheKeys(id, previous.CityId)...)
+	}
+	return result, err
+}
+
+// MoveState invalida el barrio y las listas de la ciudad de origen y la de destino
+func (r *statesCache) MoveState(ctx context.Context, id uint, cityId uint, reason string) (entities.States, error) {
+	previous, err := r.next.GetStatesFindById(ctx, id)
+	if err != nil {
+		return previous, err
+	}
+	result, err := r.next.MoveState(ctx, id, cityId, reason)
+	if err == nil {
+		invalidate(ctx, append(stateCacheKeys(id, cityId), cacheKeyStatesOfCity(previous.CityId))...)
+	}
+	return result, err
+}
+
+// GetStateMoves no usa la caché: el historial se consulta poco
+func (r *statesCache) GetStateMoves(ctx context.Context, id uint) ([]entities.StateMove, error) {
+	return r.next.GetStateMoves(ctx, id)
+}
+
+func (r *statesCache) MergeStates(ctx context.Context, sourceId uint, targetId uint) (entities.States, error) {
+	source, err := r.next.GetStatesFindById(ctx, sourceId)
+	if err != nil {
+		return entities.States{}, err
+	}
+	result, err := r.next.MergeStates(ctx, sourceId, targetId)
+	if err == nil {
+		invalidate(ctx, append(stateCacheKeys(sourceId, source.CityId), stateCacheKeys(targetId, result.CityId)...)...)
+	}
+	return result, err
+}
+
+// GetStateMergedInto no usa la caché: solo se consulta cuando un id ya no existe
+func (r *statesCache) GetStateMergedInto(ctx context.Context, id uint) (uint, error) {
+	return r.next.GetStateMergedInto(ctx, id)
+}
@@ -0,0 +1,77 @@
+package core
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	var_db "github.com/flabio/safe_var_db"
+	"github.com/safe_msvc_city/insfratructure/database"
+	"github.com/safe_msvc_city/insfratructure/entities"
+	"github.com/safe_msvc_city/insfratructure/ui/uicore"
+	"gorm.io/gorm"
+)
+
+// changedSince filtra por updated_at, que los triggers mantienen en cada escritura.
+// updated_at no tiene zona horaria, así que el parámetro se compara como timestamptz
+const changedSince = "updated_at >= CAST(? AS timestamptz)"
+
+// syncConnection es el repositorio de la sincronización incremental
+type syncConnection struct {
+	connection *gorm.DB
+}
+
+var (
+	_SYNC      *syncConnection
+	_SYNC_ONCE sync.Once
+)
+
+// GetSyncInstance devuelve la instancia única que implementa UISyncCore
+func GetSyncInstance() uicore.UISyncCore {
+	_SYNC_ONCE.Do(func() {
+		_SYNC = &syncConnection{
+			connection: database.GetDatabaseInstance(),
+		}
+	})
+	return _SYNC
+}
+
+func (db *syncConnection) Now(ctx context.Context) (time.Time, error) {
+	ctx, end := observe(ctx, "sync", "Now")
+	defer end()
+	var now time.Time
+	err := db.connection.WithContext(ctx).Raw("SELECT now()").Scan(&now).Error
+	return now, err
+}
+
+func (db *syncConnection) GetCitiesChangedSince(ctx context.Context, since time.Time) ([]entities.City, error) {
+	ctx, end := observe(ctx, "sync", "GetCitiesChangedSince")
+	defer end()
+	var cities []entities.City
+	err := db.connection.WithContext(ctx).Where(changedSince, since).Order(var_db.DB_ORDER_DESC).Find(&cities).Error
+	return cities, err
+}
+
+func (db *syncConnection) GetStatesChangedSince(ctx context.Context, since time.Time) ([]entities.States, error) {
+	ctx, end := observe(ctx, "sync", "GetStatesChangedSince")
+	defer end()
+	var states []entities.States
+	err := db.connection.WithContext(ctx).Where(changedSince, since).Order(var_db.DB_ORDER_DESC).Find(&states).Error
+	return states, err
+}
+
+func (db *syncConnection) GetDeletionsSince(ctx context.Context, since time.Time) ([]entities.Deletion, error) {
+	ctx, end := observe(ctx, "sync", "GetDeletionsSince")
+	defer end()
+	var deletions []entities.Deletion
+	err := db.connection.WithContext(ctx).Where("deleted_at >= ?", since).Order("id").Find(&deletions).Error
+	return deletions, err
+}
+
+// PruneDeletions borra las marcas de eliminación anteriores a before
+func (db *syncConnection) PruneDeletions(ctx context.Context, before time.Time) (int64, error) {
+	ctx, end := observe(ctx, "sync", "PruneDeletions")
+	defer end()
+	result := db.connection.WithContext(ctx).Where("deleted_at < ?", before).Delete(&entities.Deletion{})
+	return result.RowsAffected, result.Error
+}
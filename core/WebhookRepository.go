@@ -0,0 +1,201 @@
+package core
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	var_db "github.com/flabio/safe_var_db"
+	"github.com/safe_msvc_city/insfratructure/database"
+	"github.com/safe_msvc_city/insfratructure/entities"
+	"github.com/safe_msvc_city/insfratructure/ui/uicore"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// webhookConnection es el repositorio de suscripciones y entregas de webhooks
+type webhookConnection struct {
+	connection *gorm.DB
+}
+
+var (
+	_WEBHOOK      *webhookConnection
+	_WEBHOOK_ONCE sync.Once
+)
+
+// GetWebhookInstance devuelve la instancia única que implementa UIWebhookCore
+func GetWebhookInstance() uicore.UIWebhookCore {
+	_WEBHOOK_ONCE.Do(func() {
+		_WEBHOOK = &webhookConnection{
+			connection: database.GetDatabaseInstance(),
+		}
+	})
+	return _WEBHOOK
+}
+
+func (db *webhookConnection) GetWebhookFindAll(ctx context.Context) ([]entities.WebhookSubscription, error) {
+	ctx, end := observe(ctx, "webhook", "GetWebhookFindAll")
+	defer end()
+	var subscriptions []entities.WebhookSubscription
+	err := db.connection.WithContext(ctx).Order(var_db.DB_ORDER_DESC).Find(&subscriptions).Error
+	return subscriptions, err
+}
+
+func (db *webhookConnection) GetWebhookFindById(ctx context.Context, id uint) (entities.WebhookSubscription, error) {
+	ctx, end := observe(ctx, "webhook", "GetWebhookFindById")
+	defer end()
+	var subscription entities.WebhookSubscription
+	err := db.connection.WithContext(ctx).Where(var_db.DB_EQUAL_ID, id).Find(&subscription).Error
+	return subscription, err
+}
+
+// GetWebhookFindActive devuelve las suscripciones activas; el filtro por tipo y ciudad se hace al encolar
+func (db *webhookConnection) GetWebhookFindActive(ctx context.Context) ([]entities.WebhookSubscription, error) {
+	ctx, end := observe(ctx, "webhook", "GetWebhookFindActive")
+	defer end()
+	var subscriptions []entities.WebhookSubscription
+	err := db.connection.WithContext(ctx).Where("active = ?", true).Find(&subscriptions).Error
+	return subscriptions, err
+}
+
+func (db *webhookConnection) CreateWebhook(ctx context.Context, subscription entities.WebhookSubscription) (entities.WebhookSubscription, error) {
+	ctx, end := observe(ctx, "webhook", "CreateWebhook")
+	defer end()
+	err := db.connection.WithContext(ctx).Create(&subscription).Error
+	return subscription, err
+}
+
+// UpdateWebhook reemplaza todos los campos editables, incluidos los valores vacíos
+func (db *webhookConnection) UpdateWebhook(ctx context.Context, id uint, subscription entities.WebhookSubscription) (entities.WebhookSubscription, error) {
+	ctx, end := observe(ctx, "webhook", "UpdateWebhook")
+	defer end()
+	err := db.connection.WithContext(ctx).Model(&subscription).Where(var_db.DB_EQUAL_ID, id).
+		Select("url", "secret", "event_types", "city_ids", "active", "updated_at").
+		Updates(&subscription).Error
+	return subscription, err
+}
+
+func (db *webhookConnection) DeleteWebhook(ctx context.Context, id uint) (bool, error) {
+	ctx, end := observe(ctx, "webhook", "DeleteWebhook")
+	defer end()
+	result := db.connection.WithContext(ctx).Where(var_db.DB_EQUAL_ID, id).Delete(&entities.WebhookSubscription{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// EnqueueDeliveries crea las entregas pendientes; las que ya existen para la misma
+// suscripción y evento se ignoran porque el relay puede publicar un evento más de una vez
+func (db *webhookConnection) EnqueueDeliveries(ctx context.Context, deliveries []entities.WebhookDelivery) error {
+	ctx, end := observe(ctx, "webhook", "EnqueueDeliveries")
+	defer end()
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return db.connection.WithContext(ctx).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Omit("Subscription", "Log").
+		Create(&deliveries).Error
+}
+
+func (db *webhookConnection) GetDeliveryFindById(ctx context.Context, id uint) (entities.WebhookDelivery, error) {
+	ctx, end := observe(ctx, "webhook", "GetDeliveryFindById")
+	defer end()
+	var delivery entities.WebhookDelivery
+	err := db.connection.WithContext(ctx).Preload("Log").Where(var_db.DB_EQUAL_ID, id).Find(&delivery).Error
+	return delivery, err
+}
+
+// GetDeliveriesBySubscription devuelve el log de entregas de una suscripción, las más recientes primero
+func (db *webhookConnection) GetDeliveriesBySubscription(ctx context.Context, subscriptionId uint, limit int) ([]entities.WebhookDelivery, error) {
+	ctx, end := observe(ctx, "webhook", "GetDeliveriesBySubscription")
+	defer end()
+	var deliveries []entities.WebhookDelivery
+	err := db.connection.WithContext(ctx).Preload("Log").
+		Where("subscription_id = ?", subscriptionId).
+		Order(var_db.DB_ORDER_DESC).Limit(limit).
+		Find(&deliveries).Error
+	return deliveries, err
+}
+
+// GetDeadDeliveries devuelve la lista de entregas que agotaron los reintentos
+func (db *webhookConnection) GetDeadDeliveries(ctx context.Context, limit int) ([]entities.WebhookDelivery, error) {
+	ctx, end := observe(ctx, "webhook", "GetDeadDeliveries")
+	defer end()
+	var deliveries []entities.WebhookDelivery
+	err := db.connection.WithContext(ctx).Preload("Log").
+		Where("status = ?", entities.DeliveryDead).
+		Order(var_db.DB_ORDER_DESC).Limit(limit).
+		Find(&deliveries).Error
+	return deliveries, err
+}
+
+// ReplayDelivery devuelve una entrega muerta a la cola con los intentos en cero;
+// false si no existe o no está en la lista de muertas
+func (db *webhookConnection) ReplayDelivery(ctx context.Context, id uint) (bool, error) {
+	ctx, end := observe(ctx, "webhook", "ReplayDelivery")
+	defer end()
+	result := db.connection.WithContext(ctx).Model(&entities.WebhookDelivery{}).
+		Where(var_db.DB_EQUAL_ID, id).
+		Where("status = ?", entities.DeliveryDead).
+		Updates(map[string]any{
+			"status":          entities.DeliveryPending,
+			"attempts":        0,
+			"next_attempt_at": time.Now(),
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// ClaimDueDeliveries toma hasta limit entregas vencidas y las reserva durante lease
+// para que otra réplica no las envíe a la vez
+func (db *webhookConnection) ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]entities.WebhookDelivery, error) {
+	ctx, end := observe(ctx, "webhook", "ClaimDueDeliveries")
+	defer end()
+	var deliveries []entities.WebhookDelivery
+	err := db.connection.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", entities.DeliveryPending, time.Now()).
+			Order("next_attempt_at").Limit(limit).
+			Find(&deliveries).Error
+		if err != nil || len(deliveries) == 0 {
+			return err
+		}
+		ids := make([]uint, len(deliveries))
+		for i, delivery := range deliveries {
+			ids[i] = delivery.Id
+		}
+		return tx.Model(&entities.WebhookDelivery{}).Where("id IN ?", ids).
+			Update("next_attempt_at", time.Now().Add(lease)).Error
+	})
+	if err != nil || len(deliveries) == 0 {
+		return deliveries, err
+	}
+	// La suscripción se carga fuera del bloqueo para tener la URL y el secreto vigentes
+	for i := range deliveries {
+		err = db.connection.WithContext(ctx).Where(var_db.DB_EQUAL_ID, deliveries[i].SubscriptionId).
+			Find(&deliveries[i].Subscription).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+	return deliveries, nil
+}
+
+// RecordDeliveryAttempt guarda el resultado de un intento y agrega la línea al log
+func (db *webhookConnection) RecordDeliveryAttempt(ctx context.Context, delivery entities.WebhookDelivery, attempt entities.WebhookAttempt) error {
+	ctx, end := observe(ctx, "webhook", "RecordDeliveryAttempt")
+	defer end()
+	return db.connection.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&entities.WebhookDelivery{}).Where(var_db.DB_EQUAL_ID, delivery.Id).Updates(map[string]any{
+			"status":           delivery.Status,
+			"attempts":         delivery.Attempts,
+			"next_attempt_at":  delivery.NextAttemptAt,
+			"last_status_code": delivery.LastStatusCode,
+			"last_error":       delivery.LastError,
+			"delivered_at":     delivery.DeliveredAt,
+		}).Error
+		if err != nil {
+			return err
+		}
+		attempt.DeliveryId = delivery.Id
+		return tx.Create(&attempt).Error
+	})
+}
@@ -0,0 +1,132 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/safe_msvc_city/insfratructure/cache"
+	"github.com/safe_msvc_city/insfratructure/listener"
+	"github.com/safe_msvc_city/insfratructure/logging"
+	"github.com/safe_msvc_city/insfratructure/metrics"
+	"gorm.io/gorm"
+)
+
+// Claves de la caché de lectura
+const (
+	cacheKeyCities = "city:all"
+	cacheKeyStates = "states:all"
+)
+
+var (
+	cacheBackend cache.Backend
+	cacheTTL     time.Duration
+)
+
+// ConfigureCache activa la caché de lectura delante de los repositorios. Debe
+// llamarse antes de GetCityInstance y GetStatesInstance; con backend nil no hay caché
+func ConfigureCache(backend cache.Backend, ttl time.Duration) {
+	cacheBackend = backend
+	cacheTTL = ttl
+}
+
+func cacheKeyCity(id uint) string {
+	return fmt.Sprintf("city:id:%d", id)
+}
+
+func cacheKeyState(id uint) string {
+	return fmt.Sprintf("states:id:%d", id)
+}
+
+func cacheKeyStatesOfCity(cityId uint) string {
+	return fmt.Sprintf("states:city:%d", cityId)
+}
+
+// cityCacheKeys son las claves afectadas por un cambio en la ciudad id; incluye las
+// listas de barrios porque estas precargan su ciudad
+func cityCacheKeys(id uint) []string {
+	return []string{cacheKeyCity(id), cacheKeyCities, cacheKeyStatesOfCity(id), cacheKeyStates}
+}
+
+// stateCacheKeys son las claves afectadas por un cambio en el barrio id de la ciudad cityId
+func stateCacheKeys(id uint, cityId uint) []string {
+	return []string{cacheKeyState(id), cacheKeyStatesOfCity(cityId), cacheKeyStates}
+}
+
+// cached lee key de la caché o la carga con load; el resultado se guarda solo si
+// store lo acepta (por ejemplo, para no guardar ids inexistentes). Un fallo del
+// backend no falla la lectura: se registra y se va a la base de datos
+func cached[T any](ctx context.Context, name string, key string, load func() (T, error), store func(T) bool) (T, error) {
+	var value T
+	data, ok, err := cacheBackend.Get(ctx, key)
+	if err != nil {
+		logging.FromContext(ctx).Warn("cache read failed", "key", key, "error", err)
+	}
+	if ok && json.Unmarshal(data, &value) == nil {
+		metrics.CacheHit(name)
+		return value, nil
+	}
+	metrics.CacheMiss(name)
+
+	value, err = load()
+	if err != nil || !store(value) {
+		return value, err
+	}
+	data, err = json.Marshal(value)
+	if err == nil {
+		err = cacheBackend.Set(ctx, key, data, cacheTTL)
+	}
+	if err != nil {
+		logging.FromContext(ctx).Warn("cache write failed", "key", key, "error", err)
+	}
+	return value, nil
+}
+
+// invalidate borra las claves indicadas
+func invalidate(ctx context.Context, keys ...string) {
+	if err := cacheBackend.Delete(ctx, keys...); err != nil {
+		logging.FromContext(ctx).Error("cache invalidation failed", "keys", keys, "error", err)
+	}
+}
+
+// CacheInvalidator devuelve el receptor de cambios que mantiene la caché al día con
+// las escrituras de otras réplicas; nil si no hay caché configurada
+func CacheInvalidator() listener.Handler {
+	if cacheBackend == nil {
+		return nil
+	}
+	return cacheInvalidator{}
+}
+
+type cacheInvalidator struct{}
+
+func (cacheInvalidator) Changed(ctx context.Context, change listener.Change) {
+	switch change.Entity {
+	case "city":
+		invalidate(ctx, cityCacheKeys(change.Id)...)
+	case "state":
+		keys := stateCacheKeys(change.Id, change.CityId)
+		if change.OldCityId != 0 && change.OldCityId != change.CityId {
+			keys = append(keys, cacheKeyStatesOfCity(change.OldCityId))
+		}
+		invalidate(ctx, keys...)
+	}
+}
+
+// Reconnected vacía la caché porque no se sabe qué cambió mientras no hubo conexión
+func (cacheInvalidator) Reconnected(ctx context.Context) {
+	if err := cacheBackend.Flush(ctx); err != nil {
+		logging.FromContext(ctx).Error("cache flush failed", "error", err)
+	}
+}
+
+func always[T any](T) bool {
+	return true
+}
+
+// IsNotFound indica si err es el de un registro que no existe
+func IsNotFound(err error) bool {
+	return errors.Is(err, gorm.ErrRecordNotFound)
+}
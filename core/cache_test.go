@@ -0,0 +1,91 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/safe_msvc_city/insfratructure/cache"
+	"github.com/safe_msvc_city/insfratructure/listener"
+)
+
+func TestCacheInvalidatorKeys(t *testing.T) {
+	tests := []struct {
+		name   string
+		change listener.Change
+		want   []string
+	}{
+		{"ciudad", listener.Change{Entity: "city", Id: 3},
+			[]string{"city:all", "city:id:3", "states:all", "states:city:3"}},
+		{"barrio", listener.Change{Entity: "state", Id: 7, CityId: 3},
+			[]string{"states:all", "states:city:3", "states:id:7"}},
+		{"barrio en la misma ciudad", listener.Change{Entity: "state", Id: 7, CityId: 3, OldCityId: 3},
+			[]string{"states:all", "states:city:3", "states:id:7"}},
+		{"barrio movido de ciudad", listener.Change{Entity: "state", Id: 7, CityId: 3, OldCityId: 5},
+			[]string{"states:all", "states:city:3", "states:city:5", "states:id:7"}},
+		{"entidad desconocida", listener.Change{Entity: "other", Id: 1}, nil},
+	}
+	all := []string{"city:all", "city:id:3", "city:id:4", "states:all", "states:city:3", "states:city:4", "states:city:5", "states:id:7", "states:id:8"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			backend := cache.NewMemory(0)
+			ConfigureCache(backend, time.Minute)
+			t.Cleanup(func() { ConfigureCache(nil, 0) })
+			for _, key := range all {
+				backend.Set(ctx, key, []byte("{}"), time.Minute)
+			}
+
+			CacheInvalidator().Changed(ctx, tt.change)
+
+			var removed []string
+			for _, key := range all {
+				if _, ok, _ := backend.Get(ctx, key); !ok {
+					removed = append(removed, key)
+				}
+			}
+			sort.Strings(removed)
+			if !reflect.DeepEqual(removed, tt.want) {
+				t.Errorf("claves borradas = %v, se esperaba %v", removed, tt.want)
+			}
+		})
+	}
+}
+
+func TestCached(t *testing.T) {
+	ctx := context.Background()
+	ConfigureCache(cache.NewMemory(0), time.Minute)
+	t.Cleanup(func() { ConfigureCache(nil, 0) })
+
+	loads := 0
+	load := func(value int, err error) func() (int, error) {
+		return func() (int, error) {
+			loads++
+			return value, err
+		}
+	}
+	positive := func(value int) bool { return value > 0 }
+
+	if value, _ := cached(ctx, "test", "k", load(5, nil), positive); value != 5 {
+		t.Fatalf("valor = %d, se esperaba 5", value)
+	}
+	if value, _ := cached(ctx, "test", "k", load(9, nil), positive); value != 5 || loads != 1 {
+		t.Errorf("valor = %d con %d cargas, se esperaba 5 desde la caché", value, loads)
+	}
+
+	cached(ctx, "test", "cero", load(0, nil), positive)
+	cached(ctx, "test", "cero", load(0, nil), positive)
+	if loads != 3 {
+		t.Errorf("cargas = %d, un valor rechazado por store no debe guardarse", loads)
+	}
+
+	if _, err := cached(ctx, "test", "error", load(1, errors.New("falla")), positive); err == nil {
+		t.Error("se esperaba el error de load")
+	}
+	if value, _ := cached(ctx, "test", "error", load(2, nil), positive); value != 2 {
+		t.Errorf("valor = %d, un error de load no debe guardarse", value)
+	}
+}
@@ -0,0 +1,84 @@
+package core
+
+import (
+	"github.com/safe_msvc_city/insfratructure/entities"
+	"github.com/safe_msvc_city/insfratructure/events"
+	"github.com/safe_msvc_city/insfratructure/outbox"
+	"gorm.io/gorm"
+)
+
+// recordCityChange guarda en el outbox, dentro de tx, los eventos de un cambio de
+// ciudad; previous es nil al crear y current es nil al eliminar. Una actualización genera CityUpdated y, según lo que
+// cambió, CityRenamed y CityDeactivated o CityActivated
+func recordCityChange(tx *gorm.DB, previous *entities.City, current *entities.City) error {
+	var types []string
+	var city entities.City
+	switch {
+	case previous == nil:
+		types, city = []string{events.CityCreated}, *current
+	case current == nil:
+		types, city, previous = []string{events.CityDeleted}, *previous, nil
+	default:
+		types, city = []string{events.CityUpdated}, *current
+		if previous.Name != current.Name {
+			types = append(types, events.CityRenamed)
+		}
+		if previous.Active != current.Active {
+			types = append(types, activeEvent(current.Active, events.CityActivated, events.CityDeactivated))
+		}
+	}
+	city.States = nil
+	list := make([]events.Event, 0, len(types))
+	for _, eventType := range types {
+		event, err := events.New(eventType, events.EntityCity, city.Id, city.Id, events.CityData{City: city, Previous: previous})
+		if err != nil {
+			return err
+		}
+		list = append(list, event)
+	}
+	return outbox.Append(tx, list...)
+}
+
+// recordStateChange es el equivalente de recordCityChange para barrios; un cambio de ciudad
+// genera StateMoved con la ciudad de destino como CityId
+func recordStateChange(tx *gorm.DB, previous *entities.States, current *entities.States) error {
+	var types []string
+	var state entities.States
+	switch {
+	case previous == nil:
+		types, state = []string{events.StateCreated}, *current
+	case current == nil:
+		types, state, previous = []string{events.StateDeleted}, *previous, nil
+	default:
+		types, state = []string{events.StateUpdated}, *current
+		if previous.Name != current.Name {
+			types = append(types, events.StateRenamed)
+		}
+		if previous.CityId != current.CityId {
+			types = append(types, events.StateMoved)
+		}
+		if previous.Active != current.Active {
+			types = append(types, activeEvent(current.Active, events.StateActivated, events.StateDeactivated))
+		}
+	}
+	state.City = entities.City{}
+	if previous != nil {
+		previous.City = entities.City{}
+	}
+	list := make([]events.Event, 0, len(types))
+	for _, eventType := range types {
+		event, err := events.New(eventType, events.EntityState, state.Id, state.CityId, events.StateData{State: state, Previous: previous})
+		if err != nil {
+			return err
+		}
+		list = append(list, event)
+	}
+	return outbox.Append(tx, list...)
+}
+
+func activeEvent(active bool, activated string, deactivated string) string {
+	if active {
+		return activated
+	}
+	return deactivated
+}
@@ -0,0 +1,29 @@
+package core
+
+import (
+	"context"
+	"time"
+
+	"github.com/safe_msvc_city/insfratructure/logging"
+	"github.com/safe_msvc_city/insfratructure/metrics"
+	"github.com/safe_msvc_city/insfratructure/querylog"
+	"github.com/safe_msvc_city/insfratructure/tracing"
+)
+
+// observe inicia el span y la medición de latencia de un método del repositorio;
+// la función devuelta los cierra y deja un log de depuración con el logger de la petición
+func observe(ctx context.Context, repository string, method string) (context.Context, func()) {
+	ctx, span := tracing.Start(ctx, repository+"Repository."+method)
+	ctx = querylog.WithCaller(ctx, repository+"Repository."+method)
+	done := metrics.ObserveRepository(repository, method)
+	start := time.Now()
+	return ctx, func() {
+		done()
+		span.End()
+		logging.FromContext(ctx).Debug("repository call",
+			"repository", repository,
+			"method", method,
+			"duration_ms", float64(time.Since(start).Microseconds())/1000,
+		)
+	}
+}
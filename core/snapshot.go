@@ -0,0 +1,110 @@
+package core
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/safe_msvc_city/insfratructure/database"
+	"github.com/safe_msvc_city/insfratructure/entities"
+	"github.com/safe_msvc_city/insfratructure/logging"
+	"github.com/safe_msvc_city/insfratructure/snapshot"
+	"github.com/safe_msvc_city/insfratructure/ui/uicore"
+	"gorm.io/gorm"
+)
+
+// TakeSnapshot lee todas las ciudades y barrios directamente de la base de datos, sin caché
+func TakeSnapshot(ctx context.Context) (*snapshot.Snapshot, error) {
+	takenAt := time.Now()
+	cities, err := (&OpenConnection{connection: database.GetDatabaseInstance()}).GetCityFindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	states, err := statesConnection().GetStatesFindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &snapshot.Snapshot{TakenAt: takenAt, Cities: cities, States: states}, nil
+}
+
+// fromSnapshot decide si una lectura fallida se responde con el snapshot; lo
+// devuelve junto con true y deja la petición marcada como desactualizada
+func fromSnapshot(ctx context.Context, err error) (*snapshot.Snapshot, bool) {
+	if !database.Unavailable(err) {
+		return nil, false
+	}
+	current, ok := snapshot.Current()
+	if !ok {
+		return nil, false
+	}
+	logging.FromContext(ctx).Warn("serving from snapshot", "taken_at", current.TakenAt, "error", err)
+	snapshot.MarkStale(ctx, current.TakenAt)
+	return current, true
+}
+
+// citySnapshot responde las lecturas de ciudades con el snapshot cuando la base de
+// datos no está disponible; las escrituras pasan sin cambios
+type citySnapshot struct {
+	uicore.UICityCore
+}
+
+func (r citySnapshot) GetCityFindAll(ctx context.Context) ([]entities.City, error) {
+	cities, err := r.UICityCore.GetCityFindAll(ctx)
+	if current, ok := fromSnapshot(ctx, err); ok {
+		return slices.Clone(current.Cities), nil
+	}
+	return cities, err
+}
+
+func (r citySnapshot) GetCityFindById(ctx context.Context, id uint) (entities.City, error) {
+	city, err := r.UICityCore.GetCityFindById(ctx, id)
+	if current, ok := fromSnapshot(ctx, err); ok {
+		for _, city := range current.Cities {
+			if city.Id == id {
+				return city, nil
+			}
+		}
+		return entities.City{}, nil
+	}
+	return city, err
+}
+
+// statesSnapshot es el equivalente de citySnapshot para barrios
+type statesSnapshot struct {
+	uicore.UIStatesCore
+}
+
+func (r statesSnapshot) GetStatesFindAll(ctx context.Context) ([]entities.States, error) {
+	states, err := r.UIStatesCore.GetStatesFindAll(ctx)
+	if current, ok := fromSnapshot(ctx, err); ok {
+		return slices.Clone(current.States), nil
+	}
+	return states, err
+}
+
+func (r statesSnapshot) GetStatesFindById(ctx context.Context, id uint) (entities.States, error) {
+	state, err := r.UIStatesCore.GetStatesFindById(ctx, id)
+	if current, ok := fromSnapshot(ctx, err); ok {
+		for _, state := range current.States {
+			if state.Id == id {
+				return state, nil
+			}
+		}
+		return entities.States{}, gorm.ErrRecordNotFound
+	}
+	return state, err
+}
+
+func (r statesSnapshot) GetStatesFindByIdOfCity(ctx context.Context, id uint) ([]entities.States, error) {
+	states, err := r.UIStatesCore.GetStatesFindByIdOfCity(ctx, id)
+	if current, ok := fromSnapshot(ctx, err); ok {
+		result := []entities.States{}
+		for _, state := range current.States {
+			if state.CityId == id {
+				result = append(result, state)
+			}
+		}
+		return result, nil
+	}
+	return states, err
+}
@@ -0,0 +1,19 @@
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/safe_msvc_city/insfratructure/ui/global"
+	"github.com/safe_msvc_city/usecase/service"
+)
+
+type adminHandler struct {
+	admin global.UIAdmin
+}
+
+func NewAdminHandler() global.UIAdmin {
+	return &adminHandler{admin: service.NewAdminService()}
+}
+
+func (h *adminHandler) GetQueryStats(c *fiber.Ctx) error {
+	return h.admin.GetQueryStats(c)
+}
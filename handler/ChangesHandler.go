@@ -0,0 +1,19 @@
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/safe_msvc_city/insfratructure/ui/global"
+	"github.com/safe_msvc_city/usecase/service"
+)
+
+type changesHandler struct {
+	changes global.UIChanges
+}
+
+func NewChangesHandler() global.UIChanges {
+	return &changesHandler{changes: service.NewChangesService()}
+}
+
+func (h *changesHandler) Stream(c *fiber.Ctx) error {
+	return h.changes.Stream(c)
+}
@@ -34,3 +34,11 @@ func (h *cityHandler) UpdateCity(c *fiber.Ctx) error {
 func (h *cityHandler) DeleteCity(c *fiber.Ctx) error {
 	return h.city.DeleteCity(c)
 }
+
+func (h *cityHandler) BulkCities(c *fiber.Ctx) error {
+	return h.city.BulkCities(c)
+}
+
+func (h *cityHandler) MergeCities(c *fiber.Ctx) error {
+	return h.city.MergeCities(c)
+}
@@ -0,0 +1,19 @@
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/safe_msvc_city/insfratructure/ui/global"
+	"github.com/safe_msvc_city/usecase/service"
+)
+
+type exportHandler struct {
+	export global.UIExport
+}
+
+func NewExportHandler() global.UIExport {
+	return &exportHandler{export: service.NewExportService()}
+}
+
+func (h *exportHandler) Export(c *fiber.Ctx) error {
+	return h.export.Export(c)
+}
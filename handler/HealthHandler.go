@@ -0,0 +1,27 @@
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/safe_msvc_city/insfratructure/ui/global"
+	"github.com/safe_msvc_city/usecase/service"
+)
+
+type healthHandler struct {
+	health global.UIHealth
+}
+
+func NewHealthHandler() global.UIHealth {
+	return &healthHandler{health: service.NewHealthService()}
+}
+
+func (h *healthHandler) Liveness(c *fiber.Ctx) error {
+	return h.health.Liveness(c)
+}
+
+func (h *healthHandler) Readiness(c *fiber.Ctx) error {
+	return h.health.Readiness(c)
+}
+
+func (h *healthHandler) Details(c *fiber.Ctx) error {
+	return h.health.Details(c)
+}
@@ -0,0 +1,23 @@
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/safe_msvc_city/insfratructure/ui/global"
+	"github.com/safe_msvc_city/usecase/service"
+)
+
+type importHandler struct {
+	importer global.UIImport
+}
+
+func NewImportHandler() global.UIImport {
+	return &importHandler{importer: service.NewImportService()}
+}
+
+func (h *importHandler) ImportCities(c *fiber.Ctx) error {
+	return h.importer.ImportCities(c)
+}
+
+func (h *importHandler) ImportStates(c *fiber.Ctx) error {
+	return h.importer.ImportStates(c)
+}
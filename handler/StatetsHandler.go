@@ -36,3 +36,19 @@ func (h *statesHandler) UpdateState(c *fiber.Ctx) error {
 func (h *statesHandler) DeleteState(c *fiber.Ctx) error {
 	return h.state.DeleteState(c)
 }
+
+func (h *statesHandler) BulkStates(c *fiber.Ctx) error {
+	return h.state.BulkStates(c)
+}
+
+func (h *statesHandler) MoveState(c *fiber.Ctx) error {
+	return h.state.MoveState(c)
+}
+
+func (h *statesHandler) GetStateMoves(c *fiber.Ctx) error {
+	return h.state.GetStateMoves(c)
+}
+
+func (h *statesHandler) MergeStates(c *fiber.Ctx) error {
+	return h.state.MergeStates(c)
+}
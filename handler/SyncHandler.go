@@ -0,0 +1,19 @@
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/safe_msvc_city/insfratructure/ui/global"
+	"github.com/safe_msvc_city/usecase/service"
+)
+
+type syncHandler struct {
+	sync global.UISync
+}
+
+func NewSyncHandler() global.UISync {
+	return &syncHandler{sync: service.NewSyncService()}
+}
+
+func (h *syncHandler) GetChanges(c *fiber.Ctx) error {
+	return h.sync.GetChanges(c)
+}
@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/safe_msvc_city/insfratructure/ui/global"
+	"github.com/safe_msvc_city/usecase/service"
+)
+
+type webhookHandler struct {
+	webhook global.UIWebhook
+}
+
+func NewWebhookHandler() global.UIWebhook {
+	return &webhookHandler{
+		webhook: service.NewWebhookService(),
+	}
+}
+
+func (h *webhookHandler) GetWebhookFindAll(c *fiber.Ctx) error {
+	return h.webhook.GetWebhookFindAll(c)
+}
+
+func (h *webhookHandler) GetWebhookFindById(c *fiber.Ctx) error {
+	return h.webhook.GetWebhookFindById(c)
+}
+
+func (h *webhookHandler) CreateWebhook(c *fiber.Ctx) error {
+	return h.webhook.CreateWebhook(c)
+}
+
+func (h *webhookHandler) UpdateWebhook(c *fiber.Ctx) error {
+	return h.webhook.UpdateWebhook(c)
+}
+
+func (h *webhookHandler) DeleteWebhook(c *fiber.Ctx) error {
+	return h.webhook.DeleteWebhook(c)
+}
+
+func (h *webhookHandler) GetWebhookDeliveries(c *fiber.Ctx) error {
+	return h.webhook.GetWebhookDeliveries(c)
+}
+
+func (h *webhookHandler) GetDeadDeliveries(c *fiber.Ctx) error {
+	return h.webhook.GetDeadDeliveries(c)
+}
+
+func (h *webhookHandler) ReplayDelivery(c *fiber.Ctx) error {
+	return h.webhook.ReplayDelivery(c)
+}
@@ -0,0 +1,43 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/safe_msvc_city/insfratructure/config"
+)
+
+// Backends soportados
+const (
+	BackendNone   = "none"
+	BackendMemory = "memory"
+	BackendRedis  = "redis"
+)
+
+// Backend guarda valores serializados con vencimiento
+type Backend interface {
+	// Get devuelve el valor y true si la clave existe y no ha vencido
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+	// Flush borra todas las claves de la caché
+	Flush(ctx context.Context) error
+	// Ping verifica que el backend responda
+	Ping(ctx context.Context) error
+	Close() error
+}
+
+// New crea el backend indicado en la configuración; devuelve nil si la caché está desactivada
+func New(cfg config.Cache) (Backend, error) {
+	switch cfg.Backend {
+	case BackendNone, "":
+		return nil, nil
+	case BackendMemory:
+		return NewMemory(cfg.MaxEntries), nil
+	case BackendRedis:
+		return NewRedis(cfg.Redis), nil
+	default:
+		return nil, fmt.Errorf("backend de caché desconocido: %s", cfg.Backend)
+	}
+}
@@ -0,0 +1,87 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemory(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name    string
+		max     int
+		actions func(m *Memory)
+		present []string
+		absent  []string
+	}{
+		{"guarda y lee", 10, func(m *Memory) {
+			m.Set(ctx, "a", []byte("1"), time.Minute)
+		}, []string{"a"}, nil},
+		{"descarta la menos usada", 2, func(m *Memory) {
+			m.Set(ctx, "a", []byte("1"), time.Minute)
+			m.Set(ctx, "b", []byte("2"), time.Minute)
+			m.Set(ctx, "c", []byte("3"), time.Minute)
+		}, []string{"b", "c"}, []string{"a"}},
+		{"leer renueva la posición", 2, func(m *Memory) {
+			m.Set(ctx, "a", []byte("1"), time.Minute)
+			m.Set(ctx, "b", []byte("2"), time.Minute)
+			m.Get(ctx, "a")
+			m.Set(ctx, "c", []byte("3"), time.Minute)
+		}, []string{"a", "c"}, []string{"b"}},
+		{"sobrescribir renueva la posición", 2, func(m *Memory) {
+			m.Set(ctx, "a", []byte("1"), time.Minute)
+			m.Set(ctx, "b", []byte("2"), time.Minute)
+			m.Set(ctx, "a", []byte("3"), time.Minute)
+			m.Set(ctx, "c", []byte("4"), time.Minute)
+		}, []string{"a", "c"}, []string{"b"}},
+		{"sin límite", 0, func(m *Memory) {
+			for _, key := range []string{"a", "b", "c"} {
+				m.Set(ctx, key, []byte(key), time.Minute)
+			}
+		}, []string{"a", "b", "c"}, nil},
+		{"vencida", 10, func(m *Memory) {
+			m.Set(ctx, "a", []byte("1"), -time.Second)
+			m.Set(ctx, "b", []byte("2"), time.Minute)
+		}, []string{"b"}, []string{"a"}},
+		{"borrar varias", 10, func(m *Memory) {
+			m.Set(ctx, "a", []byte("1"), time.Minute)
+			m.Set(ctx, "b", []byte("2"), time.Minute)
+			m.Set(ctx, "c", []byte("3"), time.Minute)
+			m.Delete(ctx, "a", "c", "inexistente")
+		}, []string{"b"}, []string{"a", "c"}},
+		{"vaciar", 10, func(m *Memory) {
+			m.Set(ctx, "a", []byte("1"), time.Minute)
+			m.Flush(ctx)
+		}, nil, []string{"a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMemory(tt.max)
+			tt.actions(m)
+			for _, key := range tt.present {
+				if _, ok, _ := m.Get(ctx, key); !ok {
+					t.Errorf("falta la clave %s", key)
+				}
+			}
+			for _, key := range tt.absent {
+				if _, ok, _ := m.Get(ctx, key); ok {
+					t.Errorf("la clave %s no debería estar", key)
+				}
+			}
+		})
+	}
+}
+
+func TestMemoryExpiredIsPurged(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory(10)
+	m.Set(ctx, "a", []byte("1"), -time.Second)
+	if m.Len() != 1 {
+		t.Fatalf("Len = %d, se esperaba 1 antes de leer", m.Len())
+	}
+	m.Get(ctx, "a")
+	if m.Len() != 0 {
+		t.Errorf("Len = %d, la clave vencida debería purgarse al leerla", m.Len())
+	}
+}
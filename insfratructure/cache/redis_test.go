@@ -0,0 +1,124 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/safe_msvc_city/insfratructure/config"
+)
+
+// newTestRedis arranca un servidor miniredis y devuelve el backend apuntando a él
+func newTestRedis(t *testing.T) (*Redis, *miniredis.Miniredis) {
+	t.Helper()
+	server := miniredis.RunT(t)
+	r := NewRedis(config.Redis{Addr: server.Addr(), Prefix: "city:"})
+	t.Cleanup(func() { r.Close() })
+	return r, server
+}
+
+func TestRedis(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name    string
+		actions func(r *Redis, server *miniredis.Miniredis)
+		present []string
+		absent  []string
+	}{
+		{"guarda y lee", func(r *Redis, server *miniredis.Miniredis) {
+			r.Set(ctx, "a", []byte("1"), time.Minute)
+		}, []string{"a"}, nil},
+		{"clave inexistente", func(r *Redis, server *miniredis.Miniredis) {}, nil, []string{"a"}},
+		{"vencida", func(r *Redis, server *miniredis.Miniredis) {
+			r.Set(ctx, "a", []byte("1"), time.Second)
+			r.Set(ctx, "b", []byte("2"), time.Minute)
+			server.FastForward(2 * time.Second)
+		}, []string{"b"}, []string{"a"}},
+		{"borrar varias", func(r *Redis, server *miniredis.Miniredis) {
+			r.Set(ctx, "a", []byte("1"), time.Minute)
+			r.Set(ctx, "b", []byte("2"), time.Minute)
+			r.Set(ctx, "c", []byte("3"), time.Minute)
+			r.Delete(ctx, "a", "c", "inexistente")
+		}, []string{"b"}, []string{"a", "c"}},
+		{"borrar sin claves", func(r *Redis, server *miniredis.Miniredis) {
+			r.Set(ctx, "a", []byte("1"), time.Minute)
+			r.Delete(ctx)
+		}, []string{"a"}, nil},
+		{"vaciar", func(r *Redis, server *miniredis.Miniredis) {
+			r.Set(ctx, "a", []byte("1"), time.Minute)
+			r.Set(ctx, "b", []byte("2"), time.Minute)
+			r.Flush(ctx)
+		}, nil, []string{"a", "b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, server := newTestRedis(t)
+			tt.actions(r, server)
+			for _, key := range tt.present {
+				if _, ok, err := r.Get(ctx, key); err != nil || !ok {
+					t.Errorf("falta la clave %s (error %v)", key, err)
+				}
+			}
+			for _, key := range tt.absent {
+				value, ok, err := r.Get(ctx, key)
+				if err != nil {
+					t.Errorf("una clave ausente no debería dar error: %v", err)
+				}
+				if ok || value != nil {
+					t.Errorf("la clave %s no debería estar", key)
+				}
+			}
+		})
+	}
+}
+
+func TestRedisStoresWithPrefixAndTTL(t *testing.T) {
+	ctx := context.Background()
+	r, server := newTestRedis(t)
+	if err := r.Set(ctx, "a", []byte("1"), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	stored, err := server.Get("city:a")
+	if err != nil || stored != "1" {
+		t.Fatalf("city:a = %q (error %v), se esperaba 1", stored, err)
+	}
+	if ttl := server.TTL("city:a"); ttl != time.Minute {
+		t.Errorf("TTL = %s, se esperaba 1m", ttl)
+	}
+	value, ok, err := r.Get(ctx, "a")
+	if err != nil || !ok || string(value) != "1" {
+		t.Errorf("Get = %q, %v, %v; se esperaba 1", value, ok, err)
+	}
+}
+
+func TestRedisFlushKeepsOtherPrefixes(t *testing.T) {
+	ctx := context.Background()
+	r, server := newTestRedis(t)
+	r.Set(ctx, "a", []byte("1"), time.Minute)
+	server.Set("user:a", "otro servicio")
+	if err := r.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if server.Exists("city:a") {
+		t.Error("Flush debería borrar city:a")
+	}
+	if !server.Exists("user:a") {
+		t.Error("Flush no debería borrar claves de otro prefijo")
+	}
+	if err := r.Flush(ctx); err != nil {
+		t.Errorf("vaciar sin claves no debería fallar: %v", err)
+	}
+}
+
+func TestRedisErrors(t *testing.T) {
+	ctx := context.Background()
+	r, server := newTestRedis(t)
+	server.SetError("sin conexión")
+	if _, ok, err := r.Get(ctx, "a"); err == nil || ok {
+		t.Errorf("Get = %v, %v; se esperaba el error del servidor y no un fallo de caché", ok, err)
+	}
+	if err := r.Ping(ctx); err == nil {
+		t.Error("Ping debería fallar")
+	}
+}
@@ -0,0 +1,219 @@
+package changes
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/safe_msvc_city/insfratructure/config"
+	"github.com/safe_msvc_city/insfratructure/events"
+	"github.com/safe_msvc_city/insfratructure/outbox"
+	"gorm.io/gorm"
+)
+
+// subscriptionBuffer es cuántos eventos puede acumular un suscriptor lento antes de
+// ser desconectado; al reconectar recupera lo perdido con Last-Event-ID
+const subscriptionBuffer = 64
+
+// pollLimit es el máximo de eventos leídos del outbox en cada consulta
+const pollLimit = 500
+
+// Filter limita los eventos que recibe un suscriptor; los campos vacíos aceptan todo
+type Filter struct {
+	Entity string
+	CityId uint
+}
+
+// Match indica si el evento pasa el filtro
+func (f Filter) Match(event events.Event) bool {
+	if f.Entity != "" && f.Entity != event.Entity {
+		return false
+	}
+	if f.CityId != 0 && f.CityId != event.CityId {
+		return false
+	}
+	return true
+}
+
+// Subscription recibe los eventos del hub por C; C se cierra si el suscriptor se
+// queda atrás o el hub se detiene
+type Subscription struct {
+	C      chan outbox.Entry
+	filter Filter
+}
+
+// Hub lee los eventos nuevos del outbox, que comparten todas las réplicas, los
+// guarda en un buffer acotado y los reparte a los suscriptores
+type Hub struct {
+	db  *gorm.DB
+	cfg config.Changes
+
+	mux         sync.Mutex
+	buffer      []outbox.Entry
+	last        uint64
+	subscribers map[*Subscription]struct{}
+	stopped     bool
+
+	// gapSince es cuándo se vio por primera vez que faltaba el id siguiente; solo lo usa Run
+	gapSince time.Time
+}
+
+var defaultHub *Hub
+
+// SetDefault define el hub que usa el endpoint de stream
+func SetDefault(hub *Hub) {
+	defaultHub = hub
+}
+
+// Default devuelve el hub definido con SetDefault; nil si no hay ninguno
+func Default() *Hub {
+	return defaultHub
+}
+
+func NewHub(db *gorm.DB, cfg config.Changes) *Hub {
+	return &Hub{db: db, cfg: cfg, subscribers: map[*Subscription]struct{}{}}
+}
+
+// Heartbeat es cada cuánto el stream envía un comentario para mantener viva la conexión
+func (h *Hub) Heartbeat() time.Duration {
+	return h.cfg.Heartbeat
+}
+
+// Load llena el buffer con los eventos más recientes para poder reanudar desde ellos
+func (h *Hub) Load(ctx context.Context) error {
+	entries, err := outbox.Latest(ctx, h.db, h.cfg.BufferSize)
+	if err != nil {
+		return err
+	}
+	h.mux.Lock()
+	defer h.mux.Unlock()
+	h.buffer = entries
+	if len(entries) > 0 {
+		h.last = entries[len(entries)-1].Id
+	}
+	return nil
+}
+
+// Run consulta el outbox cada cfg.PollInterval hasta que ctx termine; al terminar
+// cierra todos los suscriptores
+func (h *Hub) Run(ctx context.Context) {
+	ticker := time.NewTicker(h.cfg.PollInterval)
+	defer ticker.Stop()
+	defer h.stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := h.poll(ctx); err != nil && ctx.Err() == nil {
+				slog.Warn("change stream poll failed", "error", err)
+			}
+		}
+	}
+}
+
+// poll agrega los eventos nuevos. Los ids se asignan antes del commit, así que una
+// transacción lenta puede confirmar un id menor después de uno mayor; ante un hueco
+// se espera hasta dos intervalos antes de darlo por perdido (rollback)
+func (h *Hub) poll(ctx context.Context) error {
+	h.mux.Lock()
+	last := h.last
+	h.mux.Unlock()
+
+	entries, err := outbox.After(ctx, h.db, last, pollLimit)
+	if err != nil {
+		return err
+	}
+	accepted := entries[:0]
+	for _, entry := range entries {
+		if entry.Id != last+1 {
+			if h.gapSince.IsZero() {
+				h.gapSince = time.Now()
+			}
+			if time.Since(h.gapSince) < 2*h.cfg.PollInterval {
+				break
+			}
+		}
+		h.gapSince = time.Time{}
+		accepted = append(accepted, entry)
+		last = entry.Id
+	}
+	if len(accepted) > 0 {
+		h.publish(accepted)
+	}
+	return nil
+}
+
+func (h *Hub) publish(entries []outbox.Entry) {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+	h.buffer = append(h.buffer, entries...)
+	if overflow := len(h.buffer) - h.cfg.BufferSize; overflow > 0 {
+		h.buffer = append([]outbox.Entry(nil), h.buffer[overflow:]...)
+	}
+	h.last = entries[len(entries)-1].Id
+subscribers:
+	for subscription := range h.subscribers {
+		for _, entry := range entries {
+			if !subscription.filter.Match(entry.Event) {
+				continue
+			}
+			select {
+			case subscription.C <- entry:
+			default:
+				// remove cierra C: no se puede seguir enviando a este suscriptor
+				h.remove(subscription)
+				continue subscribers
+			}
+		}
+	}
+}
+
+// Subscribe registra un suscriptor y devuelve los eventos del buffer posteriores a
+// lastId que pasan el filtro. complete es false si lastId ya salió del buffer y el
+// cliente pudo perder eventos
+func (h *Hub) Subscribe(lastId uint64, filter Filter) (*Subscription, []outbox.Entry, bool) {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+	subscription := &Subscription{C: make(chan outbox.Entry, subscriptionBuffer), filter: filter}
+	if h.stopped {
+		close(subscription.C)
+		return subscription, nil, true
+	}
+	h.subscribers[subscription] = struct{}{}
+	if lastId == 0 || lastId >= h.last {
+		return subscription, nil, true
+	}
+	complete := len(h.buffer) > 0 && h.buffer[0].Id <= lastId+1
+	var replay []outbox.Entry
+	for _, entry := range h.buffer {
+		if entry.Id > lastId && filter.Match(entry.Event) {
+			replay = append(replay, entry)
+		}
+	}
+	return subscription, replay, complete
+}
+
+// Unsubscribe quita al suscriptor; se puede llamar más de una vez
+func (h *Hub) Unsubscribe(subscription *Subscription) {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+	h.remove(subscription)
+}
+
+func (h *Hub) remove(subscription *Subscription) {
+	if _, ok := h.subscribers[subscription]; ok {
+		delete(h.subscribers, subscription)
+		close(subscription.C)
+	}
+}
+
+func (h *Hub) stop() {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+	h.stopped = true
+	for subscription := range h.subscribers {
+		h.remove(subscription)
+	}
+}
@@ -0,0 +1,138 @@
+package changes
+
+import (
+	"testing"
+
+	"github.com/safe_msvc_city/insfratructure/config"
+	"github.com/safe_msvc_city/insfratructure/events"
+	"github.com/safe_msvc_city/insfratructure/outbox"
+)
+
+func entries(from uint64, to uint64, entity string, cityId uint) []outbox.Entry {
+	var result []outbox.Entry
+	for id := from; id <= to; id++ {
+		result = append(result, outbox.Entry{Id: id, Event: events.Event{Entity: entity, CityId: cityId}})
+	}
+	return result
+}
+
+func ids(list []outbox.Entry) []uint64 {
+	var result []uint64
+	for _, entry := range list {
+		result = append(result, entry.Id)
+	}
+	return result
+}
+
+func TestFilterMatch(t *testing.T) {
+	event := events.Event{Entity: "state", CityId: 3}
+	tests := []struct {
+		name   string
+		filter Filter
+		want   bool
+	}{
+		{"vacío", Filter{}, true},
+		{"misma entidad", Filter{Entity: "state"}, true},
+		{"otra entidad", Filter{Entity: "city"}, false},
+		{"misma ciudad", Filter{CityId: 3}, true},
+		{"otra ciudad", Filter{CityId: 4}, false},
+		{"entidad y ciudad", Filter{Entity: "state", CityId: 3}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Match(event); got != tt.want {
+				t.Errorf("Match = %v, se esperaba %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPublishSlowSubscriber(t *testing.T) {
+	hub := NewHub(nil, config.Changes{BufferSize: 1000})
+	slow, _, _ := hub.Subscribe(0, Filter{})
+	other, _, _ := hub.Subscribe(0, Filter{Entity: "state"})
+
+	hub.publish(entries(1, subscriptionBuffer+10, "city", 1))
+
+	received := 0
+	for range slow.C {
+		received++
+	}
+	if received != subscriptionBuffer {
+		t.Errorf("recibió %d eventos antes del cierre, se esperaban %d", received, subscriptionBuffer)
+	}
+	hub.publish(entries(subscriptionBuffer+11, subscriptionBuffer+11, "state", 1))
+	select {
+	case entry, ok := <-other.C:
+		if !ok || entry.Id != subscriptionBuffer+11 {
+			t.Errorf("el suscriptor filtrado recibió %v (abierto %v)", entry.Id, ok)
+		}
+	default:
+		t.Error("el suscriptor filtrado debería seguir recibiendo eventos")
+	}
+	hub.Unsubscribe(slow)
+}
+
+func TestPublishTrimsBuffer(t *testing.T) {
+	hub := NewHub(nil, config.Changes{BufferSize: 5})
+	hub.publish(entries(1, 8, "city", 1))
+	if got := ids(hub.buffer); len(got) != 5 || got[0] != 4 || got[4] != 8 {
+		t.Errorf("buffer = %v, se esperaban los ids 4 a 8", got)
+	}
+	if hub.last != 8 {
+		t.Errorf("last = %d, se esperaba 8", hub.last)
+	}
+}
+
+func TestSubscribeResume(t *testing.T) {
+	tests := []struct {
+		name     string
+		lastId   uint64
+		filter   Filter
+		replay   []uint64
+		complete bool
+	}{
+		{"sin Last-Event-ID", 0, Filter{}, nil, true},
+		{"al día", 20, Filter{}, nil, true},
+		{"adelantado", 25, Filter{}, nil, true},
+		{"dentro del buffer", 17, Filter{}, []uint64{18, 19, 20}, true},
+		{"justo antes del buffer", 10, Filter{}, []uint64{11, 12, 13, 14, 15, 16, 17, 18, 19, 20}, true},
+		{"fuera del buffer", 5, Filter{}, []uint64{11, 12, 13, 14, 15, 16, 17, 18, 19, 20}, false},
+		{"con filtro", 15, Filter{Entity: "state"}, []uint64{19, 20}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hub := NewHub(nil, config.Changes{BufferSize: 10})
+			hub.publish(append(entries(1, 18, "city", 1), entries(19, 20, "state", 2)...))
+
+			subscription, replay, complete := hub.Subscribe(tt.lastId, tt.filter)
+			defer hub.Unsubscribe(subscription)
+			got := ids(replay)
+			if len(got) != len(tt.replay) {
+				t.Fatalf("replay = %v, se esperaba %v", got, tt.replay)
+			}
+			for i := range got {
+				if got[i] != tt.replay[i] {
+					t.Fatalf("replay = %v, se esperaba %v", got, tt.replay)
+				}
+			}
+			if complete != tt.complete {
+				t.Errorf("complete = %v, se esperaba %v", complete, tt.complete)
+			}
+		})
+	}
+}
+
+func TestSubscribeAfterStop(t *testing.T) {
+	hub := NewHub(nil, config.Changes{BufferSize: 10})
+	open, _, _ := hub.Subscribe(0, Filter{})
+	hub.stop()
+	if _, ok := <-open.C; ok {
+		t.Error("stop debería cerrar los suscriptores existentes")
+	}
+	late, replay, _ := hub.Subscribe(0, Filter{})
+	if _, ok := <-late.C; ok || replay != nil {
+		t.Error("un hub detenido debería devolver un canal cerrado")
+	}
+	hub.Unsubscribe(open)
+}
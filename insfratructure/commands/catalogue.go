@@ -0,0 +1,130 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/safe_msvc_city/core"
+	"github.com/safe_msvc_city/insfratructure/entities"
+)
+
+// catalogue es el formato JSON que usan import, export y seed
+type catalogue struct {
+	Cities []catalogueCity `json:"cities"`
+}
+
+type catalogueCity struct {
+	Name   string           `json:"name"`
+	Active bool             `json:"active"`
+	States []catalogueState `json:"states,omitempty"`
+}
+
+type catalogueState struct {
+	Name    string `json:"name"`
+	ZipCode string `json:"zip_code"`
+	Active  bool   `json:"active"`
+}
+
+// importReport resume lo que hizo importCatalogue
+type importReport struct {
+	CitiesCreated int
+	CitiesSkipped int
+	StatesCreated int
+	StatesSkipped int
+}
+
+func (r importReport) String() string {
+	return fmt.Sprintf("ciudades: %d creadas, %d omitidas; barrios: %d creados, %d omitidos",
+		r.CitiesCreated, r.CitiesSkipped, r.StatesCreated, r.StatesSkipped)
+}
+
+// importCatalogue crea las ciudades y barrios que no existan todavía, usando las
+// mismas reglas de nombre único que los servicios HTTP
+func importCatalogue(ctx context.Context, cat catalogue) (importReport, error) {
+	var report importReport
+	cityRepository := core.GetCityInstance()
+	statesRepository := core.GetStatesInstance()
+
+	existing, err := cityRepository.GetCityFindAll(ctx)
+	if err != nil {
+		return report, err
+	}
+	byName := make(map[string]entities.City, len(existing))
+	for _, city := range existing {
+		byName[city.Name] = city
+	}
+
+	for _, item := range cat.Cities {
+		name := strings.TrimSpace(item.Name)
+		if name == "" {
+			return report, fmt.Errorf("ciudad sin nombre en el catálogo")
+		}
+		city, ok := byName[name]
+		if ok {
+			report.CitiesSkipped++
+		} else {
+			city, err = cityRepository.CreateCity(ctx, entities.City{Name: name, Active: item.Active})
+			if err != nil {
+				return report, fmt.Errorf("no se pudo crear la ciudad %q: %w", name, err)
+			}
+			byName[name] = city
+			report.CitiesCreated++
+		}
+
+		for _, itemState := range item.States {
+			stateName := strings.TrimSpace(itemState.Name)
+			if stateName == "" || itemState.ZipCode == "" {
+				return report, fmt.Errorf("barrio incompleto en la ciudad %q", name)
+			}
+			exists, err := statesRepository.GetStatesFindByName(ctx, 0, stateName)
+			if err != nil && !isNotFound(err) {
+				return report, err
+			}
+			if exists {
+				report.StatesSkipped++
+				continue
+			}
+			_, err = statesRepository.CreateStates(ctx, entities.States{
+				Name:    stateName,
+				ZipCode: itemState.ZipCode,
+				CityId:  city.Id,
+				Active:  itemState.Active,
+			})
+			if err != nil {
+				return report, fmt.Errorf("no se pudo crear el barrio %q: %w", stateName, err)
+			}
+			report.StatesCreated++
+		}
+	}
+	return report, nil
+}
+
+// buildCatalogue arma el catálogo completo a partir de los repositorios
+func buildCatalogue(ctx context.Context) (catalogue, error) {
+	cities, err := core.GetCityInstance().GetCityFindAll(ctx)
+	if err != nil {
+		return catalogue{}, err
+	}
+	states, err := core.GetStatesInstance().GetStatesFindAll(ctx)
+	if err != nil {
+		return catalogue{}, err
+	}
+	byCity := map[uint][]catalogueState{}
+	for _, state := range states {
+		byCity[state.CityId] = append(byCity[state.CityId], catalogueState{
+			Name:    state.Name,
+			ZipCode: state.ZipCode,
+			Active:  state.Active,
+		})
+	}
+	cat := catalogue{Cities: make([]catalogueCity, 0, len(cities))}
+	for _, city := range cities {
+		cat.Cities = append(cat.Cities, catalogueCity{
+			Name:   city.Name,
+			Active: city.Active,
+			States: byCity[city.Id],
+		})
+	}
+	return cat, nil
+}
@@ -0,0 +1,77 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/safe_msvc_city/core"
+)
+
+// runCheck revisa la consistencia del catálogo y falla si encuentra problemas
+func runCheck(args []string) error {
+	flags, loader := newFlagSet("check")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if _, err := loadConfig(loader); err != nil {
+		return err
+	}
+	if err := connectDatabase(); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	cities, err := core.GetCityInstance().GetCityFindAll(ctx)
+	if err != nil {
+		return err
+	}
+	states, err := core.GetStatesInstance().GetStatesFindAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	var problems []string
+	activeCity := map[uint]bool{}
+	cityNames := map[string]uint{}
+	for _, city := range cities {
+		activeCity[city.Id] = city.Active
+		key := strings.ToLower(strings.TrimSpace(city.Name))
+		if other, ok := cityNames[key]; ok {
+			problems = append(problems, fmt.Sprintf("ciudad %d: nombre duplicado con la ciudad %d (%q)", city.Id, other, city.Name))
+		}
+		cityNames[key] = city.Id
+		if strings.TrimSpace(city.Name) == "" {
+			problems = append(problems, fmt.Sprintf("ciudad %d: sin nombre", city.Id))
+		}
+	}
+
+	stateNames := map[string]uint{}
+	for _, state := range states {
+		active, ok := activeCity[state.CityId]
+		switch {
+		case !ok:
+			problems = append(problems, fmt.Sprintf("barrio %d: la ciudad %d no existe", state.Id, state.CityId))
+		case state.Active && !active:
+			problems = append(problems, fmt.Sprintf("barrio %d: activo en la ciudad inactiva %d", state.Id, state.CityId))
+		}
+		key := strings.ToLower(strings.TrimSpace(state.Name))
+		if other, ok := stateNames[key]; ok {
+			problems = append(problems, fmt.Sprintf("barrio %d: nombre duplicado con el barrio %d (%q)", state.Id, other, state.Name))
+		}
+		stateNames[key] = state.Id
+		if strings.TrimSpace(state.ZipCode) == "" {
+			problems = append(problems, fmt.Sprintf("barrio %d: sin código postal", state.Id))
+		}
+	}
+
+	for _, problem := range problems {
+		fmt.Println(problem)
+	}
+	fmt.Printf("%d ciudades, %d barrios, %d problemas\n", len(cities), len(states), len(problems))
+	if len(problems) > 0 {
+		return errors.New("se encontraron inconsistencias")
+	}
+	return nil
+}
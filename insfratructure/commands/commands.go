@@ -0,0 +1,102 @@
+package commands
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sort"
+
+	"github.com/safe_msvc_city/insfratructure/config"
+	"github.com/safe_msvc_city/insfratructure/database"
+	"github.com/safe_msvc_city/insfratructure/logging"
+)
+
+// command describe un subcomando de la CLI
+type command struct {
+	summary string
+	run     func(args []string) error
+}
+
+var registry = map[string]command{
+	"serve":      {"inicia el servidor HTTP", runServe},
+	"migrate":    {"aplica, revierte o lista migraciones (up|down|status)", runMigrate},
+	"seed":       {"carga ciudades y barrios de ejemplo", runSeed},
+	"import":     {"importa un catálogo JSON de ciudades y barrios", runImport},
+	"divipola":   {"importa los municipios oficiales del DANE (DIVIPOLA)", runDivipola},
+	"geonames":   {"importa ciudades de un volcado local de GeoNames", runGeoNames},
+	"export":     {"exporta el catálogo de ciudades y barrios a JSON", runExport},
+	"check":      {"revisa la consistencia de los datos", runCheck},
+	"user-token": {"genera un JWT de desarrollo", runUserToken},
+}
+
+// Run ejecuta el subcomando indicado en args y devuelve el código de salida.
+// Sin argumentos se comporta como serve.
+func Run(args []string) int {
+	name := "serve"
+	if len(args) > 0 {
+		name, args = args[0], args[1:]
+	}
+	if name == "help" || name == "-h" || name == "--help" {
+		usage(os.Stdout)
+		return 0
+	}
+	cmd, ok := registry[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "comando desconocido: %s\n\n", name)
+		usage(os.Stderr)
+		return 2
+	}
+	if err := cmd.run(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+		return 1
+	}
+	return 0
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "uso: safe_msvc_city <comando> [opciones]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "comandos:")
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-12s %s\n", name, registry[name].summary)
+	}
+}
+
+// newFlagSet crea el FlagSet de un subcomando con las opciones de configuración compartidas
+func newFlagSet(name string) (*flag.FlagSet, *config.Loader) {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	return flags, config.Bind(flags)
+}
+
+// loadConfig carga y valida la configuración compartida por todos los subcomandos
+// y la aplica a la conexión de base de datos
+func loadConfig(loader *config.Loader) (*config.Config, error) {
+	cfg, err := loader.Load()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := logging.Setup(cfg.Logging); err != nil {
+		return nil, err
+	}
+	slog.Info("configuration loaded", "profile", cfg.Profile)
+	database.Configure(cfg.Database)
+	return cfg, nil
+}
+
+// connectDatabase abre la conexión con reintentos para que los comandos devuelvan
+// un error en lugar de terminar el proceso cuando Postgres no está disponible
+func connectDatabase() error {
+	_, err := database.Connect(context.Background())
+	return err
+}
@@ -0,0 +1,254 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/safe_msvc_city/core"
+	"github.com/safe_msvc_city/insfratructure/divipola"
+	"github.com/safe_msvc_city/insfratructure/entities"
+)
+
+// runDivipola importa los municipios oficiales del DANE y los guarda como ciudades
+// con su código; al volver a importar actualiza por código
+func runDivipola(args []string) error {
+	flags, loader := newFlagSet("divipola")
+	file := flags.String("file", "-", "archivo DIVIPOLA en CSV (- para la entrada estándar)")
+	dryRun := flags.Bool("dry-run", false, "muestra el reporte sin escribir en la base de datos")
+	deactivate := flags.Bool("deactivate-removed", false, "desactiva las ciudades cuyo código ya no está en el archivo")
+	keepCase := flags.Bool("keep-case", false, "conserva los nombres en mayúsculas como los publica el DANE")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if _, err := loadConfig(loader); err != nil {
+		return err
+	}
+
+	var reader io.Reader = os.Stdin
+	if *file != "-" {
+		f, err := os.Open(*file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		reader = f
+	}
+	municipalities, err := divipola.Parse(reader)
+	if err != nil {
+		return err
+	}
+	if err := connectDatabase(); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	cities, err := core.GetCityInstance().GetCityFindAll(ctx)
+	if err != nil {
+		return err
+	}
+	plan, err := planDivipola(municipalities, cities, divipolaOptions{
+		deactivateRemoved: *deactivate,
+		keepCase:          *keepCase,
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Print(plan.report)
+	if *dryRun {
+		fmt.Println("simulación: no se escribió ningún cambio")
+		return nil
+	}
+	if _, err := core.GetImportInstance().ApplyOfficialCities(ctx, plan.created, plan.updated); err != nil {
+		return fmt.Errorf("no se pudo aplicar la importación: %w", err)
+	}
+	return nil
+}
+
+type divipolaOptions struct {
+	deactivateRemoved bool
+	keepCase          bool
+}
+
+// divipolaChange es un municipio del reporte; previous es el nombre anterior en los
+// renombrados
+type divipolaChange struct {
+	code     string
+	name     string
+	previous string
+}
+
+// divipolaReport compara el archivo con las ciudades actuales
+type divipolaReport struct {
+	added   []divipolaChange
+	renamed []divipolaChange
+	linked  []divipolaChange
+	removed []divipolaChange
+	// departments cuenta los municipios a los que solo les cambió el departamento
+	departments int
+	unchanged   int
+}
+
+func (r divipolaReport) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "municipios: %d nuevos, %d renombrados, %d vinculados, %d retirados, %d con otro departamento, %d sin cambios\n",
+		len(r.added), len(r.renamed), len(r.linked), len(r.removed), r.departments, r.unchanged)
+	section := func(title string, changes []divipolaChange) {
+		if len(changes) == 0 {
+			return
+		}
+		fmt.Fprintf(&b, "%s:\n", title)
+		for _, change := range changes {
+			if change.previous != "" {
+				fmt.Fprintf(&b, "  %s %s (antes %s)\n", change.code, change.name, change.previous)
+			} else {
+				fmt.Fprintf(&b, "  %s %s\n", change.code, change.name)
+			}
+		}
+	}
+	section("nuevos", r.added)
+	section("renombrados", r.renamed)
+	section("vinculados por nombre", r.linked)
+	section("retirados", r.removed)
+	return b.String()
+}
+
+type divipolaPlan struct {
+	created []entities.City
+	updated []entities.City
+	report  divipolaReport
+}
+
+// planDivipola decide qué crear y actualizar sin escribir nada. Un municipio se busca
+// primero por código y, si no existe, entre las ciudades sin código con el mismo nombre
+// sin distinguir mayúsculas, que quedan vinculadas a ese código. Los nombres repetidos
+// en el archivo, como Buenavista o La Unión, llevan el departamento entre paréntesis
+// para respetar la regla de nombre único de las ciudades
+func planDivipola(municipalities []divipola.Municipality, cities []entities.City, opts divipolaOptions) (divipolaPlan, error) {
+	var plan divipolaPlan
+	byCode := map[string]entities.City{}
+	withoutCode := map[string]entities.City{}
+	for _, city := range cities {
+		if city.Code != nil {
+			byCode[*city.Code] = city
+		} else {
+			withoutCode[strings.ToLower(city.Name)] = city
+		}
+	}
+
+	names := make([]string, len(municipalities))
+	departments := make([]string, len(municipalities))
+	repeated := map[string]int{}
+	for i, municipality := range municipalities {
+		names[i], departments[i] = municipality.Name, municipality.Department
+		if !opts.keepCase {
+			names[i], departments[i] = divipola.TitleCase(names[i]), divipola.TitleCase(departments[i])
+		}
+		repeated[strings.ToLower(names[i])]++
+	}
+
+	final := map[uint]string{}
+	for _, city := range cities {
+		final[city.Id] = city.Name
+	}
+	var createdNames []string
+	inFile := map[string]bool{}
+	for i, municipality := range municipalities {
+		inFile[municipality.Code] = true
+		plain := names[i]
+		name := plain
+		if repeated[strings.ToLower(plain)] > 1 {
+			name = fmt.Sprintf("%s (%s)", plain, departments[i])
+		}
+		code := municipality.Code
+		official := entities.City{
+			Name:           name,
+			Active:         true,
+			Code:           &code,
+			DepartmentCode: municipality.DepartmentCode,
+			Department:     departments[i],
+		}
+
+		city, ok := byCode[code]
+		if !ok {
+			if city, ok = withoutCode[strings.ToLower(name)]; !ok {
+				city, ok = withoutCode[strings.ToLower(plain)]
+			}
+			if ok {
+				delete(withoutCode, strings.ToLower(city.Name))
+				official.Id, official.Active = city.Id, city.Active
+				plan.updated = append(plan.updated, official)
+				final[city.Id] = name
+				plan.report.linked = append(plan.report.linked, divipolaChange{code: code, name: name, previous: renamedFrom(city.Name, name)})
+				continue
+			}
+			plan.created = append(plan.created, official)
+			createdNames = append(createdNames, name)
+			plan.report.added = append(plan.report.added, divipolaChange{code: code, name: name})
+			continue
+		}
+
+		if city.Name == name && city.DepartmentCode == official.DepartmentCode && city.Department == official.Department {
+			plan.report.unchanged++
+			continue
+		}
+		official.Id, official.Active = city.Id, city.Active
+		plan.updated = append(plan.updated, official)
+		final[city.Id] = name
+		if city.Name != name {
+			plan.report.renamed = append(plan.report.renamed, divipolaChange{code: code, name: name, previous: city.Name})
+		} else {
+			plan.report.departments++
+		}
+	}
+
+	for code, city := range byCode {
+		if inFile[code] {
+			continue
+		}
+		plan.report.removed = append(plan.report.removed, divipolaChange{code: code, name: city.Name})
+		if opts.deactivateRemoved && city.Active {
+			city.Active = false
+			plan.updated = append(plan.updated, city)
+		}
+	}
+	sort.Slice(plan.report.removed, func(i, j int) bool {
+		return plan.report.removed[i].code < plan.report.removed[j].code
+	})
+
+	// Un nombre nuevo o renombrado puede chocar con una ciudad que no está en el archivo
+	owners := map[string]int{}
+	for _, name := range final {
+		owners[name]++
+	}
+	touched := map[string]bool{}
+	for _, name := range createdNames {
+		owners[name]++
+		touched[name] = true
+	}
+	for _, city := range plan.updated {
+		touched[city.Name] = true
+	}
+	var conflicts []string
+	for name, count := range owners {
+		if count > 1 && touched[name] {
+			conflicts = append(conflicts, name)
+		}
+	}
+	if len(conflicts) > 0 {
+		sort.Strings(conflicts)
+		return plan, fmt.Errorf("nombres de ciudad repetidos tras la importación: %s", strings.Join(conflicts, ", "))
+	}
+	return plan, nil
+}
+
+// renamedFrom devuelve el nombre anterior solo si cambió
+func renamedFrom(previous string, name string) string {
+	if previous == name {
+		return ""
+	}
+	return previous
+}
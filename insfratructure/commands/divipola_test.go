@@ -0,0 +1,123 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/safe_msvc_city/insfratructure/divipola"
+	"github.com/safe_msvc_city/insfratructure/entities"
+)
+
+func codePtr(code string) *string {
+	return &code
+}
+
+func TestPlanDivipola(t *testing.T) {
+	medellin := divipola.Municipality{DepartmentCode: "05", Department: "ANTIOQUIA", Code: "05001", Name: "MEDELLÍN"}
+	buenavistaBoyaca := divipola.Municipality{DepartmentCode: "15", Department: "BOYACÁ", Code: "15109", Name: "BUENAVISTA"}
+	buenavistaCordoba := divipola.Municipality{DepartmentCode: "23", Department: "CÓRDOBA", Code: "23079", Name: "BUENAVISTA"}
+	official := entities.City{Id: 1, Name: "Medellín", Active: true, Code: codePtr("05001"), DepartmentCode: "05", Department: "Antioquia"}
+
+	tests := []struct {
+		name           string
+		municipalities []divipola.Municipality
+		cities         []entities.City
+		opts           divipolaOptions
+		created        []string
+		updated        []string
+		report         string
+		wantErr        string
+	}{
+		{"nuevo", []divipola.Municipality{medellin}, nil, divipolaOptions{},
+			[]string{"Medellín"}, nil, "1 nuevos, 0 renombrados, 0 vinculados, 0 retirados, 0 con otro departamento, 0 sin cambios", ""},
+		{"sin cambios", []divipola.Municipality{medellin}, []entities.City{official}, divipolaOptions{},
+			nil, nil, "0 nuevos, 0 renombrados, 0 vinculados, 0 retirados, 0 con otro departamento, 1 sin cambios", ""},
+		{"vincula por nombre", []divipola.Municipality{medellin}, []entities.City{{Id: 7, Name: "medellín", Active: true}}, divipolaOptions{},
+			nil, []string{"Medellín"}, "0 nuevos, 0 renombrados, 1 vinculados", ""},
+		{"renombra por código", []divipola.Municipality{medellin}, []entities.City{{Id: 1, Name: "Medellin", Active: true, Code: codePtr("05001"), DepartmentCode: "05", Department: "Antioquia"}}, divipolaOptions{},
+			nil, []string{"Medellín"}, "0 nuevos, 1 renombrados", ""},
+		{"conserva mayúsculas", []divipola.Municipality{medellin}, nil, divipolaOptions{keepCase: true},
+			[]string{"MEDELLÍN"}, nil, "1 nuevos", ""},
+		{"nombres repetidos llevan departamento", []divipola.Municipality{buenavistaBoyaca, buenavistaCordoba}, nil, divipolaOptions{},
+			[]string{"Buenavista (Boyacá)", "Buenavista (Córdoba)"}, nil, "2 nuevos", ""},
+		{"retirado sin desactivar", nil, []entities.City{official}, divipolaOptions{},
+			nil, nil, "1 retirados", ""},
+		{"retirado y desactivado", nil, []entities.City{official}, divipolaOptions{deactivateRemoved: true},
+			nil, []string{"Medellín"}, "1 retirados", ""},
+		{"choca con una ciudad fuera del archivo", []divipola.Municipality{medellin}, []entities.City{{Id: 9, Name: "Medellín", Active: true, Code: codePtr("99999")}}, divipolaOptions{},
+			nil, nil, "", "nombres de ciudad repetidos tras la importación: Medellín"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan, err := planDivipola(tt.municipalities, tt.cities, tt.opts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, se esperaba %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error inesperado: %v", err)
+			}
+			if got := cityNames(plan.created); !equalNames(got, tt.created) {
+				t.Errorf("creadas = %v, se esperaba %v", got, tt.created)
+			}
+			if got := cityNames(plan.updated); !equalNames(got, tt.updated) {
+				t.Errorf("actualizadas = %v, se esperaba %v", got, tt.updated)
+			}
+			if !strings.Contains(plan.report.String(), tt.report) {
+				t.Errorf("reporte %q no contiene %q", plan.report.String(), tt.report)
+			}
+		})
+	}
+}
+
+func TestPlanDivipolaIsIdempotent(t *testing.T) {
+	municipalities := []divipola.Municipality{
+		{DepartmentCode: "05", Department: "ANTIOQUIA", Code: "05001", Name: "MEDELLÍN"},
+		{DepartmentCode: "15", Department: "BOYACÁ", Code: "15109", Name: "BUENAVISTA"},
+		{DepartmentCode: "23", Department: "CÓRDOBA", Code: "23079", Name: "BUENAVISTA"},
+	}
+	first, err := planDivipola(municipalities, nil, divipolaOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range first.created {
+		first.created[i].Id = uint(i + 1)
+	}
+	second, err := planDivipola(municipalities, first.created, divipolaOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(second.created) != 0 || len(second.updated) != 0 || second.report.unchanged != 3 {
+		t.Errorf("segunda importación: %d creadas, %d actualizadas, %d sin cambios", len(second.created), len(second.updated), second.report.unchanged)
+	}
+}
+
+func cityNames(cities []entities.City) []string {
+	var names []string
+	for _, city := range cities {
+		names = append(names, city.Name)
+	}
+	return names
+}
+
+// equalNames compara sin importar el orden
+func equalNames(got []string, want []string) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	count := map[string]int{}
+	for _, name := range got {
+		count[name]++
+	}
+	for _, name := range want {
+		count[name]--
+	}
+	for _, n := range count {
+		if n != 0 {
+			return false
+		}
+	}
+	return true
+}
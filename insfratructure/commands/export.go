@@ -0,0 +1,40 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+)
+
+// runExport escribe el catálogo completo en JSON
+func runExport(args []string) error {
+	flags, loader := newFlagSet("export")
+	file := flags.String("file", "-", "archivo de salida (- para la salida estándar)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if _, err := loadConfig(loader); err != nil {
+		return err
+	}
+	if err := connectDatabase(); err != nil {
+		return err
+	}
+
+	cat, err := buildCatalogue(context.Background())
+	if err != nil {
+		return err
+	}
+	var writer io.Writer = os.Stdout
+	if *file != "-" {
+		f, err := os.Create(*file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		writer = f
+	}
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(cat)
+}
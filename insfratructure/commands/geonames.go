@@ -0,0 +1,346 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/safe_msvc_city/core"
+	"github.com/safe_msvc_city/insfratructure/entities"
+	"github.com/safe_msvc_city/insfratructure/geonames"
+)
+
+// divipolaCountry es el país de los municipios con código DIVIPOLA
+const divipolaCountry = "CO"
+
+// geoBatchSize es cuántas ciudades se escriben por transacción
+const geoBatchSize = 1000
+
+// runGeoNames importa ciudades de un volcado local de GeoNames; al volver a importar
+// actualiza por geonameid. El plan necesita todas las ciudades a la vez para que los
+// nombres sean únicos, así que se exige -country o -min-population para acotar la
+// memoria; los cambios se escriben por lotes, cada uno en su transacción, y una
+// importación interrumpida se completa volviendo a ejecutar el comando
+func runGeoNames(args []string) error {
+	flags, loader := newFlagSet("geonames")
+	file := flags.String("file", "", "volcado de GeoNames: cities500.txt, cities15000.zip, allCountries.txt...")
+	admin1 := flags.String("admin1", "", "admin1CodesASCII.txt con los nombres de los departamentos o estados")
+	countries := flags.String("country", "", "códigos ISO de país separados por comas; vacío importa todos")
+	minPopulation := flags.Int64("min-population", 0, "población mínima de las ciudades a importar")
+	batchSize := flags.Int("batch-size", geoBatchSize, "ciudades escritas por transacción")
+	dryRun := flags.Bool("dry-run", false, "muestra el reporte sin escribir en la base de datos")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *file == "" {
+		return errors.New("falta -file con el volcado de GeoNames")
+	}
+	if strings.TrimSpace(*countries) == "" && *minPopulation <= 0 {
+		return errors.New("indique -country o -min-population para acotar las ciudades a importar")
+	}
+	if *batchSize <= 0 {
+		return errors.New("-batch-size debe ser positivo")
+	}
+	if _, err := loadConfig(loader); err != nil {
+		return err
+	}
+
+	filter := geonames.Filter{MinPopulation: *minPopulation}
+	if *countries != "" {
+		filter.Countries = map[string]bool{}
+		for _, country := range strings.Split(*countries, ",") {
+			filter.Countries[strings.ToUpper(strings.TrimSpace(country))] = true
+		}
+	}
+	admin1Names := map[string]string{}
+	if *admin1 != "" {
+		reader, err := geonames.Open(*admin1)
+		if err != nil {
+			return err
+		}
+		admin1Names, err = geonames.ReadAdmin1(reader)
+		reader.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", *admin1, err)
+		}
+	}
+	reader, err := geonames.Open(*file)
+	if err != nil {
+		return err
+	}
+	var places []geonames.Place
+	err = geonames.Read(reader, filter, func(place geonames.Place) error {
+		places = append(places, place)
+		return nil
+	})
+	reader.Close()
+	if err != nil {
+		return fmt.Errorf("%s: %w", *file, err)
+	}
+	if err := connectDatabase(); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	cities, err := core.GetCityInstance().GetCityFindAll(ctx)
+	if err != nil {
+		return err
+	}
+	plan, err := planGeoNames(places, admin1Names, cities)
+	if err != nil {
+		return err
+	}
+	fmt.Print(plan.report)
+	if *dryRun {
+		fmt.Println("simulación: no se escribió ningún cambio")
+		return nil
+	}
+	// Primero las actualizaciones: un renombrado puede liberar el nombre de una ciudad nueva
+	repository := core.GetImportInstance()
+	written := 0
+	total := len(plan.updated) + len(plan.created)
+	for _, batch := range geoBatches(plan.updated, *batchSize) {
+		if _, err := repository.ApplyGeoCities(ctx, nil, batch); err != nil {
+			return fmt.Errorf("no se pudo aplicar la importación tras %d de %d ciudades: %w", written, total, err)
+		}
+		written += len(batch)
+	}
+	for _, batch := range geoBatches(plan.created, *batchSize) {
+		if _, err := repository.ApplyGeoCities(ctx, batch, nil); err != nil {
+			return fmt.Errorf("no se pudo aplicar la importación tras %d de %d ciudades: %w", written, total, err)
+		}
+		written += len(batch)
+	}
+	return nil
+}
+
+// geoBatches parte cities en lotes de a lo sumo size
+func geoBatches(cities []entities.City, size int) [][]entities.City {
+	var batches [][]entities.City
+	for start := 0; start < len(cities); start += size {
+		batches = append(batches, cities[start:min(start+size, len(cities))])
+	}
+	return batches
+}
+
+// geoReport resume la comparación del volcado con las ciudades actuales
+type geoReport struct {
+	places    int
+	added     int
+	updated   int
+	linked    int
+	unchanged int
+	renamed   []divipolaChange
+}
+
+func (r geoReport) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "ciudades en el volcado: %d; %d nuevas, %d actualizadas, %d vinculadas, %d sin cambios\n",
+		r.places, r.added, r.updated, r.linked, r.unchanged)
+	if len(r.renamed) > 0 {
+		fmt.Fprintln(&b, "renombradas:")
+		for _, change := range r.renamed {
+			fmt.Fprintf(&b, "  %s %s (antes %s)\n", change.code, change.name, change.previous)
+		}
+	}
+	return b.String()
+}
+
+type geoPlan struct {
+	created []entities.City
+	updated []entities.City
+	report  geoReport
+}
+
+// Niveles con los que se desambigua el nombre de una ciudad repetida
+const (
+	geoNamePlain = iota
+	geoNameAdmin
+	geoNameCountry
+	geoNameId
+)
+
+// planGeoNames decide qué crear y actualizar sin escribir nada. Cada lugar se busca
+// primero por geonameid y, si no existe, entre las ciudades sin geonameid con el mismo
+// nombre, solo o con el departamento entre paréntesis; esas quedan vinculadas. Las
+// ciudades con código DIVIPOLA conservan su nombre y su departamento. Para el resto,
+// un nombre repetido se completa con el departamento, luego con el país y por último
+// con el geonameid hasta que sea único
+func planGeoNames(places []geonames.Place, admin1 map[string]string, cities []entities.City) (geoPlan, error) {
+	plan := geoPlan{report: geoReport{places: len(places)}}
+	byGeoname := map[uint64]entities.City{}
+	withoutGeoname := map[string]entities.City{}
+	for _, city := range cities {
+		if city.GeonameId != nil {
+			byGeoname[*city.GeonameId] = city
+		} else {
+			withoutGeoname[strings.ToLower(city.Name)] = city
+		}
+	}
+	adminName := func(place geonames.Place) string {
+		return admin1[place.CountryCode+"."+place.Admin1Code]
+	}
+	candidate := func(place geonames.Place, level int) string {
+		admin := adminName(place)
+		switch {
+		case level == geoNamePlain:
+			return place.Name
+		case level == geoNameAdmin && admin != "":
+			return fmt.Sprintf("%s (%s)", place.Name, admin)
+		case level <= geoNameCountry && admin != "":
+			return fmt.Sprintf("%s (%s, %s)", place.Name, admin, place.CountryCode)
+		case level <= geoNameCountry:
+			return fmt.Sprintf("%s (%s)", place.Name, place.CountryCode)
+		case admin != "":
+			return fmt.Sprintf("%s (%s, %s, %d)", place.Name, admin, place.CountryCode, place.GeonameId)
+		default:
+			return fmt.Sprintf("%s (%s, %d)", place.Name, place.CountryCode, place.GeonameId)
+		}
+	}
+
+	// Un nombre solo sirve para vincular si ningún otro lugar del volcado lo comparte
+	plain := map[string]int{}
+	withAdmin := map[string]int{}
+	for _, place := range places {
+		plain[strings.ToLower(candidate(place, geoNamePlain))]++
+		withAdmin[strings.ToLower(candidate(place, geoNameAdmin))]++
+	}
+	existing := make([]*entities.City, len(places))
+	fixed := make([]bool, len(places))
+	for i, place := range places {
+		if city, ok := byGeoname[place.GeonameId]; ok {
+			existing[i] = &city
+			fixed[i] = city.Code != nil
+			continue
+		}
+		for _, level := range []int{geoNameAdmin, geoNamePlain} {
+			name := strings.ToLower(candidate(place, level))
+			counts := withAdmin
+			if level == geoNamePlain {
+				counts = plain
+			}
+			city, ok := withoutGeoname[name]
+			if !ok || counts[name] > 1 || city.Code != nil && place.CountryCode != divipolaCountry ||
+				city.CountryCode != "" && city.CountryCode != place.CountryCode {
+				continue
+			}
+			delete(withoutGeoname, name)
+			existing[i], fixed[i] = &city, city.Code != nil
+			break
+		}
+	}
+
+	// Los nombres que no cambia esta importación quedan ocupados
+	owned := map[uint]bool{}
+	for i, city := range existing {
+		if city != nil && !fixed[i] {
+			owned[city.Id] = true
+		}
+	}
+	occupied := map[string]bool{}
+	for _, city := range cities {
+		if !owned[city.Id] {
+			occupied[strings.ToLower(city.Name)] = true
+		}
+	}
+	levels := make([]int, len(places))
+	for {
+		counts := map[string]int{}
+		for i, place := range places {
+			if !fixed[i] {
+				counts[strings.ToLower(candidate(place, levels[i]))]++
+			}
+		}
+		bumped, stuck := false, []string{}
+		for i, place := range places {
+			name := strings.ToLower(candidate(place, levels[i]))
+			if fixed[i] || counts[name] == 1 && !occupied[name] {
+				continue
+			}
+			if levels[i] == geoNameId {
+				stuck = append(stuck, candidate(place, levels[i]))
+				continue
+			}
+			levels[i]++
+			bumped = true
+		}
+		if !bumped {
+			if len(stuck) > 0 {
+				sort.Strings(stuck)
+				return plan, fmt.Errorf("nombres de ciudad repetidos tras la importación: %s", strings.Join(stuck, ", "))
+			}
+			break
+		}
+	}
+
+	for i, place := range places {
+		id, country, timezone := place.GeonameId, place.CountryCode, place.Timezone
+		latitude, longitude, population := place.Latitude, place.Longitude, place.Population
+		city := entities.City{
+			Name:           candidate(place, levels[i]),
+			Active:         true,
+			DepartmentCode: place.Admin1Code,
+			Department:     adminName(place),
+			GeonameId:      &id,
+			CountryCode:    country,
+			Latitude:       &latitude,
+			Longitude:      &longitude,
+			Population:     &population,
+			Timezone:       timezone,
+			AlternateNames: place.AlternateNames,
+		}
+		previous := existing[i]
+		if previous == nil {
+			plan.created = append(plan.created, city)
+			plan.report.added++
+			continue
+		}
+		city.Id, city.Active, city.Code = previous.Id, previous.Active, previous.Code
+		if fixed[i] {
+			city.Name, city.DepartmentCode, city.Department = previous.Name, previous.DepartmentCode, previous.Department
+		}
+		if sameGeoCity(*previous, city) {
+			plan.report.unchanged++
+			continue
+		}
+		plan.updated = append(plan.updated, city)
+		switch {
+		case previous.GeonameId == nil:
+			plan.report.linked++
+		default:
+			plan.report.updated++
+		}
+		if previous.Name != city.Name {
+			plan.report.renamed = append(plan.report.renamed, divipolaChange{
+				code:     fmt.Sprint(id),
+				name:     city.Name,
+				previous: previous.Name,
+			})
+		}
+	}
+	return plan, nil
+}
+
+// sameGeoCity indica si la ciudad ya tiene todos los datos que trae el volcado
+func sameGeoCity(previous entities.City, city entities.City) bool {
+	return previous.Name == city.Name &&
+		previous.DepartmentCode == city.DepartmentCode &&
+		previous.Department == city.Department &&
+		equalPointer(previous.GeonameId, city.GeonameId) &&
+		previous.CountryCode == city.CountryCode &&
+		equalPointer(previous.Latitude, city.Latitude) &&
+		equalPointer(previous.Longitude, city.Longitude) &&
+		equalPointer(previous.Population, city.Population) &&
+		previous.Timezone == city.Timezone &&
+		slices.Equal(previous.AlternateNames, city.AlternateNames)
+}
+
+func equalPointer[T comparable](a *T, b *T) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
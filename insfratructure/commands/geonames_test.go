@@ -0,0 +1,122 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/safe_msvc_city/insfratructure/entities"
+	"github.com/safe_msvc_city/insfratructure/geonames"
+)
+
+func geonameIdPtr(id uint64) *uint64 {
+	return &id
+}
+
+func TestPlanGeoNames(t *testing.T) {
+	admin1 := map[string]string{"CO.02": "Antioquia", "CO.15": "Boyacá", "CO.23": "Córdoba", "US.TX": "Texas"}
+	place := func(id uint64, name string, country string, admin string) geonames.Place {
+		return geonames.Place{GeonameId: id, Name: name, CountryCode: country, Admin1Code: admin, Population: 1000, Timezone: "America/Bogota"}
+	}
+	medellin := place(3674962, "Medellín", "CO", "02")
+
+	tests := []struct {
+		name    string
+		places  []geonames.Place
+		cities  []entities.City
+		created []string
+		updated []string
+		report  string
+		wantErr string
+	}{
+		{"nueva", []geonames.Place{medellin}, nil,
+			[]string{"Medellín"}, nil, "1 nuevas, 0 actualizadas, 0 vinculadas", ""},
+		{"repetida en el departamento", []geonames.Place{place(1, "Buenavista", "CO", "15"), place(2, "Buenavista", "CO", "23")}, nil,
+			[]string{"Buenavista (Boyacá)", "Buenavista (Córdoba)"}, nil, "2 nuevas", ""},
+		{"repetida en el país", []geonames.Place{place(1, "Paris", "US", "TX"), place(2, "Paris", "FR", "11")}, nil,
+			[]string{"Paris (Texas)", "Paris (FR)"}, nil, "2 nuevas", ""},
+		{"repetida hasta el geonameid", []geonames.Place{place(1, "Olaya", "CO", "02"), place(2, "Olaya", "CO", "02")}, nil,
+			[]string{"Olaya (Antioquia, CO, 1)", "Olaya (Antioquia, CO, 2)"}, nil, "2 nuevas", ""},
+		{"ocupada por una ciudad existente", []geonames.Place{medellin}, []entities.City{{Id: 5, Name: "Medellín", Active: true, CountryCode: "MX"}},
+			[]string{"Medellín (Antioquia)"}, nil, "1 nuevas", ""},
+		{"vincula por nombre", []geonames.Place{medellin}, []entities.City{{Id: 5, Name: "Medellín", Active: true}},
+			nil, []string{"Medellín"}, "0 nuevas, 0 actualizadas, 1 vinculadas", ""},
+		{"vincula con departamento", []geonames.Place{medellin}, []entities.City{{Id: 5, Name: "Medellín (Antioquia)", Active: true}},
+			nil, []string{"Medellín"}, "1 vinculadas", ""},
+		{"conserva el nombre DIVIPOLA", []geonames.Place{place(3674962, "Medellin", "CO", "02")},
+			[]entities.City{{Id: 5, Name: "Medellín", Active: true, Code: codePtr("05001"), DepartmentCode: "05", Department: "Antioquia", GeonameId: geonameIdPtr(3674962)}},
+			nil, []string{"Medellín"}, "1 actualizadas", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan, err := planGeoNames(tt.places, admin1, tt.cities)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, se esperaba %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error inesperado: %v", err)
+			}
+			if got := cityNames(plan.created); !equalNames(got, tt.created) {
+				t.Errorf("creadas = %v, se esperaba %v", got, tt.created)
+			}
+			if got := cityNames(plan.updated); !equalNames(got, tt.updated) {
+				t.Errorf("actualizadas = %v, se esperaba %v", got, tt.updated)
+			}
+			if !strings.Contains(plan.report.String(), tt.report) {
+				t.Errorf("reporte %q no contiene %q", plan.report.String(), tt.report)
+			}
+		})
+	}
+}
+
+func TestPlanGeoNamesIsIdempotent(t *testing.T) {
+	admin1 := map[string]string{"CO.15": "Boyacá", "CO.23": "Córdoba"}
+	places := []geonames.Place{
+		{GeonameId: 1, Name: "Buenavista", CountryCode: "CO", Admin1Code: "15", AlternateNames: []string{"Buena Vista"}},
+		{GeonameId: 2, Name: "Buenavista", CountryCode: "CO", Admin1Code: "23"},
+		{GeonameId: 3, Name: "Tunja", CountryCode: "CO", Admin1Code: "15"},
+	}
+	first, err := planGeoNames(places, admin1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range first.created {
+		first.created[i].Id = uint(i + 1)
+	}
+	second, err := planGeoNames(places, admin1, first.created)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(second.created) != 0 || len(second.updated) != 0 || second.report.unchanged != 3 {
+		t.Errorf("segunda importación: %d creadas, %d actualizadas, %d sin cambios", len(second.created), len(second.updated), second.report.unchanged)
+	}
+}
+
+func TestGeoBatches(t *testing.T) {
+	cities := make([]entities.City, 5)
+	tests := []struct {
+		size  int
+		sizes []int
+	}{
+		{2, []int{2, 2, 1}},
+		{5, []int{5}},
+		{10, []int{5}},
+		{1, []int{1, 1, 1, 1, 1}},
+	}
+	for _, tt := range tests {
+		batches := geoBatches(cities, tt.size)
+		if len(batches) != len(tt.sizes) {
+			t.Fatalf("size %d: %d lotes, se esperaban %d", tt.size, len(batches), len(tt.sizes))
+		}
+		for i, batch := range batches {
+			if len(batch) != tt.sizes[i] {
+				t.Errorf("size %d: lote %d tiene %d, se esperaban %d", tt.size, i, len(batch), tt.sizes[i])
+			}
+		}
+	}
+	if batches := geoBatches(nil, 3); len(batches) != 0 {
+		t.Errorf("sin ciudades: %d lotes", len(batches))
+	}
+}
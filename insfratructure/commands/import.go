@@ -0,0 +1,48 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"gorm.io/gorm"
+)
+
+// runImport importa un catálogo JSON desde un archivo o desde la entrada estándar
+func runImport(args []string) error {
+	flags, loader := newFlagSet("import")
+	file := flags.String("file", "-", "archivo JSON a importar (- para la entrada estándar)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if _, err := loadConfig(loader); err != nil {
+		return err
+	}
+	if err := connectDatabase(); err != nil {
+		return err
+	}
+
+	var reader io.Reader = os.Stdin
+	if *file != "-" {
+		f, err := os.Open(*file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		reader = f
+	}
+	var cat catalogue
+	if err := json.NewDecoder(reader).Decode(&cat); err != nil {
+		return fmt.Errorf("catálogo inválido: %w", err)
+	}
+	report, err := importCatalogue(context.Background(), cat)
+	fmt.Println(report)
+	return err
+}
+
+func isNotFound(err error) bool {
+	return errors.Is(err, gorm.ErrRecordNotFound)
+}
@@ -0,0 +1,73 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/safe_msvc_city/insfratructure/database"
+)
+
+const migrateUsage = "uso: migrate up|down [-steps N]|status"
+
+// runMigrate ejecuta el comando migrate up|down|status
+func runMigrate(args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+	flags, loader := newFlagSet("migrate " + args[0])
+	steps := flags.Int("steps", 1, "número de migraciones a revertir con down")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+	if _, err := loadConfig(loader); err != nil {
+		return err
+	}
+	if err := connectDatabase(); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	migrator, err := database.GetMigrator()
+	if err != nil {
+		return err
+	}
+	defer database.CloseConnection()
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, version := range applied {
+			fmt.Printf("aplicada %04d\n", version)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Println("no hay migraciones pendientes")
+		}
+		return err
+	case "down":
+		reverted, err := migrator.Down(ctx, *steps)
+		for _, version := range reverted {
+			fmt.Printf("revertida %04d\n", version)
+		}
+		return err
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+		for _, status := range statuses {
+			state, appliedAt := "pending", "-"
+			if status.Applied {
+				state, appliedAt = "applied", status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\t%s\n", status.Version, status.Name, state, appliedAt)
+		}
+		return w.Flush()
+	default:
+		return errors.New(migrateUsage)
+	}
+}
@@ -0,0 +1,43 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+)
+
+// seedCatalogue contiene los datos de ejemplo para entornos de desarrollo
+var seedCatalogue = catalogue{
+	Cities: []catalogueCity{
+		{Name: "Bogotá", Active: true, States: []catalogueState{
+			{Name: "Chapinero", ZipCode: "110231", Active: true},
+			{Name: "Usaquén", ZipCode: "110111", Active: true},
+			{Name: "Teusaquillo", ZipCode: "111311", Active: true},
+		}},
+		{Name: "Medellín", Active: true, States: []catalogueState{
+			{Name: "El Poblado", ZipCode: "050021", Active: true},
+			{Name: "Laureles", ZipCode: "050031", Active: true},
+		}},
+		{Name: "Cali", Active: true, States: []catalogueState{
+			{Name: "San Antonio", ZipCode: "760044", Active: true},
+			{Name: "Granada", ZipCode: "760045", Active: true},
+		}},
+	},
+}
+
+// runSeed carga el catálogo de ejemplo; es idempotente
+func runSeed(args []string) error {
+	flags, loader := newFlagSet("seed")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if _, err := loadConfig(loader); err != nil {
+		return err
+	}
+	if err := connectDatabase(); err != nil {
+		return err
+	}
+
+	report, err := importCatalogue(context.Background(), seedCatalogue)
+	fmt.Println(report)
+	return err
+}
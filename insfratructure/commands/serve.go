@@ -0,0 +1,339 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/safe_msvc_city/core"
+	"github.com/safe_msvc_city/insfratructure/cache"
+	"github.com/safe_msvc_city/insfratructure/changes"
+	"github.com/safe_msvc_city/insfratructure/config"
+	"github.com/safe_msvc_city/insfratructure/database"
+	"github.com/safe_msvc_city/insfratructure/events"
+	"github.com/safe_msvc_city/insfratructure/health"
+	"github.com/safe_msvc_city/insfratructure/listener"
+	"github.com/safe_msvc_city/insfratructure/logging"
+	"github.com/safe_msvc_city/insfratructure/metrics"
+	"github.com/safe_msvc_city/insfratructure/middleware"
+	"github.com/safe_msvc_city/insfratructure/outbox"
+	"github.com/safe_msvc_city/insfratructure/routers"
+	"github.com/safe_msvc_city/insfratructure/shutdown"
+	"github.com/safe_msvc_city/insfratructure/snapshot"
+	"github.com/safe_msvc_city/insfratructure/tracing"
+	"github.com/safe_msvc_city/insfratructure/webhooks"
+	"github.com/safe_msvc_city/usecase/service"
+	"gorm.io/gorm"
+)
+
+// checkTimeout es el tiempo máximo del ping que hace el chequeo de la base de datos
+const checkTimeout = 2 * time.Second
+
+// runServe conecta a la base de datos, aplica las migraciones pendientes, inicia el
+// monitor de salud y levanta el servidor HTTP hasta recibir SIGINT o SIGTERM
+func runServe(args []string) error {
+	flags, loader := newFlagSet("serve")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	cfg, err := loadConfig(loader)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	shutdownTracing, err := tracing.Setup(ctx, cfg.Tracing)
+	if err != nil {
+		return err
+	}
+	shutdown.Register("tracing", shutdownTracing)
+
+	db, err := database.Connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer database.CloseConnection()
+	if err := database.Migrate(ctx); err != nil {
+		return err
+	}
+	database.StartMonitor(ctx, db, cfg.Database.PingInterval)
+	registerHealthChecks(db)
+	if err := setupCache(ctx, cfg.Cache, cfg.Database); err != nil {
+		return err
+	}
+
+	if err := registerDBStats(db); err != nil {
+		return err
+	}
+	startSnapshots(ctx, cfg.Snapshot)
+	// El relay, los webhooks y el stream de cambios no usan ctx: deben seguir vivos
+	// mientras se drenan las peticiones, así que se detienen desde gracefulShutdown
+	workers, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+	streams, closeStreams := context.WithCancel(context.Background())
+	defer closeStreams()
+	if err := startRelay(workers, db, *cfg); err != nil {
+		return err
+	}
+	startChanges(streams, db, cfg.Changes)
+	service.ConfigureSync(cfg.Sync)
+	go pruneTombstones(ctx, cfg.Sync)
+
+	middleware.Configure(cfg.Auth)
+	if cfg.Auth.JWTSecret == "" {
+		slog.Warn("auth.jwt_secret is empty; token protected routes reject every request")
+	}
+	app := fiber.New()
+	app.Use(tracing.Middleware)
+	app.Use(logging.Middleware)
+	app.Use(metrics.Middleware)
+	app.Use(snapshot.Middleware)
+	routers.NewHealthRouter(app)
+	routers.NewMetricsRouter(app)
+	routers.NewAdminRouter(app)
+	routers.NewCityRouter(app)
+	routers.NewStatesRouter(app)
+	routers.NewWebhookRouter(app)
+	routers.NewChangesRouter(app)
+	routers.NewSyncRouter(app)
+	routers.NewImportRouter(app)
+	routers.NewExportRouter(app)
+
+	listenErr := make(chan error, 1)
+	go func() {
+		listenErr <- app.Listen(cfg.Server.Addr)
+	}()
+	health.SetReady(true)
+	slog.Info("listening", "addr", cfg.Server.Addr, "profile", cfg.Profile)
+
+	select {
+	case err := <-listenErr:
+		return err
+	case <-ctx.Done():
+	}
+	stop()
+	return gracefulShutdown(app, cfg.Server, closeStreams, stopWorkers)
+}
+
+// gracefulShutdown marca el servicio como no listo, espera a que el balanceador lo
+// note, drena las peticiones en curso dentro del plazo configurado y ejecuta los
+// hooks de apagado. Los streams de cambios se cierran justo antes del drenaje porque
+// una conexión abierta lo retendría hasta el plazo; el relay y los webhooks se
+// detienen después, cuando ya no llegan escrituras. La base de datos se cierra al
+// volver a runServe
+func gracefulShutdown(app *fiber.App, cfg config.Server, closeStreams, stopWorkers context.CancelFunc) error {
+	slog.Info("shutting down", "drain_delay", cfg.DrainDelay.String(), "timeout", cfg.ShutdownTimeout.String())
+	health.SetReady(false)
+	time.Sleep(cfg.DrainDelay)
+
+	closeStreams()
+	err := app.ShutdownWithTimeout(cfg.ShutdownTimeout)
+	if err != nil {
+		slog.Warn("could not drain all requests", "error", err)
+	}
+	stopWorkers()
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	shutdown.Run(ctx)
+	return err
+}
+
+// registerDBStats publica las estadísticas del pool en /metrics
+func registerDBStats(db *gorm.DB) error {
+	dbSQL, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return metrics.RegisterDBStats(dbSQL)
+}
+
+// registerHealthChecks registra los chequeos que usa /readyz
+func registerHealthChecks(db *gorm.DB) {
+	health.Register("database", func(ctx context.Context) error {
+		status := database.Ping(ctx, db, checkTimeout)
+		if status.Healthy {
+			return nil
+		}
+		// Con un snapshot la réplica sigue respondiendo lecturas, así que no se saca del balanceador
+		if _, ok := snapshot.Current(); ok {
+			return nil
+		}
+		return errors.New(status.LastError)
+	})
+	health.Register("migrations", func(ctx context.Context) error {
+		migrator, err := database.GetMigrator()
+		if err != nil {
+			return err
+		}
+		pending, err := migrator.Pending(ctx)
+		if err != nil {
+			return err
+		}
+		if pending > 0 {
+			return fmt.Errorf("%d migraciones pendientes", pending)
+		}
+		return nil
+	})
+}
+
+// setupCache crea el backend de caché, lo pone delante de los repositorios y lo
+// precarga en segundo plano; /readyz falla hasta que termina la precarga. Con
+// cfg.Listen las escrituras de otras réplicas invalidan la caché vía LISTEN/NOTIFY
+func setupCache(ctx context.Context, cfg config.Cache, db config.Database) error {
+	backend, err := cache.New(cfg)
+	if err != nil || backend == nil {
+		return err
+	}
+	core.ConfigureCache(backend, cfg.TTL)
+	if cfg.Listen {
+		listener.Start(ctx, db.DSN(), core.CacheInvalidator())
+	}
+	shutdown.Register("cache", func(context.Context) error {
+		return backend.Close()
+	})
+
+	var warmed atomic.Bool
+	health.Register("cache", func(ctx context.Context) error {
+		if !warmed.Load() {
+			return errors.New("precargando la caché")
+		}
+		return backend.Ping(ctx)
+	})
+	go func() {
+		start := time.Now()
+		if _, err := core.GetCityInstance().GetCityFindAll(ctx); err != nil {
+			slog.Warn("cache warm-up failed", "error", err)
+		} else if _, err := core.GetStatesInstance().GetStatesFindAll(ctx); err != nil {
+			slog.Warn("cache warm-up failed", "error", err)
+		} else {
+			slog.Info("cache warmed", "backend", cfg.Backend, "duration_ms", time.Since(start).Milliseconds())
+		}
+		// Una precarga fallida no bloquea el tráfico: la caché se llena con las lecturas
+		warmed.Store(true)
+	}()
+	return nil
+}
+
+// startSnapshots carga el snapshot persistido, si existe, y lo refresca desde la base
+// de datos cada cfg.Interval hasta que ctx termine
+func startSnapshots(ctx context.Context, cfg config.Snapshot) {
+	if cfg.Path != "" {
+		saved, err := snapshot.Load(cfg.Path)
+		switch {
+		case err == nil:
+			snapshot.Set(saved)
+			slog.Info("snapshot loaded", "path", cfg.Path, "taken_at", saved.TakenAt)
+		case !errors.Is(err, os.ErrNotExist):
+			slog.Warn("snapshot load failed", "path", cfg.Path, "error", err)
+		}
+	}
+	refresh := func() {
+		taken, err := core.TakeSnapshot(ctx)
+		if err != nil {
+			slog.Warn("snapshot refresh failed", "error", err)
+			return
+		}
+		snapshot.Set(taken)
+		if cfg.Path == "" {
+			return
+		}
+		if err := snapshot.Save(cfg.Path, taken); err != nil {
+			slog.Warn("snapshot save failed", "path", cfg.Path, "error", err)
+		}
+	}
+	go func() {
+		refresh()
+		ticker := time.NewTicker(cfg.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				refresh()
+			}
+		}
+	}()
+}
+
+// startRelay publica en segundo plano los eventos pendientes del outbox en el bus del
+// proceso, que encola los webhooks, y en el broker externo si hay uno configurado.
+// También arranca el envío de webhooks. En el apagado se publica lo que quede en el
+// outbox, dentro del plazo, antes de cerrar los brokers
+func startRelay(ctx context.Context, db *gorm.DB, cfg config.Config) error {
+	webhookRepository := core.GetWebhookInstance()
+	local := events.NewInProcess()
+	local.Subscribe(webhooks.Enqueue(webhookRepository))
+	brokers := events.Fanout{local}
+
+	external, err := events.NewBroker(cfg.Events)
+	if err != nil {
+		return err
+	}
+	if external != nil {
+		brokers = append(brokers, external)
+	}
+	relay := outbox.NewRelay(db, brokers, cfg.Events)
+	shutdown.Register("events", func(ctx context.Context) error {
+		flushOutbox(ctx, relay, cfg.Events.BatchSize)
+		return brokers.Close()
+	})
+	go relay.Run(ctx)
+	go webhooks.NewWorker(webhookRepository, cfg.Webhooks).Run(ctx)
+	slog.Info("outbox relay started", "broker", cfg.Events.Broker, "interval", cfg.Events.RelayInterval.String())
+	return nil
+}
+
+// flushOutbox publica los eventos pendientes hasta vaciar el outbox, fallar o agotar
+// ctx; lo que quede lo publica el relay al volver a arrancar
+func flushOutbox(ctx context.Context, relay *outbox.Relay, batchSize int) {
+	for {
+		published, err := relay.Flush(ctx)
+		if err != nil {
+			slog.Warn("final outbox flush failed", "error", err)
+			return
+		}
+		if published < batchSize {
+			return
+		}
+	}
+}
+
+// startChanges carga los eventos recientes y arranca el hub del stream de cambios; el
+// hub cierra los streams abiertos cuando ctx termina para no retrasar el apagado
+func startChanges(ctx context.Context, db *gorm.DB, cfg config.Changes) {
+	hub := changes.NewHub(db, cfg)
+	if err := hub.Load(ctx); err != nil {
+		slog.Warn("change stream buffer not loaded", "error", err)
+	}
+	changes.SetDefault(hub)
+	go hub.Run(ctx)
+}
+
+// pruneTombstones borra cada hora las marcas de eliminación más viejas que la retención
+func pruneTombstones(ctx context.Context, cfg config.Sync) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for {
+		pruned, err := core.GetSyncInstance().PruneDeletions(ctx, time.Now().Add(-cfg.TombstoneRetention))
+		if err != nil {
+			slog.Warn("tombstone prune failed", "error", err)
+		} else if pruned > 0 {
+			slog.Info("tombstones pruned", "deletions", pruned)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// runUserToken genera un JWT HS256 para pruebas locales
+func runUserToken(args []string) error {
+	flags, loader := newFlagSet("user-token")
+	subject := flags.String("sub", "dev", "usuario (claim sub)")
+	role := flags.String("role", "admin", "rol (claim role)")
+	ttl := flags.Duration("ttl", 24*time.Hour, "vigencia del token")
+	secret := flags.String("secret", "", "clave de firma (por defecto auth.jwt_secret)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	cfg, err := loadConfig(loader)
+	if err != nil {
+		return err
+	}
+
+	key := *secret
+	if key == "" {
+		key = cfg.Auth.JWTSecret
+	}
+	if key == "" {
+		return errors.New("se requiere -secret, JWT_SECRET o auth.jwt_secret")
+	}
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub":  *subject,
+		"role": *role,
+		"iat":  now.Unix(),
+		"exp":  now.Add(*ttl).Unix(),
+	})
+	signed, err := token.SignedString([]byte(key))
+	if err != nil {
+		return err
+	}
+	fmt.Println(signed)
+	return nil
+}
@@ -0,0 +1,428 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// Perfiles soportados
+const (
+	ProfileDev  = "dev"
+	ProfileTest = "test"
+	ProfileProd = "prod"
+)
+
+// Config agrupa toda la configuración del servicio
+type Config struct {
+	Profile  string   `yaml:"profile" toml:"profile"`
+	Server   Server   `yaml:"server" toml:"server"`
+	Database Database `yaml:"database" toml:"database"`
+	Auth     Auth     `yaml:"auth" toml:"auth"`
+	Tracing  Tracing  `yaml:"tracing" toml:"tracing"`
+	Logging  Logging  `yaml:"logging" toml:"logging"`
+	Cache    Cache    `yaml:"cache" toml:"cache"`
+	Snapshot Snapshot `yaml:"snapshot" toml:"snapshot"`
+	Events   Events   `yaml:"events" toml:"events"`
+	Webhooks Webhooks `yaml:"webhooks" toml:"webhooks"`
+	Changes  Changes  `yaml:"changes" toml:"changes"`
+	Sync     Sync     `yaml:"sync" toml:"sync"`
+}
+
+// Server contiene la configuración del servidor HTTP
+type Server struct {
+	Addr string `yaml:"addr" toml:"addr"`
+	// ShutdownTimeout es el tiempo máximo para drenar las peticiones en curso al apagar
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout"`
+	// DrainDelay es la espera entre marcar el servicio como no listo y dejar de aceptar
+	// conexiones, para que el balanceador deje de enviar tráfico
+	DrainDelay time.Duration `yaml:"drain_delay" toml:"drain_delay"`
+}
+
+// Database contiene los datos de conexión a Postgres
+type Database struct {
+	Host     string `yaml:"host" toml:"host"`
+	Port     int    `yaml:"port" toml:"port"`
+	User     string `yaml:"user" toml:"user"`
+	Password string `yaml:"password" toml:"password"`
+	Name     string `yaml:"name" toml:"name"`
+	SSLMode  string `yaml:"sslmode" toml:"sslmode"`
+	Pool     Pool   `yaml:"pool" toml:"pool"`
+	Retry    Retry  `yaml:"retry" toml:"retry"`
+	// PingInterval es cada cuánto se verifica que la base de datos responda
+	PingInterval time.Duration `yaml:"ping_interval" toml:"ping_interval"`
+	// SlowQueryThreshold es la duración a partir de la cual una consulta se registra
+	// como lenta; 0 desactiva el log de consultas lentas
+	SlowQueryThreshold time.Duration `yaml:"slow_query_threshold" toml:"slow_query_threshold"`
+}
+
+// Pool contiene los parámetros del pool de conexiones de database/sql
+type Pool struct {
+	MaxOpenConns    int           `yaml:"max_open_conns" toml:"max_open_conns"`
+	MaxIdleConns    int           `yaml:"max_idle_conns" toml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" toml:"conn_max_lifetime"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time" toml:"conn_max_idle_time"`
+}
+
+// Retry define los reintentos con backoff exponencial al conectar en el arranque
+type Retry struct {
+	MaxAttempts    int           `yaml:"max_attempts" toml:"max_attempts"`
+	InitialBackoff time.Duration `yaml:"initial_backoff" toml:"initial_backoff"`
+	MaxBackoff     time.Duration `yaml:"max_backoff" toml:"max_backoff"`
+}
+
+// Auth contiene la configuración de autenticación
+type Auth struct {
+	JWTSecret string `yaml:"jwt_secret" toml:"jwt_secret"`
+}
+
+// Tracing configura el exportador de OpenTelemetry
+type Tracing struct {
+	// Exporter es otlp, stdout o none
+	Exporter string `yaml:"exporter" toml:"exporter"`
+	// Endpoint es host:puerto del colector OTLP/HTTP; vacío usa OTEL_EXPORTER_OTLP_ENDPOINT
+	Endpoint    string  `yaml:"endpoint" toml:"endpoint"`
+	Insecure    bool    `yaml:"insecure" toml:"insecure"`
+	ServiceName string  `yaml:"service_name" toml:"service_name"`
+	SampleRatio float64 `yaml:"sample_ratio" toml:"sample_ratio"`
+}
+
+// Logging configura el logger estructurado
+type Logging struct {
+	// Level es debug, info, warn o error
+	Level string `yaml:"level" toml:"level"`
+	// Format es json o text
+	Format string `yaml:"format" toml:"format"`
+}
+
+// Cache configura la caché de lectura de ciudades y barrios
+type Cache struct {
+	// Backend es memory, redis o none
+	Backend string        `yaml:"backend" toml:"backend"`
+	TTL     time.Duration `yaml:"ttl" toml:"ttl"`
+	// MaxEntries limita el número de claves del backend memory (LRU)
+	MaxEntries int `yaml:"max_entries" toml:"max_entries"`
+	// Listen activa el LISTEN de Postgres que invalida la caché cuando otra réplica
+	// modifica ciudades o barrios
+	Listen bool  `yaml:"listen" toml:"listen"`
+	Redis  Redis `yaml:"redis" toml:"redis"`
+}
+
+// Snapshot configura la copia del catálogo que responde las lecturas cuando la base
+// de datos no está disponible
+type Snapshot struct {
+	// Interval es cada cuánto se refresca el snapshot desde la base de datos
+	Interval time.Duration `yaml:"interval" toml:"interval"`
+	// Path es el archivo donde se persiste el snapshot para sobrevivir reinicios;
+	// vacío lo mantiene solo en memoria
+	Path string `yaml:"path" toml:"path"`
+}
+
+// Events configura el outbox y el broker de los eventos de dominio
+type Events struct {
+	// Broker es memory, nats o kafka. Los consumidores del proceso (webhooks) reciben
+	// los eventos siempre; memory (o none) no usa un broker externo
+	Broker string `yaml:"broker" toml:"broker"`
+	// RelayInterval es cada cuánto el relay busca eventos pendientes en el outbox
+	RelayInterval time.Duration `yaml:"relay_interval" toml:"relay_interval"`
+	BatchSize     int           `yaml:"batch_size" toml:"batch_size"`
+	// Retention es cuánto se conservan en el outbox los eventos ya publicados
+	Retention time.Duration `yaml:"retention" toml:"retention"`
+	NATS      NATS          `yaml:"nats" toml:"nats"`
+	Kafka     Kafka         `yaml:"kafka" toml:"kafka"`
+}
+
+// NATS contiene los datos de conexión a NATS
+type NATS struct {
+	URL string `yaml:"url" toml:"url"`
+	// SubjectPrefix antecede al tipo de evento: <prefijo>.CityCreated
+	SubjectPrefix string `yaml:"subject_prefix" toml:"subject_prefix"`
+	// Stream es el stream de JetStream que guarda los subjects <prefijo>.>; se crea al
+	// conectar si no existe
+	Stream string `yaml:"stream" toml:"stream"`
+}
+
+// Kafka contiene los datos de conexión a Kafka
+type Kafka struct {
+	Brokers []string `yaml:"brokers" toml:"brokers"`
+	Topic   string   `yaml:"topic" toml:"topic"`
+}
+
+// Webhooks configura el envío de las entregas de webhooks
+type Webhooks struct {
+	// Interval es cada cuánto se buscan entregas pendientes
+	Interval time.Duration `yaml:"interval" toml:"interval"`
+	// Timeout es el tiempo máximo de cada petición al partner
+	Timeout   time.Duration `yaml:"timeout" toml:"timeout"`
+	BatchSize int           `yaml:"batch_size" toml:"batch_size"`
+	// MaxAttempts es el número de intentos antes de pasar la entrega a la lista de muertas
+	MaxAttempts int `yaml:"max_attempts" toml:"max_attempts"`
+	// InitialBackoff se duplica en cada reintento hasta MaxBackoff
+	InitialBackoff time.Duration `yaml:"initial_backoff" toml:"initial_backoff"`
+	MaxBackoff     time.Duration `yaml:"max_backoff" toml:"max_backoff"`
+}
+
+// Changes configura el stream de cambios por Server-Sent Events
+type Changes struct {
+	// BufferSize es cuántos eventos recientes se guardan para reanudar con Last-Event-ID
+	BufferSize int `yaml:"buffer_size" toml:"buffer_size"`
+	// PollInterval es cada cuánto se leen los eventos nuevos del outbox
+	PollInterval time.Duration `yaml:"poll_interval" toml:"poll_interval"`
+	// Heartbeat es cada cuánto se envía un comentario para mantener viva la conexión
+	Heartbeat time.Duration `yaml:"heartbeat" toml:"heartbeat"`
+}
+
+// Sync configura la sincronización incremental de /api/sync
+type Sync struct {
+	// TombstoneRetention es cuánto se conservan las marcas de eliminación; un token
+	// más viejo obliga al cliente a descargar el catálogo completo
+	TombstoneRetention time.Duration `yaml:"tombstone_retention" toml:"tombstone_retention"`
+	// Overlap se resta al token para incluir escrituras que confirmaron tarde
+	Overlap time.Duration `yaml:"overlap" toml:"overlap"`
+}
+
+// Redis contiene los datos de conexión a Redis
+type Redis struct {
+	Addr     string `yaml:"addr" toml:"addr"`
+	Password string `yaml:"password" toml:"password"`
+	DB       int    `yaml:"db" toml:"db"`
+	Prefix   string `yaml:"prefix" toml:"prefix"`
+}
+
+// DSN devuelve la cadena de conexión para el driver de Postgres
+func (d Database) DSN() string {
+	return fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%d sslmode=%s",
+		d.Host, d.User, d.Password, d.Name, d.Port, d.SSLMode)
+}
+
+// Default devuelve los valores por defecto del perfil indicado
+func Default(profile string) Config {
+	cfg := Config{
+		Profile: profile,
+		Server: Server{
+			Addr:            ":3014",
+			ShutdownTimeout: 30 * time.Second,
+		},
+		Database: Database{
+			Host:    "localhost",
+			Port:    5432,
+			User:    "postgres",
+			Name:    "msvc_safe_city_db",
+			SSLMode: "disable",
+			Pool: Pool{
+				MaxOpenConns:    25,
+				MaxIdleConns:    10,
+				ConnMaxLifetime: 30 * time.Minute,
+				ConnMaxIdleTime: 5 * time.Minute,
+			},
+			Retry: Retry{
+				MaxAttempts:    10,
+				InitialBackoff: 500 * time.Millisecond,
+				MaxBackoff:     30 * time.Second,
+			},
+			PingInterval:       10 * time.Second,
+			SlowQueryThreshold: 200 * time.Millisecond,
+		},
+		Tracing: Tracing{
+			Exporter:    "none",
+			ServiceName: "safe_msvc_city",
+			SampleRatio: 1,
+		},
+		Logging: Logging{
+			Level:  "info",
+			Format: "json",
+		},
+		Cache: Cache{
+			Backend:    "memory",
+			TTL:        5 * time.Minute,
+			MaxEntries: 10000,
+			Listen:     true,
+			Redis: Redis{
+				Addr:   "localhost:6379",
+				Prefix: "safe_city:",
+			},
+		},
+		Snapshot: Snapshot{
+			Interval: time.Minute,
+		},
+		Webhooks: Webhooks{
+			Interval:       2 * time.Second,
+			Timeout:        10 * time.Second,
+			BatchSize:      20,
+			MaxAttempts:    8,
+			InitialBackoff: 30 * time.Second,
+			MaxBackoff:     time.Hour,
+		},
+		Sync: Sync{
+			TombstoneRetention: 90 * 24 * time.Hour,
+			Overlap:            5 * time.Second,
+		},
+		Changes: Changes{
+			BufferSize:   1000,
+			PollInterval: time.Second,
+			Heartbeat:    15 * time.Second,
+		},
+		Events: Events{
+			Broker:        "memory",
+			RelayInterval: time.Second,
+			BatchSize:     100,
+			Retention:     7 * 24 * time.Hour,
+			NATS: NATS{
+				URL:           "nats://localhost:4222",
+				SubjectPrefix: "safe_city",
+				Stream:        "SAFE_CITY_EVENTS",
+			},
+			Kafka: Kafka{
+				Brokers: []string{"localhost:9092"},
+				Topic:   "safe_city.events",
+			},
+		},
+	}
+	switch profile {
+	case ProfileDev:
+		cfg.Logging.Level = "debug"
+	case ProfileTest:
+		cfg.Database.Name = "msvc_safe_city_test_db"
+		cfg.Logging.Level = "warn"
+		cfg.Database.Retry.MaxAttempts = 1
+	case ProfileProd:
+		cfg.Database.SSLMode = "require"
+		cfg.Server.DrainDelay = 5 * time.Second
+	}
+	return cfg
+}
+
+// Validate revisa la configuración y devuelve todos los errores encontrados
+func (c Config) Validate() error {
+	var errs []error
+	switch c.Profile {
+	case ProfileDev, ProfileTest, ProfileProd:
+	default:
+		errs = append(errs, fmt.Errorf("profile: %q no es válido, use dev, test o prod", c.Profile))
+	}
+	if strings.TrimSpace(c.Server.Addr) == "" {
+		errs = append(errs, errors.New("server.addr (SERVER_ADDR) es obligatorio"))
+	}
+	if c.Server.ShutdownTimeout <= 0 {
+		errs = append(errs, errors.New("server.shutdown_timeout (SHUTDOWN_TIMEOUT) debe ser positivo"))
+	}
+	if c.Server.DrainDelay < 0 {
+		errs = append(errs, errors.New("server.drain_delay (SHUTDOWN_DRAIN_DELAY) no puede ser negativo"))
+	}
+	if strings.TrimSpace(c.Database.Host) == "" {
+		errs = append(errs, errors.New("database.host (DB_HOST) es obligatorio"))
+	}
+	if c.Database.Port <= 0 || c.Database.Port > 65535 {
+		errs = append(errs, fmt.Errorf("database.port (DB_PORT): %d está fuera de rango", c.Database.Port))
+	}
+	if strings.TrimSpace(c.Database.User) == "" {
+		errs = append(errs, errors.New("database.user (DB_USER) es obligatorio"))
+	}
+	if strings.TrimSpace(c.Database.Name) == "" {
+		errs = append(errs, errors.New("database.name (DB_NAME) es obligatorio"))
+	}
+	switch c.Database.SSLMode {
+	case "disable", "allow", "prefer", "require", "verify-ca", "verify-full":
+	default:
+		errs = append(errs, fmt.Errorf("database.sslmode (DB_SSLMODE): %q no es válido", c.Database.SSLMode))
+	}
+	if c.Database.Pool.MaxOpenConns < 0 || c.Database.Pool.MaxIdleConns < 0 {
+		errs = append(errs, errors.New("database.pool: max_open_conns y max_idle_conns no pueden ser negativos"))
+	}
+	if c.Database.Pool.MaxOpenConns > 0 && c.Database.Pool.MaxIdleConns > c.Database.Pool.MaxOpenConns {
+		errs = append(errs, fmt.Errorf("database.pool.max_idle_conns (%d) no puede superar max_open_conns (%d)",
+			c.Database.Pool.MaxIdleConns, c.Database.Pool.MaxOpenConns))
+	}
+	if c.Database.Retry.MaxAttempts < 1 {
+		errs = append(errs, errors.New("database.retry.max_attempts (DB_CONNECT_RETRIES) debe ser al menos 1"))
+	}
+	if c.Database.Retry.InitialBackoff <= 0 || c.Database.Retry.MaxBackoff < c.Database.Retry.InitialBackoff {
+		errs = append(errs, errors.New("database.retry: initial_backoff debe ser positivo y menor o igual que max_backoff"))
+	}
+	if c.Database.SlowQueryThreshold < 0 {
+		errs = append(errs, errors.New("database.slow_query_threshold (DB_SLOW_QUERY_THRESHOLD) no puede ser negativo"))
+	}
+	if c.Database.PingInterval <= 0 {
+		errs = append(errs, errors.New("database.ping_interval (DB_PING_INTERVAL) debe ser positivo"))
+	}
+	if c.Snapshot.Interval <= 0 {
+		errs = append(errs, errors.New("snapshot.interval (SNAPSHOT_INTERVAL) debe ser positivo"))
+	}
+	switch c.Events.Broker {
+	case "none", "memory":
+	case "nats":
+		if strings.TrimSpace(c.Events.NATS.URL) == "" || strings.TrimSpace(c.Events.NATS.Stream) == "" {
+			errs = append(errs, errors.New("events.nats.url (NATS_URL) y events.nats.stream (NATS_STREAM) son obligatorios con el broker nats"))
+		}
+	case "kafka":
+		if len(c.Events.Kafka.Brokers) == 0 || strings.TrimSpace(c.Events.Kafka.Topic) == "" {
+			errs = append(errs, errors.New("events.kafka.brokers (KAFKA_BROKERS) y events.kafka.topic (KAFKA_TOPIC) son obligatorios con el broker kafka"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("events.broker (EVENTS_BROKER): %q no es válido, use memory, nats, kafka o none", c.Events.Broker))
+	}
+	if c.Events.RelayInterval <= 0 || c.Events.BatchSize <= 0 {
+		errs = append(errs, errors.New("events.relay_interval (EVENTS_RELAY_INTERVAL) y events.batch_size (EVENTS_BATCH_SIZE) deben ser positivos"))
+	}
+	if c.Webhooks.Interval <= 0 || c.Webhooks.Timeout <= 0 || c.Webhooks.BatchSize <= 0 || c.Webhooks.MaxAttempts <= 0 {
+		errs = append(errs, errors.New("webhooks.interval, webhooks.timeout, webhooks.batch_size y webhooks.max_attempts (WEBHOOK_*) deben ser positivos"))
+	}
+	if c.Webhooks.InitialBackoff <= 0 || c.Webhooks.MaxBackoff < c.Webhooks.InitialBackoff {
+		errs = append(errs, errors.New("webhooks.initial_backoff (WEBHOOK_INITIAL_BACKOFF) debe ser positivo y no mayor que webhooks.max_backoff (WEBHOOK_MAX_BACKOFF)"))
+	}
+	if c.Changes.BufferSize <= 0 || c.Changes.PollInterval <= 0 || c.Changes.Heartbeat <= 0 {
+		errs = append(errs, errors.New("changes.buffer_size, changes.poll_interval y changes.heartbeat (CHANGES_*) deben ser positivos"))
+	}
+	if c.Sync.TombstoneRetention <= 0 || c.Sync.Overlap < 0 {
+		errs = append(errs, errors.New("sync.tombstone_retention (SYNC_TOMBSTONE_RETENTION) debe ser positivo y sync.overlap (SYNC_OVERLAP) no puede ser negativo"))
+	}
+	switch c.Tracing.Exporter {
+	case "none", "stdout", "otlp":
+	default:
+		errs = append(errs, fmt.Errorf("tracing.exporter (TRACING_EXPORTER): %q no es válido, use otlp, stdout o none", c.Tracing.Exporter))
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		errs = append(errs, errors.New("tracing.sample_ratio (TRACING_SAMPLE_RATIO) debe estar entre 0 y 1"))
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.Logging.Level)); err != nil {
+		errs = append(errs, fmt.Errorf("logging.level (LOG_LEVEL): %q no es válido, use debug, info, warn o error", c.Logging.Level))
+	}
+	switch c.Logging.Format {
+	case "json", "text":
+	default:
+		errs = append(errs, fmt.Errorf("logging.format (LOG_FORMAT): %q no es válido, use json o text", c.Logging.Format))
+	}
+	switch c.Cache.Backend {
+	case "none", "memory":
+	case "redis":
+		if strings.TrimSpace(c.Cache.Redis.Addr) == "" {
+			errs = append(errs, errors.New("cache.redis.addr (REDIS_ADDR) es obligatorio con el backend redis"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("cache.backend (CACHE_BACKEND): %q no es válido, use memory, redis o none", c.Cache.Backend))
+	}
+	if c.Cache.Backend != "none" && c.Cache.TTL <= 0 {
+		errs = append(errs, errors.New("cache.ttl (CACHE_TTL) debe ser positivo"))
+	}
+	if c.Cache.MaxEntries < 0 {
+		errs = append(errs, errors.New("cache.max_entries (CACHE_MAX_ENTRIES) no puede ser negativo"))
+	}
+	if c.Profile == ProfileProd {
+		if c.Database.Password == "" {
+			errs = append(errs, errors.New("database.password (DB_PASSWORD o DB_PASSWORD_FILE) es obligatorio en prod"))
+		}
+		if c.Database.SSLMode == "disable" {
+			errs = append(errs, errors.New("database.sslmode (DB_SSLMODE) no puede ser disable en prod"))
+		}
+		if c.Auth.JWTSecret == "" {
+			errs = append(errs, errors.New("auth.jwt_secret (JWT_SECRET o JWT_SECRET_FILE) es obligatorio en prod"))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("configuración inválida:\n%w", errors.Join(errs...))
+	}
+	return nil
+}
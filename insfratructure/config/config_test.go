@@ -0,0 +1,134 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(*Config)
+		want   []string
+	}{
+		{"dev por defecto", func(c *Config) {}, nil},
+		{"perfil inválido", func(c *Config) { c.Profile = "staging" }, []string{"profile"}},
+		{"puerto fuera de rango", func(c *Config) { c.Database.Port = 70000 }, []string{"database.port"}},
+		{"idle mayor que open", func(c *Config) { c.Database.Pool.MaxIdleConns = 50 }, []string{"max_idle_conns"}},
+		{"sslmode inválido", func(c *Config) { c.Database.SSLMode = "on" }, []string{"database.sslmode"}},
+		{"redis sin dirección", func(c *Config) { c.Cache.Backend = "redis"; c.Cache.Redis.Addr = "" }, []string{"cache.redis.addr"}},
+		{"nats sin url", func(c *Config) { c.Events.Broker = "nats"; c.Events.NATS.URL = "" }, []string{"events.nats.url"}},
+		{"muestreo mayor que 1", func(c *Config) { c.Tracing.SampleRatio = 2 }, []string{"tracing.sample_ratio"}},
+		{"nivel de log inválido", func(c *Config) { c.Logging.Level = "verbose" }, []string{"logging.level"}},
+		{"prod sin secretos", func(c *Config) {
+			c.Profile = ProfileProd
+			c.Database.Password = ""
+			c.Database.SSLMode = "disable"
+			c.Auth.JWTSecret = ""
+		}, []string{"database.password", "database.sslmode", "auth.jwt_secret"}},
+		{"prod completo", func(c *Config) {
+			c.Profile = ProfileProd
+			c.Database.Password = "clave"
+			c.Database.SSLMode = "require"
+			c.Auth.JWTSecret = "secreto"
+		}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Default(ProfileDev)
+			tt.mutate(&cfg)
+			err := cfg.Validate()
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Fatalf("error inesperado: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("se esperaba un error con %v", tt.want)
+			}
+			for _, field := range tt.want {
+				if !strings.Contains(err.Error(), field) {
+					t.Errorf("el error no menciona %s: %v", field, err)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadProfile(t *testing.T) {
+	tests := []struct {
+		name    string
+		flag    string
+		env     string
+		file    string
+		goRun   bool
+		want    string
+		wantErr bool
+	}{
+		{"flag sobre entorno y archivo", ProfileTest, ProfileProd, ProfileProd, false, ProfileTest, false},
+		{"entorno sobre archivo", "", ProfileTest, ProfileProd, false, ProfileTest, false},
+		{"solo archivo", "", "", ProfileTest, false, ProfileTest, false},
+		{"sin perfil con go run", "", "", "", true, ProfileDev, false},
+		{"sin perfil en binario", "", "", "", false, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			chdir(t, dir)
+			t.Setenv("APP_PROFILE", tt.env)
+			t.Setenv("CONFIG_FILE", "")
+			previous := goRun
+			goRun = func() bool { return tt.goRun }
+			t.Cleanup(func() { goRun = previous })
+
+			flags := flag.NewFlagSet("test", flag.ContinueOnError)
+			loader := Bind(flags)
+			args := []string{}
+			if tt.flag != "" {
+				args = append(args, "-profile", tt.flag)
+			}
+			if tt.file != "" {
+				path := filepath.Join(dir, "config.yaml")
+				if err := os.WriteFile(path, []byte("profile: "+tt.file+"\n"), 0o600); err != nil {
+					t.Fatal(err)
+				}
+				args = append(args, "-config", path)
+			}
+			if err := flags.Parse(args); err != nil {
+				t.Fatal(err)
+			}
+
+			cfg, err := loader.Load()
+			if tt.wantErr {
+				if err == nil || err.Error() != profileMissing {
+					t.Fatalf("error = %v, se esperaba %q", err, profileMissing)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error inesperado: %v", err)
+			}
+			if cfg.Profile != tt.want {
+				t.Errorf("perfil = %q, se esperaba %q", cfg.Profile, tt.want)
+			}
+		})
+	}
+}
+
+// chdir cambia el directorio de trabajo durante la prueba para que no se cargue un
+// .env_dev del repositorio
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+	previous, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(previous) })
+}
@@ -0,0 +1,326 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// devEnvFile es el archivo de variables que se carga automáticamente en el perfil dev
+const devEnvFile = ".env_dev"
+
+// profileMissing se devuelve cuando un binario compilado arranca sin perfil, para que un
+// despliegue que olvida APP_PROFILE no corra con la configuración de dev
+const profileMissing = "falta el perfil: use -profile, APP_PROFILE o profile en el archivo de configuración"
+
+// goRun indica si el proceso es un binario temporal de go run o go test, los únicos
+// casos en que se asume el perfil dev
+var goRun = func() bool {
+	executable, err := os.Executable()
+	return err == nil && strings.Contains(filepath.ToSlash(executable), "/go-build")
+}
+
+// Loader carga la configuración en orden: valores por defecto del perfil, archivo
+// YAML/TOML, variables de entorno y flags. El perfil se toma de -profile,
+// APP_PROFILE o del propio archivo, en ese orden; solo con go run se asume dev
+type Loader struct {
+	flags    *flag.FlagSet
+	file     *string
+	profile  *string
+	envFile  *string
+	addr     *string
+	dbHost   *string
+	dbPort   *int
+	dbName   *string
+	dbUser   *string
+	sslMode  *string
+	setFlags map[string]bool
+}
+
+// Bind registra en flags las opciones de configuración compartidas por todos los comandos
+func Bind(flags *flag.FlagSet) *Loader {
+	return &Loader{
+		flags:   flags,
+		file:    flags.String("config", "", "archivo de configuración YAML o TOML (CONFIG_FILE)"),
+		profile: flags.String("profile", "", "perfil dev, test o prod (APP_PROFILE)"),
+		envFile: flags.String("env", "", "archivo de variables de entorno a cargar (en dev: "+devEnvFile+")"),
+		addr:    flags.String("addr", "", "dirección en la que escucha el servidor (SERVER_ADDR)"),
+		dbHost:  flags.String("db-host", "", "host de Postgres (DB_HOST)"),
+		dbPort:  flags.Int("db-port", 0, "puerto de Postgres (DB_PORT)"),
+		dbName:  flags.String("db-name", "", "base de datos (DB_NAME)"),
+		dbUser:  flags.String("db-user", "", "usuario de Postgres (DB_USER)"),
+		sslMode: flags.String("db-sslmode", "", "sslmode de Postgres (DB_SSLMODE)"),
+	}
+}
+
+// Load construye y valida la configuración. Debe llamarse después de flags.Parse
+func (l *Loader) Load() (*Config, error) {
+	l.setFlags = map[string]bool{}
+	l.flags.Visit(func(f *flag.Flag) { l.setFlags[f.Name] = true })
+
+	file := firstNonEmpty(*l.file, os.Getenv("CONFIG_FILE"))
+	var fromFile Config
+	if file != "" {
+		if err := loadFile(file, &fromFile); err != nil {
+			return nil, err
+		}
+	}
+	profile := firstNonEmpty(*l.profile, os.Getenv("APP_PROFILE"), fromFile.Profile)
+	if profile == "" {
+		if !goRun() {
+			return nil, errors.New(profileMissing)
+		}
+		profile = ProfileDev
+	}
+
+	cfg := Default(profile)
+	if file != "" {
+		if err := loadFile(file, &cfg); err != nil {
+			return nil, err
+		}
+		cfg.Profile = profile
+	}
+	if err := loadEnvFile(*l.envFile, profile); err != nil {
+		return nil, err
+	}
+	if err := applyEnv(&cfg); err != nil {
+		return nil, err
+	}
+	l.applyFlags(&cfg)
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// loadEnvFile carga el archivo de variables indicado; en dev usa .env_dev si existe.
+// Las variables ya definidas en el entorno tienen prioridad sobre las del archivo
+func loadEnvFile(fileName string, profile string) error {
+	if fileName == "" {
+		if profile != ProfileDev {
+			return nil
+		}
+		if _, err := os.Stat(devEnvFile); err != nil {
+			return nil
+		}
+		fileName = devEnvFile
+	}
+	if err := godotenv.Load(fileName); err != nil {
+		return fmt.Errorf("no se pudo cargar el archivo %s: %w", fileName, err)
+	}
+	return nil
+}
+
+// loadFile lee un archivo YAML o TOML según su extensión sobre cfg
+func loadFile(fileName string, cfg *Config) error {
+	content, err := os.ReadFile(fileName)
+	if err != nil {
+		return fmt.Errorf("no se pudo leer el archivo de configuración: %w", err)
+	}
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, cfg)
+	case ".toml":
+		_, err = toml.Decode(string(content), cfg)
+	default:
+		return fmt.Errorf("formato de configuración no soportado: %s (use .yaml, .yml o .toml)", fileName)
+	}
+	if err != nil {
+		return fmt.Errorf("archivo de configuración %s inválido: %w", fileName, err)
+	}
+	return nil
+}
+
+// applyEnv sobrescribe cfg con las variables de entorno definidas
+func applyEnv(cfg *Config) error {
+	var err error
+	setString := func(key string, target *string) {
+		if err != nil {
+			return
+		}
+		var value string
+		var ok bool
+		if value, ok, err = lookupEnv(key); ok {
+			*target = value
+		}
+	}
+	setString("SERVER_ADDR", &cfg.Server.Addr)
+	setString("DB_HOST", &cfg.Database.Host)
+	setString("DB_USER", &cfg.Database.User)
+	setString("DB_PASSWORD", &cfg.Database.Password)
+	setString("DB_NAME", &cfg.Database.Name)
+	setString("DB_SSLMODE", &cfg.Database.SSLMode)
+	setString("JWT_SECRET", &cfg.Auth.JWTSecret)
+	setString("LOG_LEVEL", &cfg.Logging.Level)
+	setString("LOG_FORMAT", &cfg.Logging.Format)
+	setString("CACHE_BACKEND", &cfg.Cache.Backend)
+	setString("REDIS_ADDR", &cfg.Cache.Redis.Addr)
+	setString("REDIS_PASSWORD", &cfg.Cache.Redis.Password)
+	setString("CACHE_REDIS_PREFIX", &cfg.Cache.Redis.Prefix)
+	setString("SNAPSHOT_PATH", &cfg.Snapshot.Path)
+	setString("EVENTS_BROKER", &cfg.Events.Broker)
+	setString("NATS_URL", &cfg.Events.NATS.URL)
+	setString("NATS_SUBJECT_PREFIX", &cfg.Events.NATS.SubjectPrefix)
+	setString("NATS_STREAM", &cfg.Events.NATS.Stream)
+	setString("KAFKA_TOPIC", &cfg.Events.Kafka.Topic)
+	setString("TRACING_EXPORTER", &cfg.Tracing.Exporter)
+	setString("TRACING_OTLP_ENDPOINT", &cfg.Tracing.Endpoint)
+	setString("OTEL_SERVICE_NAME", &cfg.Tracing.ServiceName)
+	if err != nil {
+		return err
+	}
+
+	if port, ok := os.LookupEnv("PORT"); ok && os.Getenv("SERVER_ADDR") == "" {
+		cfg.Server.Addr = ":" + port
+	}
+	if value, ok, err := lookupEnv("DB_PORT"); err != nil {
+		return err
+	} else if ok {
+		port, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("DB_PORT: %q no es un número", value)
+		}
+		cfg.Database.Port = port
+	}
+
+	ints := []struct {
+		key    string
+		target *int
+	}{
+		{"DB_MAX_OPEN_CONNS", &cfg.Database.Pool.MaxOpenConns},
+		{"DB_MAX_IDLE_CONNS", &cfg.Database.Pool.MaxIdleConns},
+		{"DB_CONNECT_RETRIES", &cfg.Database.Retry.MaxAttempts},
+		{"CACHE_MAX_ENTRIES", &cfg.Cache.MaxEntries},
+		{"REDIS_DB", &cfg.Cache.Redis.DB},
+		{"EVENTS_BATCH_SIZE", &cfg.Events.BatchSize},
+		{"WEBHOOK_BATCH_SIZE", &cfg.Webhooks.BatchSize},
+		{"CHANGES_BUFFER_SIZE", &cfg.Changes.BufferSize},
+		{"WEBHOOK_MAX_ATTEMPTS", &cfg.Webhooks.MaxAttempts},
+	}
+	for _, item := range ints {
+		if value, ok := os.LookupEnv(item.key); ok {
+			number, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("%s: %q no es un número", item.key, value)
+			}
+			*item.target = number
+		}
+	}
+	if value, ok := os.LookupEnv("TRACING_SAMPLE_RATIO"); ok {
+		ratio, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("TRACING_SAMPLE_RATIO: %q no es un número", value)
+		}
+		cfg.Tracing.SampleRatio = ratio
+	}
+	if value, ok := os.LookupEnv("TRACING_OTLP_INSECURE"); ok {
+		insecure, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("TRACING_OTLP_INSECURE: %q no es un booleano", value)
+		}
+		cfg.Tracing.Insecure = insecure
+	}
+	if value, ok := os.LookupEnv("KAFKA_BROKERS"); ok {
+		cfg.Events.Kafka.Brokers = strings.Split(value, ",")
+	}
+	if value, ok := os.LookupEnv("CACHE_LISTEN"); ok {
+		listen, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("CACHE_LISTEN: %q no es un booleano", value)
+		}
+		cfg.Cache.Listen = listen
+	}
+	durations := []struct {
+		key    string
+		target *time.Duration
+	}{
+		{"SHUTDOWN_TIMEOUT", &cfg.Server.ShutdownTimeout},
+		{"SHUTDOWN_DRAIN_DELAY", &cfg.Server.DrainDelay},
+		{"DB_CONN_MAX_LIFETIME", &cfg.Database.Pool.ConnMaxLifetime},
+		{"DB_CONN_MAX_IDLE_TIME", &cfg.Database.Pool.ConnMaxIdleTime},
+		{"DB_CONNECT_BACKOFF", &cfg.Database.Retry.InitialBackoff},
+		{"DB_CONNECT_MAX_BACKOFF", &cfg.Database.Retry.MaxBackoff},
+		{"DB_PING_INTERVAL", &cfg.Database.PingInterval},
+		{"DB_SLOW_QUERY_THRESHOLD", &cfg.Database.SlowQueryThreshold},
+		{"CACHE_TTL", &cfg.Cache.TTL},
+		{"SNAPSHOT_INTERVAL", &cfg.Snapshot.Interval},
+		{"EVENTS_RELAY_INTERVAL", &cfg.Events.RelayInterval},
+		{"EVENTS_RETENTION", &cfg.Events.Retention},
+		{"WEBHOOK_INTERVAL", &cfg.Webhooks.Interval},
+		{"CHANGES_POLL_INTERVAL", &cfg.Changes.PollInterval},
+		{"SYNC_TOMBSTONE_RETENTION", &cfg.Sync.TombstoneRetention},
+		{"SYNC_OVERLAP", &cfg.Sync.Overlap},
+		{"CHANGES_HEARTBEAT", &cfg.Changes.Heartbeat},
+		{"WEBHOOK_TIMEOUT", &cfg.Webhooks.Timeout},
+		{"WEBHOOK_INITIAL_BACKOFF", &cfg.Webhooks.InitialBackoff},
+		{"WEBHOOK_MAX_BACKOFF", &cfg.Webhooks.MaxBackoff},
+	}
+	for _, item := range durations {
+		if value, ok := os.LookupEnv(item.key); ok {
+			duration, err := time.ParseDuration(value)
+			if err != nil {
+				return fmt.Errorf("%s: %q no es una duración válida (ej. 5s, 1m)", item.key, value)
+			}
+			*item.target = duration
+		}
+	}
+	return nil
+}
+
+// lookupEnv lee key del entorno o, si existe key_FILE, del archivo indicado (secretos de Docker/K8s)
+func lookupEnv(key string) (string, bool, error) {
+	value, ok := os.LookupEnv(key)
+	fileName, fileOk := os.LookupEnv(key + "_FILE")
+	if !fileOk {
+		return value, ok, nil
+	}
+	if ok {
+		return "", false, fmt.Errorf("%s y %s_FILE no pueden definirse a la vez", key, key)
+	}
+	content, err := os.ReadFile(fileName)
+	if err != nil {
+		return "", false, fmt.Errorf("%s_FILE: %w", key, err)
+	}
+	return strings.TrimRight(string(content), "\r\n"), true, nil
+}
+
+// applyFlags sobrescribe cfg con los flags indicados explícitamente
+func (l *Loader) applyFlags(cfg *Config) {
+	if l.setFlags["addr"] {
+		cfg.Server.Addr = *l.addr
+	}
+	if l.setFlags["db-host"] {
+		cfg.Database.Host = *l.dbHost
+	}
+	if l.setFlags["db-port"] {
+		cfg.Database.Port = *l.dbPort
+	}
+	if l.setFlags["db-name"] {
+		cfg.Database.Name = *l.dbName
+	}
+	if l.setFlags["db-user"] {
+		cfg.Database.User = *l.dbUser
+	}
+	if l.setFlags["db-sslmode"] {
+		cfg.Database.SSLMode = *l.sslMode
+	}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -1,13 +1,17 @@
 package database
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 	"sync"
+	"time"
 
-	"github.com/joho/godotenv"
-	"github.com/safe_msvc_city/insfratructure/entities"
+	"github.com/safe_msvc_city/insfratructure/config"
+	"github.com/safe_msvc_city/insfratructure/database/migrations"
+	"github.com/safe_msvc_city/insfratructure/querylog"
+	"github.com/safe_msvc_city/insfratructure/tracing"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -15,68 +19,120 @@ import (
 
 var dbInstance *gorm.DB
 var dbOnce sync.Once
+var dbErr error
+var dbConfig config.Database
 
-// LoadEnv carga las variables de entorno desde el archivo .env
-func LoadEnv() {
-	err := godotenv.Load()
-	if err != nil {
-		log.Println("Error cargando el archivo .env:", err)
-	}
+// Configure define los datos de conexión que usará GetDatabaseInstance
+func Configure(cfg config.Database) {
+	dbConfig = cfg
 }
 
-// GetDatabaseInstance devuelve una instancia única de la conexión a la base de datos
-func GetDatabaseInstance() *gorm.DB {
+// Connect abre la conexión única reintentando con backoff exponencial; las
+// llamadas siguientes devuelven el resultado del primer intento
+func Connect(ctx context.Context) (*gorm.DB, error) {
 	dbOnce.Do(func() {
-		var err error
-		dbInstance, err = DatabaseConnection()
-		if err != nil {
-			log.Fatalf("Error al inicializar la base de datos: %v", err)
-		}
+		dbInstance, dbErr = connectWithRetry(ctx, dbConfig)
 	})
-	return dbInstance
+	return dbInstance, dbErr
 }
 
-// DatabaseConnection establece la conexión a la base de datos y realiza migraciones
-func DatabaseConnection() (*gorm.DB, error) {
-	LoadEnv()
-
-	DB_USER := os.Getenv("DB_USER")
-	DB_PASSWORD := os.Getenv("DB_PASSWORD")
-	DB_HOST := os.Getenv("DB_HOST")
-	DB_NAME := os.Getenv("DB_NAME")
-	DB_PORT := os.Getenv("DB_PORT")
-	DB_SSLMODE := os.Getenv("DB_SSLMODE")
+// GetDatabaseInstance devuelve una instancia única de la conexión a la base de datos
+func GetDatabaseInstance() *gorm.DB {
+	db, err := Connect(context.Background())
+	if err != nil {
+		slog.Error("database initialization failed", "error", err)
+		os.Exit(1)
+	}
+	return db
+}
 
-	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=%s",
-		DB_HOST,
-		DB_USER,
-		DB_PASSWORD,
-		DB_NAME,
-		DB_PORT,
-		DB_SSLMODE,
-	)
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+// DatabaseConnection establece la conexión a la base de datos y configura el pool
+func DatabaseConnection(cfg config.Database) (*gorm.DB, error) {
+	db, err := gorm.Open(postgres.Open(cfg.DSN()), &gorm.Config{
+		Logger: querylog.New(cfg.SlowQueryThreshold, querylog.DefaultStats),
+	})
 	if err != nil {
 		return nil, fmt.Errorf("no se pudo conectar a la base de datos: %w", err)
 	}
-
-	err = db.AutoMigrate(&entities.City{}, &entities.States{})
+	if err := db.Use(tracing.GormPlugin{}); err != nil {
+		return nil, fmt.Errorf("no se pudo registrar el plugin de trazas: %w", err)
+	}
+	dbSQL, err := db.DB()
 	if err != nil {
-		return nil, fmt.Errorf("no se pudo migrar la base de datos: %w", err)
+		return nil, fmt.Errorf("no se pudo obtener la instancia de *sql.DB: %w", err)
 	}
+	dbSQL.SetMaxOpenConns(cfg.Pool.MaxOpenConns)
+	dbSQL.SetMaxIdleConns(cfg.Pool.MaxIdleConns)
+	dbSQL.SetConnMaxLifetime(cfg.Pool.ConnMaxLifetime)
+	dbSQL.SetConnMaxIdleTime(cfg.Pool.ConnMaxIdleTime)
 	return db, nil
 }
 
-// CloseConnection cierra la conexión a la base de datos
+// connectWithRetry intenta conectar hasta cfg.Retry.MaxAttempts veces, duplicando la
+// espera entre intentos hasta cfg.Retry.MaxBackoff
+func connectWithRetry(ctx context.Context, cfg config.Database) (*gorm.DB, error) {
+	backoff := cfg.Retry.InitialBackoff
+	attempts := max(cfg.Retry.MaxAttempts, 1)
+	for attempt := 1; ; attempt++ {
+		db, err := DatabaseConnection(cfg)
+		if err == nil {
+			return db, nil
+		}
+		if attempt >= attempts {
+			return nil, fmt.Errorf("%w (después de %d intentos)", err, attempt)
+		}
+		slog.Warn("database unavailable, retrying",
+			"attempt", attempt,
+			"max_attempts", attempts,
+			"backoff", backoff.String(),
+			"error", err,
+		)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, cfg.Retry.MaxBackoff)
+	}
+}
+
+// GetMigrator devuelve un Migrator sobre la conexión única a la base de datos
+func GetMigrator() (*migrations.Migrator, error) {
+	dbSQL, err := GetDatabaseInstance().DB()
+	if err != nil {
+		return nil, fmt.Errorf("no se pudo obtener la instancia de *sql.DB: %w", err)
+	}
+	return migrations.NewMigrator(dbSQL)
+}
+
+// Migrate aplica las migraciones pendientes
+func Migrate(ctx context.Context) error {
+	migrator, err := GetMigrator()
+	if err != nil {
+		return err
+	}
+	applied, err := migrator.Up(ctx)
+	if err != nil {
+		return fmt.Errorf("no se pudo migrar la base de datos: %w", err)
+	}
+	for _, version := range applied {
+		slog.Info("migration applied", "version", version)
+	}
+	return nil
+}
+
+// CloseConnection cierra la conexión a la base de datos si fue abierta
 func CloseConnection() {
-	db := GetDatabaseInstance()
-	dbSQL, err := db.DB()
+	if dbInstance == nil {
+		return
+	}
+	dbSQL, err := dbInstance.DB()
 	if err != nil {
-		log.Println("Error al obtener la instancia de *sql.DB:", err)
+		slog.Error("could not get *sql.DB", "error", err)
 		return
 	}
 	err = dbSQL.Close()
 	if err != nil {
-		log.Println("Error al cerrar la conexión a la base de datos:", err)
+		slog.Error("could not close the database connection", "error", err)
 	}
 }
@@ -0,0 +1,128 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+// HealthStatus es el último resultado del pinger de la base de datos
+type HealthStatus struct {
+	Healthy   bool          `json:"healthy"`
+	Latency   time.Duration `json:"-"`
+	LatencyMs float64       `json:"latency_ms"`
+	LastCheck time.Time     `json:"last_check"`
+	LastError string        `json:"last_error,omitempty"`
+	// Since es el momento en que cambió Healthy por última vez
+	Since time.Time `json:"since"`
+}
+
+var (
+	healthMux    sync.RWMutex
+	healthStatus HealthStatus
+)
+
+// Health devuelve el último estado conocido de la base de datos
+func Health() HealthStatus {
+	healthMux.RLock()
+	defer healthMux.RUnlock()
+	return healthStatus
+}
+
+// Ping verifica la conexión una vez y actualiza el estado de salud
+func Ping(ctx context.Context, db *gorm.DB, timeout time.Duration) HealthStatus {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	dbSQL, err := db.DB()
+	if err == nil {
+		err = dbSQL.PingContext(ctx)
+	}
+	return recordHealth(time.Since(start), err)
+}
+
+// StartMonitor hace ping a la base de datos cada interval hasta que ctx termine.
+// Si la base de datos deja de responder el servicio se marca como no saludable en
+// lugar de terminar; database/sql reabre las conexiones cuando vuelve a estar disponible
+func StartMonitor(ctx context.Context, db *gorm.DB, interval time.Duration) {
+	Ping(ctx, db, interval)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				Ping(ctx, db, interval)
+			}
+		}
+	}()
+}
+
+// Unavailable indica si err se debe a que la base de datos no responde, ya sea por
+// el tipo de error o porque el último ping falló. El resultado del ping solo cuenta si
+// ya hubo uno: los comandos de consola no arrancan el monitor y sin él Healthy
+// siempre es false. Un registro inexistente nunca es una caída
+func Unavailable(err error) bool {
+	if err == nil || errors.Is(err, gorm.ErrRecordNotFound) {
+		return false
+	}
+	var netErr net.Error
+	var connectErr *pgconn.ConnectError
+	if errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.As(err, &netErr) ||
+		errors.As(err, &connectErr) {
+		return true
+	}
+	status := Health()
+	return !status.LastCheck.IsZero() && !status.Healthy
+}
+
+// Stats devuelve las estadísticas del pool de conexiones; false si no hay conexión
+func Stats() (sql.DBStats, bool) {
+	if dbInstance == nil {
+		return sql.DBStats{}, false
+	}
+	dbSQL, err := dbInstance.DB()
+	if err != nil {
+		return sql.DBStats{}, false
+	}
+	return dbSQL.Stats(), true
+}
+
+func recordHealth(latency time.Duration, err error) HealthStatus {
+	healthMux.Lock()
+	defer healthMux.Unlock()
+
+	now := time.Now()
+	healthy := err == nil
+	if healthy != healthStatus.Healthy || healthStatus.Since.IsZero() {
+		if healthy {
+			slog.Info("database available", "latency_ms", float64(latency.Microseconds())/1000)
+		} else {
+			slog.Error("database unavailable", "error", err)
+		}
+		healthStatus.Since = now
+	}
+	healthStatus.Healthy = healthy
+	healthStatus.Latency = latency
+	healthStatus.LatencyMs = float64(latency.Microseconds()) / 1000
+	healthStatus.LastCheck = now
+	healthStatus.LastError = ""
+	if err != nil {
+		healthStatus.LastError = err.Error()
+	}
+	return healthStatus
+}
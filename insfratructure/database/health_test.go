@@ -0,0 +1,44 @@
+package database
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func TestUnavailable(t *testing.T) {
+	failed := errors.New("duplicate key value violates unique constraint")
+	tests := []struct {
+		name   string
+		status HealthStatus
+		err    error
+		want   bool
+	}{
+		{"sin error", HealthStatus{}, nil, false},
+		{"conexión rota", HealthStatus{}, fmt.Errorf("query: %w", driver.ErrBadConn), true},
+		{"sin ping no cuenta el estado", HealthStatus{}, failed, false},
+		{"ping saludable", HealthStatus{Healthy: true, LastCheck: time.Now()}, failed, false},
+		{"ping fallido", HealthStatus{LastCheck: time.Now()}, failed, true},
+		{"no encontrado con ping fallido", HealthStatus{LastCheck: time.Now()}, gorm.ErrRecordNotFound, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			healthMux.Lock()
+			previous := healthStatus
+			healthStatus = tt.status
+			healthMux.Unlock()
+			t.Cleanup(func() {
+				healthMux.Lock()
+				healthStatus = previous
+				healthMux.Unlock()
+			})
+			if got := Unavailable(tt.err); got != tt.want {
+				t.Errorf("Unavailable(%v) = %v, se esperaba %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
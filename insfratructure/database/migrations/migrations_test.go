@@ -0,0 +1,105 @@
+package migrations
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestParseFileName(t *testing.T) {
+	tests := []struct {
+		file      string
+		version   int64
+		name      string
+		direction string
+		wantErr   bool
+	}{
+		{"0001_create_cities.up.sql", 1, "create_cities", "up", false},
+		{"0010_merge_redirects.down.sql", 10, "merge_redirects", "down", false},
+		{"0002_a_b_c.up.sql", 2, "a_b_c", "up", false},
+		{"0001_create_cities.sql", 0, "", "", true},
+		{"0001.up.sql", 0, "", "", true},
+		{"0001_.up.sql", 0, "", "", true},
+		{"abcd_create.up.sql", 0, "", "", true},
+		{"0000_zero.up.sql", 0, "", "", true},
+		{"-1_negative.up.sql", 0, "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			version, name, direction, err := parseFileName(tt.file)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("se esperaba un error para %s", tt.file)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error inesperado: %v", err)
+			}
+			if version != tt.version || name != tt.name || direction != tt.direction {
+				t.Errorf("= %d %s %s, se esperaba %d %s %s", version, name, direction, tt.version, tt.name, tt.direction)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	file := func(content string) *fstest.MapFile { return &fstest.MapFile{Data: []byte(content)} }
+	tests := []struct {
+		name     string
+		fsys     fstest.MapFS
+		versions []int64
+		wantErr  bool
+	}{
+		{"ordena por versión", fstest.MapFS{
+			"sql/0002_b.up.sql":   file("up b"),
+			"sql/0002_b.down.sql": file("down b"),
+			"sql/0001_a.up.sql":   file("up a"),
+			"sql/0001_a.down.sql": file("down a"),
+		}, []int64{1, 2}, false},
+		{"falta down", fstest.MapFS{
+			"sql/0001_a.up.sql": file("up a"),
+		}, nil, true},
+		{"nombres distintos", fstest.MapFS{
+			"sql/0001_a.up.sql":   file("up a"),
+			"sql/0001_b.down.sql": file("down b"),
+		}, nil, true},
+		{"archivo inválido", fstest.MapFS{
+			"sql/README.md": file("x"),
+		}, nil, true},
+		{"sin directorio", fstest.MapFS{}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrations, err := Load(tt.fsys)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("se esperaba un error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error inesperado: %v", err)
+			}
+			if len(migrations) != len(tt.versions) {
+				t.Fatalf("%d migraciones, se esperaban %d", len(migrations), len(tt.versions))
+			}
+			for i, m := range migrations {
+				if m.Version != tt.versions[i] || m.Up == "" || m.Down == "" {
+					t.Errorf("migración %d = %+v", i, m)
+				}
+			}
+		})
+	}
+}
+
+func TestEmbedded(t *testing.T) {
+	migrations, err := Load(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, m := range migrations {
+		if m.Version != int64(i+1) {
+			t.Errorf("la migración %s tiene versión %d, se esperaba %d sin huecos", m.Name, m.Version, i+1)
+		}
+	}
+}
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS cities (
+    id         BIGSERIAL PRIMARY KEY,
+    name       VARCHAR(100) NOT NULL,
+    active     BOOLEAN,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMP(6)
+);
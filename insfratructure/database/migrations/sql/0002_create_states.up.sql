@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS states (
+    id         BIGSERIAL PRIMARY KEY,
+    name       VARCHAR(100) NOT NULL,
+    zip_code   VARCHAR(100),
+    city_id    BIGINT,
+    active     BOOLEAN,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMP(6),
+    CONSTRAINT fk_states_city FOREIGN KEY (city_id)
+        REFERENCES cities (id) ON UPDATE CASCADE ON DELETE CASCADE
+);
@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS states_notify_change ON states;
+DROP TRIGGER IF EXISTS cities_notify_change ON cities;
+DROP FUNCTION IF EXISTS notify_catalogue_change();
@@ -0,0 +1,31 @@
+CREATE OR REPLACE FUNCTION notify_catalogue_change() RETURNS trigger AS $$
+DECLARE
+    payload JSON;
+BEGIN
+    IF TG_TABLE_NAME = 'cities' THEN
+        payload := json_build_object(
+            'entity', 'city',
+            'operation', lower(TG_OP),
+            'id', COALESCE(NEW.id, OLD.id)
+        );
+    ELSE
+        payload := json_build_object(
+            'entity', 'state',
+            'operation', lower(TG_OP),
+            'id', COALESCE(NEW.id, OLD.id),
+            'city_id', CASE WHEN TG_OP = 'DELETE' THEN OLD.city_id ELSE NEW.city_id END,
+            'old_city_id', CASE WHEN TG_OP = 'INSERT' THEN NULL ELSE OLD.city_id END
+        );
+    END IF;
+    PERFORM pg_notify('catalogue_changes', payload::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER cities_notify_change
+    AFTER INSERT OR UPDATE OR DELETE ON cities
+    FOR EACH ROW EXECUTE FUNCTION notify_catalogue_change();
+
+CREATE TRIGGER states_notify_change
+    AFTER INSERT OR UPDATE OR DELETE ON states
+    FOR EACH ROW EXECUTE FUNCTION notify_catalogue_change();
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS outbox (
+    id           BIGSERIAL PRIMARY KEY,
+    event_id     UUID NOT NULL UNIQUE,
+    type         VARCHAR(100) NOT NULL,
+    entity       VARCHAR(20) NOT NULL,
+    entity_id    BIGINT NOT NULL,
+    city_id      BIGINT NOT NULL,
+    payload      JSONB NOT NULL,
+    occurred_at  TIMESTAMPTZ NOT NULL,
+    published_at TIMESTAMPTZ,
+    attempts     INTEGER NOT NULL DEFAULT 0,
+    last_error   TEXT
+);
+
+CREATE INDEX IF NOT EXISTS outbox_pending_idx ON outbox (id) WHERE published_at IS NULL;
@@ -0,0 +1,42 @@
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+    id          BIGSERIAL PRIMARY KEY,
+    url         VARCHAR(2048) NOT NULL,
+    secret      VARCHAR(255) NOT NULL,
+    event_types JSONB NOT NULL DEFAULT '[]',
+    city_ids    JSONB NOT NULL DEFAULT '[]',
+    active      BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at  TIMESTAMPTZ,
+    updated_at  TIMESTAMP(6)
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id               BIGSERIAL PRIMARY KEY,
+    subscription_id  BIGINT NOT NULL,
+    event_id         UUID NOT NULL,
+    event_type       VARCHAR(100) NOT NULL,
+    payload          JSONB NOT NULL,
+    status           VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts         INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at  TIMESTAMPTZ NOT NULL,
+    last_status_code INTEGER,
+    last_error       TEXT,
+    created_at       TIMESTAMPTZ,
+    delivered_at     TIMESTAMPTZ,
+    CONSTRAINT uq_webhook_deliveries_event UNIQUE (subscription_id, event_id),
+    CONSTRAINT fk_webhook_deliveries_subscription FOREIGN KEY (subscription_id)
+        REFERENCES webhook_subscriptions (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS webhook_deliveries_due_idx ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS webhook_deliveries_dead_idx ON webhook_deliveries (id) WHERE status = 'dead';
+
+CREATE TABLE IF NOT EXISTS webhook_attempts (
+    id           BIGSERIAL PRIMARY KEY,
+    delivery_id  BIGINT NOT NULL,
+    status_code  INTEGER,
+    error        TEXT,
+    duration_ms  DOUBLE PRECISION NOT NULL,
+    attempted_at TIMESTAMPTZ NOT NULL,
+    CONSTRAINT fk_webhook_attempts_delivery FOREIGN KEY (delivery_id)
+        REFERENCES webhook_deliveries (id) ON DELETE CASCADE
+);
@@ -0,0 +1,9 @@
+DROP TRIGGER IF EXISTS states_record_deletion ON states;
+DROP TRIGGER IF EXISTS cities_record_deletion ON cities;
+DROP FUNCTION IF EXISTS record_deletion();
+DROP TABLE IF EXISTS deletions;
+DROP INDEX IF EXISTS states_updated_at_idx;
+DROP INDEX IF EXISTS cities_updated_at_idx;
+DROP TRIGGER IF EXISTS states_touch_updated_at ON states;
+DROP TRIGGER IF EXISTS cities_touch_updated_at ON cities;
+DROP FUNCTION IF EXISTS touch_updated_at();
@@ -0,0 +1,52 @@
+UPDATE cities SET updated_at = COALESCE(created_at, now()) WHERE updated_at IS NULL;
+UPDATE states SET updated_at = COALESCE(created_at, now()) WHERE updated_at IS NULL;
+
+CREATE OR REPLACE FUNCTION touch_updated_at() RETURNS trigger AS $$
+BEGIN
+    NEW.updated_at := now();
+    IF TG_OP = 'INSERT' THEN
+        NEW.created_at := COALESCE(NEW.created_at, now());
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER cities_touch_updated_at
+    BEFORE INSERT OR UPDATE ON cities
+    FOR EACH ROW EXECUTE FUNCTION touch_updated_at();
+
+CREATE TRIGGER states_touch_updated_at
+    BEFORE INSERT OR UPDATE ON states
+    FOR EACH ROW EXECUTE FUNCTION touch_updated_at();
+
+CREATE INDEX IF NOT EXISTS cities_updated_at_idx ON cities (updated_at);
+CREATE INDEX IF NOT EXISTS states_updated_at_idx ON states (updated_at);
+
+CREATE TABLE IF NOT EXISTS deletions (
+    id         BIGSERIAL PRIMARY KEY,
+    entity     VARCHAR(20) NOT NULL,
+    entity_id  BIGINT NOT NULL,
+    city_id    BIGINT,
+    deleted_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS deletions_deleted_at_idx ON deletions (deleted_at);
+
+CREATE OR REPLACE FUNCTION record_deletion() RETURNS trigger AS $$
+BEGIN
+    IF TG_TABLE_NAME = 'cities' THEN
+        INSERT INTO deletions (entity, entity_id, city_id) VALUES ('city', OLD.id, OLD.id);
+    ELSE
+        INSERT INTO deletions (entity, entity_id, city_id) VALUES ('state', OLD.id, OLD.city_id);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER cities_record_deletion
+    AFTER DELETE ON cities
+    FOR EACH ROW EXECUTE FUNCTION record_deletion();
+
+CREATE TRIGGER states_record_deletion
+    AFTER DELETE ON states
+    FOR EACH ROW EXECUTE FUNCTION record_deletion();
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS cities_code_key;
+ALTER TABLE cities DROP COLUMN IF EXISTS department;
+ALTER TABLE cities DROP COLUMN IF EXISTS department_code;
+ALTER TABLE cities DROP COLUMN IF EXISTS code;
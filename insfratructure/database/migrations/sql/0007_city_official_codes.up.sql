@@ -0,0 +1,5 @@
+ALTER TABLE cities ADD COLUMN IF NOT EXISTS code VARCHAR(10);
+ALTER TABLE cities ADD COLUMN IF NOT EXISTS department_code VARCHAR(10);
+ALTER TABLE cities ADD COLUMN IF NOT EXISTS department VARCHAR(100);
+
+CREATE UNIQUE INDEX IF NOT EXISTS cities_code_key ON cities (code) WHERE code IS NOT NULL;
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS cities_country_code_idx;
+DROP INDEX IF EXISTS cities_geoname_id_key;
+ALTER TABLE cities DROP COLUMN IF EXISTS alternate_names;
+ALTER TABLE cities DROP COLUMN IF EXISTS timezone;
+ALTER TABLE cities DROP COLUMN IF EXISTS population;
+ALTER TABLE cities DROP COLUMN IF EXISTS longitude;
+ALTER TABLE cities DROP COLUMN IF EXISTS latitude;
+ALTER TABLE cities DROP COLUMN IF EXISTS country_code;
+ALTER TABLE cities DROP COLUMN IF EXISTS geoname_id;
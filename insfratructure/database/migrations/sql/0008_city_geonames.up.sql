@@ -0,0 +1,10 @@
+ALTER TABLE cities ADD COLUMN IF NOT EXISTS geoname_id BIGINT;
+ALTER TABLE cities ADD COLUMN IF NOT EXISTS country_code VARCHAR(2);
+ALTER TABLE cities ADD COLUMN IF NOT EXISTS latitude DOUBLE PRECISION;
+ALTER TABLE cities ADD COLUMN IF NOT EXISTS longitude DOUBLE PRECISION;
+ALTER TABLE cities ADD COLUMN IF NOT EXISTS population BIGINT;
+ALTER TABLE cities ADD COLUMN IF NOT EXISTS timezone VARCHAR(40);
+ALTER TABLE cities ADD COLUMN IF NOT EXISTS alternate_names JSONB;
+
+CREATE UNIQUE INDEX IF NOT EXISTS cities_geoname_id_key ON cities (geoname_id) WHERE geoname_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS cities_country_code_idx ON cities (country_code);
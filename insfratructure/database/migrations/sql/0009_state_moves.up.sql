@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS state_moves (
+    id           BIGSERIAL PRIMARY KEY,
+    state_id     BIGINT NOT NULL,
+    from_city_id BIGINT NOT NULL,
+    to_city_id   BIGINT NOT NULL,
+    reason       TEXT,
+    moved_at     TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CONSTRAINT fk_state_moves_state FOREIGN KEY (state_id)
+        REFERENCES states (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS state_moves_state_id_idx ON state_moves (state_id, moved_at);
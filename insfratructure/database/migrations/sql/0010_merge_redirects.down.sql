@@ -0,0 +1,8 @@
+DROP TRIGGER IF EXISTS states_record_merge ON states;
+DROP TRIGGER IF EXISTS cities_record_merge ON cities;
+DROP INDEX IF EXISTS states_merged_into_idx;
+DROP INDEX IF EXISTS cities_merged_into_idx;
+ALTER TABLE states DROP COLUMN IF EXISTS merged_into;
+ALTER TABLE states DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE cities DROP COLUMN IF EXISTS merged_into;
+ALTER TABLE cities DROP COLUMN IF EXISTS deleted_at;
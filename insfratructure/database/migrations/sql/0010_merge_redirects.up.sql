@@ -0,0 +1,18 @@
+ALTER TABLE cities ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+ALTER TABLE cities ADD COLUMN IF NOT EXISTS merged_into BIGINT;
+ALTER TABLE states ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+ALTER TABLE states ADD COLUMN IF NOT EXISTS merged_into BIGINT;
+
+CREATE INDEX IF NOT EXISTS cities_merged_into_idx ON cities (merged_into) WHERE merged_into IS NOT NULL;
+CREATE INDEX IF NOT EXISTS states_merged_into_idx ON states (merged_into) WHERE merged_into IS NOT NULL;
+
+-- Una fila fusionada queda borrada lógicamente; la sincronización la recibe como eliminación
+CREATE TRIGGER cities_record_merge
+    AFTER UPDATE OF deleted_at ON cities
+    FOR EACH ROW WHEN (OLD.deleted_at IS NULL AND NEW.deleted_at IS NOT NULL)
+    EXECUTE FUNCTION record_deletion();
+
+CREATE TRIGGER states_record_merge
+    AFTER UPDATE OF deleted_at ON states
+    FOR EACH ROW WHEN (OLD.deleted_at IS NULL AND NEW.deleted_at IS NOT NULL)
+    EXECUTE FUNCTION record_deletion();
@@ -0,0 +1,222 @@
+// Package divipola lee la División Político-Administrativa de Colombia (DIVIPOLA) que
+// publica el DANE, en CSV separado por comas o por punto y coma
+package divipola
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Municipality es una fila del archivo con los códigos normalizados: dos dígitos para
+// el departamento y cinco para el municipio, que incluyen el del departamento
+type Municipality struct {
+	DepartmentCode string
+	Department     string
+	Code           string
+	Name           string
+}
+
+// Columnas que se buscan en la cabecera, comparadas sin tildes, mayúsculas, espacios
+// ni signos; cubren los nombres del archivo de datos.gov.co y de la descarga del DANE
+var columns = map[string][]string{
+	"department_code": {"coddpto", "codigodepartamento", "coddepartamento", "codigodpto"},
+	"department":      {"nomdpto", "nombredepartamento", "departamento", "nombredpto"},
+	"code":            {"codmpio", "codigomunicipio", "codmunicipio", "codigodivipola", "codigompio"},
+	"name":            {"nommpio", "nombremunicipio", "municipio", "nombrempio"},
+}
+
+// Parse lee el archivo completo. Acepta UTF-8 o Latin-1, completa los ceros a la
+// izquierda que las hojas de cálculo suelen quitar a los códigos y falla si un código
+// de municipio se repite o no empieza por el de su departamento
+func Parse(r io.Reader) ([]Municipality, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.TrimPrefix(data, []byte("\ufeff"))
+	if !utf8.Valid(data) {
+		data = latin1(data)
+	}
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comma = delimiter(data)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("el archivo DIVIPOLA está vacío")
+	}
+	if err != nil {
+		return nil, err
+	}
+	index, err := headerIndex(header)
+	if err != nil {
+		return nil, err
+	}
+
+	var municipalities []Municipality
+	seen := map[string]int{}
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		value := func(column string) string {
+			if i := index[column]; i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+		municipality := Municipality{
+			DepartmentCode: padCode(value("department_code"), 2),
+			Department:     value("department"),
+			Code:           padCode(value("code"), 5),
+			Name:           value("name"),
+		}
+		// Algunas versiones traen solo los tres dígitos del municipio; un código completo
+		// sin el cero inicial tiene al menos cuatro
+		if code := strings.TrimSuffix(value("code"), ".0"); len(code) <= 3 {
+			municipality.Code = municipality.DepartmentCode + padCode(code, 3)
+		}
+		switch {
+		case !digits(municipality.DepartmentCode, 2):
+			return nil, fmt.Errorf("línea %d: código de departamento inválido %q", line, municipality.DepartmentCode)
+		case !digits(municipality.Code, 5) || !strings.HasPrefix(municipality.Code, municipality.DepartmentCode):
+			return nil, fmt.Errorf("línea %d: código de municipio inválido %q", line, municipality.Code)
+		case municipality.Name == "":
+			return nil, fmt.Errorf("línea %d: municipio sin nombre", line)
+		}
+		if previous, ok := seen[municipality.Code]; ok {
+			return nil, fmt.Errorf("línea %d: el municipio %s ya aparece en la línea %d", line, municipality.Code, previous)
+		}
+		seen[municipality.Code] = line
+		municipalities = append(municipalities, municipality)
+	}
+	if len(municipalities) == 0 {
+		return nil, errors.New("el archivo DIVIPOLA no tiene municipios")
+	}
+	return municipalities, nil
+}
+
+// TitleCase pasa a formato título un nombre que viene todo en mayúsculas, como los
+// publica el DANE, dejando en minúscula los artículos y preposiciones intermedios
+func TitleCase(name string) string {
+	if strings.ToUpper(name) != name {
+		return name
+	}
+	words := strings.Fields(strings.ToLower(name))
+	for i, word := range words {
+		if i > 0 && minorWords[word] {
+			continue
+		}
+		// La primera letra y las que siguen a un punto, como en D.C.
+		runes := []rune(word)
+		upper := true
+		for j, r := range runes {
+			if upper && unicode.IsLetter(r) {
+				runes[j] = unicode.ToUpper(r)
+				upper = false
+			}
+			if r == '.' {
+				upper = true
+			}
+		}
+		words[i] = string(runes)
+	}
+	return strings.Join(words, " ")
+}
+
+var minorWords = map[string]bool{
+	"de": true, "del": true, "la": true, "las": true, "los": true, "el": true, "y": true, "e": true,
+}
+
+func headerIndex(header []string) (map[string]int, error) {
+	index := map[string]int{}
+	for i, name := range header {
+		key := normalize(name)
+		for column, candidates := range columns {
+			if _, found := index[column]; found {
+				continue
+			}
+			for _, candidate := range candidates {
+				if key == candidate {
+					index[column] = i
+				}
+			}
+		}
+	}
+	var missing []string
+	for _, column := range []string{"department_code", "department", "code", "name"} {
+		if _, ok := index[column]; !ok {
+			missing = append(missing, column)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("faltan columnas en la cabecera DIVIPOLA: %s", strings.Join(missing, ", "))
+	}
+	return index, nil
+}
+
+// normalize quita tildes, signos y espacios y pasa a minúsculas
+func normalize(value string) string {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(strings.ToLower(value)) {
+		if unicode.IsLetter(r) && r < unicode.MaxASCII || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// delimiter elige entre coma y punto y coma según la cabecera
+func delimiter(data []byte) rune {
+	line, _, _ := bufio.NewReader(bytes.NewReader(data)).ReadLine()
+	if bytes.Count(line, []byte(";")) > bytes.Count(line, []byte(",")) {
+		return ';'
+	}
+	return ','
+}
+
+func latin1(data []byte) []byte {
+	runes := make([]rune, len(data))
+	for i, b := range data {
+		runes[i] = rune(b)
+	}
+	return []byte(string(runes))
+}
+
+func padCode(code string, width int) string {
+	code = strings.TrimSuffix(code, ".0")
+	for len(code) > 0 && len(code) < width {
+		code = "0" + code
+	}
+	return code
+}
+
+func digits(code string, width int) bool {
+	if len(code) != width {
+		return false
+	}
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
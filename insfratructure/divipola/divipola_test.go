@@ -0,0 +1,77 @@
+package divipola
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	bom := string(rune(0xFEFF))
+	tests := []struct {
+		name    string
+		input   string
+		want    []Municipality
+		wantErr string
+	}{
+		{"coma", "Código Departamento,Nombre Departamento,Código Municipio,Nombre Municipio\n05,ANTIOQUIA,05001,MEDELLÍN\n",
+			[]Municipality{{"05", "ANTIOQUIA", "05001", "MEDELLÍN"}}, ""},
+		{"punto y coma con BOM", bom + "COD_DPTO;NOM_DPTO;COD_MPIO;NOM_MPIO\n11;BOGOTÁ, D.C.;11001;BOGOTÁ, D.C.\n",
+			[]Municipality{{"11", "BOGOTÁ, D.C.", "11001", "BOGOTÁ, D.C."}}, ""},
+		{"ceros perdidos", "coddpto,nomdpto,codmpio,nommpio\n5,ANTIOQUIA,5001,MEDELLÍN\n",
+			[]Municipality{{"05", "ANTIOQUIA", "05001", "MEDELLÍN"}}, ""},
+		{"código de tres dígitos", "coddpto,nomdpto,codmpio,nommpio\n05,ANTIOQUIA,2,ABEJORRAL\n",
+			[]Municipality{{"05", "ANTIOQUIA", "05002", "ABEJORRAL"}}, ""},
+		{"decimales de hoja de cálculo", "coddpto,nomdpto,codmpio,nommpio\n5.0,ANTIOQUIA,5001.0,MEDELLÍN\n",
+			[]Municipality{{"05", "ANTIOQUIA", "05001", "MEDELLÍN"}}, ""},
+		{"latin-1", "coddpto,nomdpto,codmpio,nommpio\n05,ANTIOQUIA,05001,MEDELL\xcdN\n",
+			[]Municipality{{"05", "ANTIOQUIA", "05001", "MEDELLÍN"}}, ""},
+		{"salta líneas vacías", "coddpto,nomdpto,codmpio,nommpio\n,,,\n05,ANTIOQUIA,05001,MEDELLÍN\n",
+			[]Municipality{{"05", "ANTIOQUIA", "05001", "MEDELLÍN"}}, ""},
+		{"vacío", "", nil, "vacío"},
+		{"sin filas", "coddpto,nomdpto,codmpio,nommpio\n", nil, "no tiene municipios"},
+		{"falta columna", "coddpto,nomdpto,codmpio\n05,ANTIOQUIA,05001\n", nil, "faltan columnas"},
+		{"departamento inválido", "coddpto,nomdpto,codmpio,nommpio\nAB,ANTIOQUIA,05001,MEDELLÍN\n", nil, "código de departamento"},
+		{"municipio de otro departamento", "coddpto,nomdpto,codmpio,nommpio\n05,ANTIOQUIA,08001,BARRANQUILLA\n", nil, "código de municipio"},
+		{"sin nombre", "coddpto,nomdpto,codmpio,nommpio\n05,ANTIOQUIA,05001,\n", nil, "sin nombre"},
+		{"repetido", "coddpto,nomdpto,codmpio,nommpio\n05,ANTIOQUIA,05001,MEDELLÍN\n05,ANTIOQUIA,05001,MEDELLÍN\n", nil, "ya aparece en la línea 2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(strings.NewReader(tt.input))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, se esperaba %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error inesperado: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("= %+v, se esperaba %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTitleCase(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"MEDELLÍN", "Medellín"},
+		{"SANTA FE DE ANTIOQUIA", "Santa Fe de Antioquia"},
+		{"EL CARMEN DE VIBORAL", "El Carmen de Viboral"},
+		{"BOGOTÁ, D.C.", "Bogotá, D.C."},
+		{"SAN ANDRÉS DE TUMACO", "San Andrés de Tumaco"},
+		{"Cartagena de Indias", "Cartagena de Indias"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := TitleCase(tt.input); got != tt.want {
+				t.Errorf("TitleCase(%q) = %q, se esperaba %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
@@ -1,12 +1,32 @@
 package entities
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type City struct {
-	Id        uint       `gorm:"primary_key:auto_increment"  json:"id" `
-	Name      string     `gorm:"type:varchar(100);not null" json:"name" `
-	Active    bool       `gorm:"type:boolean"  json:"active"`
-	CreatedAt time.Time  `gorm:"<-:created_at"  json:"created_at"`
-	UpdatedAt *time.Time `gorm:"type:TIMESTAMP(6)" json:"updated_at" `
-	States    *[]States  `json:"states,omitempty"`
+	Id     uint   `gorm:"primary_key:auto_increment"  json:"id" `
+	Name   string `gorm:"type:varchar(100);not null" json:"name" `
+	Active bool   `gorm:"type:boolean"  json:"active"`
+	// Code es el código oficial del municipio (DIVIPOLA); nil en ciudades cargadas a mano
+	Code           *string `gorm:"type:varchar(10)" json:"code,omitempty"`
+	DepartmentCode string  `gorm:"type:varchar(10)" json:"department_code,omitempty"`
+	Department     string  `gorm:"type:varchar(100)" json:"department,omitempty"`
+	// Datos de GeoNames; GeonameId es nil en las ciudades que no vienen de ese volcado
+	GeonameId      *uint64    `json:"geoname_id,omitempty"`
+	CountryCode    string     `gorm:"type:varchar(2)" json:"country_code,omitempty"`
+	Latitude       *float64   `json:"latitude,omitempty"`
+	Longitude      *float64   `json:"longitude,omitempty"`
+	Population     *int64     `json:"population,omitempty"`
+	Timezone       string     `gorm:"type:varchar(40)" json:"timezone,omitempty"`
+	AlternateNames []string   `gorm:"type:jsonb;serializer:json" json:"alternate_names,omitempty"`
+	CreatedAt      time.Time  `gorm:"<-:created_at"  json:"created_at"`
+	UpdatedAt      *time.Time `gorm:"type:TIMESTAMP(6)" json:"updated_at" `
+	States         *[]States  `json:"states,omitempty"`
+	// MergedInto es la ciudad en la que se fusionó; una ciudad fusionada queda borrada
+	// con DeletedAt y las consultas normales ya no la devuelven
+	MergedInto *uint          `json:"merged_into,omitempty"`
+	DeletedAt  gorm.DeletedAt `json:"-"`
 }
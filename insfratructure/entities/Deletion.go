@@ -0,0 +1,12 @@
+package entities
+
+import "time"
+
+// Deletion es la marca (tombstone) que deja una ciudad o barrio eliminado para la sincronización
+type Deletion struct {
+	Id        uint      `gorm:"primary_key:auto_increment" json:"-"`
+	Entity    string    `gorm:"type:varchar(20);not null" json:"-"`
+	EntityId  uint      `gorm:"not null" json:"id"`
+	CityId    uint      `json:"city_id"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
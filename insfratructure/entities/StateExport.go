@@ -0,0 +1,15 @@
+package entities
+
+import "time"
+
+// StateExport es un barrio con el nombre de su ciudad, tal como lo lee la exportación
+type StateExport struct {
+	Id        uint       `json:"id"`
+	Name      string     `json:"name"`
+	ZipCode   string     `json:"zip_code"`
+	CityId    uint       `json:"city_id"`
+	CityName  string     `json:"city_name"`
+	Active    bool       `json:"active"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt *time.Time `json:"updated_at"`
+}
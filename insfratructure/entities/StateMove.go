@@ -0,0 +1,13 @@
+package entities
+
+import "time"
+
+// StateMove es un registro del historial de cambios de ciudad de un barrio
+type StateMove struct {
+	Id         uint      `gorm:"primary_key:auto_increment" json:"id"`
+	StateId    uint      `gorm:"not null" json:"state_id"`
+	FromCityId uint      `gorm:"not null" json:"from_city_id"`
+	ToCityId   uint      `gorm:"not null" json:"to_city_id"`
+	Reason     string    `json:"reason,omitempty"`
+	MovedAt    time.Time `json:"moved_at"`
+}
@@ -1,6 +1,10 @@
 package entities
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type States struct {
 	Id        uint       `gorm:"primary_key:auto_increment" json:"id"`
@@ -11,4 +15,7 @@ type States struct {
 	Active    bool       `gorm:"type:boolean" json:"active"`
 	CreatedAt time.Time  `gorm:"<-:created_at" json:"created"`
 	UpdatedAt *time.Time `gorm:"type:TIMESTAMP(6)"  json:"updated"`
+	// MergedInto es el barrio en el que se fusionó, igual que en City
+	MergedInto *uint          `json:"merged_into,omitempty"`
+	DeletedAt  gorm.DeletedAt `json:"-"`
 }
@@ -0,0 +1,40 @@
+package entities
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Estados de una entrega de webhook
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryDead      = "dead"
+)
+
+type WebhookDelivery struct {
+	Id             uint                `gorm:"primary_key:auto_increment" json:"id"`
+	SubscriptionId uint                `gorm:"not null" json:"subscription_id"`
+	Subscription   WebhookSubscription `gorm:"foreignkey:SubscriptionId" json:"-"`
+	EventId        string              `gorm:"type:uuid;not null" json:"event_id"`
+	EventType      string              `gorm:"type:varchar(100);not null" json:"event_type"`
+	Payload        json.RawMessage     `gorm:"type:jsonb;not null" json:"payload"`
+	Status         string              `gorm:"type:varchar(20);not null" json:"status"`
+	Attempts       int                 `json:"attempts"`
+	NextAttemptAt  time.Time           `json:"next_attempt_at"`
+	LastStatusCode *int                `json:"last_status_code,omitempty"`
+	LastError      *string             `json:"last_error,omitempty"`
+	CreatedAt      time.Time           `gorm:"<-:created_at" json:"created_at"`
+	DeliveredAt    *time.Time          `json:"delivered_at,omitempty"`
+	Log            []WebhookAttempt    `gorm:"foreignkey:DeliveryId" json:"log,omitempty"`
+}
+
+// WebhookAttempt es un intento de entrega registrado en el log
+type WebhookAttempt struct {
+	Id          uint      `gorm:"primary_key:auto_increment" json:"id"`
+	DeliveryId  uint      `gorm:"not null" json:"delivery_id"`
+	StatusCode  *int      `json:"status_code,omitempty"`
+	Error       *string   `json:"error,omitempty"`
+	DurationMs  float64   `json:"duration_ms"`
+	AttemptedAt time.Time `json:"attempted_at"`
+}
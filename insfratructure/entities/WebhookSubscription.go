@@ -0,0 +1,16 @@
+package entities
+
+import "time"
+
+type WebhookSubscription struct {
+	Id  uint   `gorm:"primary_key:auto_increment" json:"id"`
+	Url string `gorm:"type:varchar(2048);not null" json:"url"`
+	// Secret firma las entregas; solo se muestra al crear la suscripción
+	Secret string `gorm:"type:varchar(255);not null" json:"-"`
+	// EventTypes y CityIds vacíos reciben todos los eventos
+	EventTypes []string   `gorm:"type:jsonb;serializer:json" json:"event_types"`
+	CityIds    []uint     `gorm:"type:jsonb;serializer:json" json:"city_ids"`
+	Active     bool       `gorm:"type:boolean" json:"active"`
+	CreatedAt  time.Time  `gorm:"<-:created_at" json:"created_at"`
+	UpdatedAt  *time.Time `gorm:"type:TIMESTAMP(6)" json:"updated_at"`
+}
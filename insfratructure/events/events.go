@@ -0,0 +1,114 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/safe_msvc_city/insfratructure/config"
+	"github.com/safe_msvc_city/insfratructure/entities"
+)
+
+// Tipos de eventos de dominio
+const (
+	CityCreated     = "CityCreated"
+	CityUpdated     = "CityUpdated"
+	CityRenamed     = "CityRenamed"
+	CityDeactivated = "CityDeactivated"
+	CityActivated   = "CityActivated"
+	CityDeleted     = "CityDeleted"
+
+	StateCreated     = "StateCreated"
+	StateUpdated     = "StateUpdated"
+	StateRenamed     = "StateRenamed"
+	StateMoved       = "StateMoved"
+	StateDeactivated = "StateDeactivated"
+	StateActivated   = "StateActivated"
+	StateDeleted     = "StateDeleted"
+)
+
+// Types son todos los tipos de eventos, en el orden en que se documentan
+var Types = []string{
+	CityCreated, CityUpdated, CityRenamed, CityDeactivated, CityActivated, CityDeleted,
+	StateCreated, StateUpdated, StateRenamed, StateMoved, StateDeactivated, StateActivated, StateDeleted,
+}
+
+// Entidades de los eventos
+const (
+	EntityCity  = "city"
+	EntityState = "state"
+)
+
+// Brokers soportados
+const (
+	BrokerNone   = "none"
+	BrokerMemory = "memory"
+	BrokerNATS   = "nats"
+	BrokerKafka  = "kafka"
+)
+
+// Event es un cambio del catálogo. CityId es la ciudad afectada (la propia ciudad o
+// la del barrio) para que los consumidores puedan filtrar por ciudad
+type Event struct {
+	Id         string          `json:"id"`
+	Type       string          `json:"type"`
+	Entity     string          `json:"entity"`
+	EntityId   uint            `json:"entity_id"`
+	CityId     uint            `json:"city_id"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// CityData es el contenido de los eventos de ciudad; Previous solo está en las actualizaciones
+type CityData struct {
+	City     entities.City  `json:"city"`
+	Previous *entities.City `json:"previous,omitempty"`
+}
+
+// StateData es el contenido de los eventos de barrio; Previous solo está en las
+// actualizaciones y en StateMoved trae la ciudad de origen
+type StateData struct {
+	State    entities.States  `json:"state"`
+	Previous *entities.States `json:"previous,omitempty"`
+}
+
+// New crea un evento con id y fecha nuevos
+func New(eventType string, entity string, entityId uint, cityId uint, data any) (Event, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return Event{}, fmt.Errorf("no se pudo serializar el evento %s: %w", eventType, err)
+	}
+	return Event{
+		Id:         uuid.NewString(),
+		Type:       eventType,
+		Entity:     entity,
+		EntityId:   entityId,
+		CityId:     cityId,
+		OccurredAt: time.Now().UTC(),
+		Data:       raw,
+	}, nil
+}
+
+// Broker entrega los eventos a los consumidores externos. Publish debe devolver nil
+// solo cuando el broker confirmó el evento
+type Broker interface {
+	Publish(ctx context.Context, event Event) error
+	Close() error
+}
+
+// NewBroker crea el broker externo indicado en la configuración. Con memory o none
+// devuelve nil: los eventos solo llegan al bus del proceso, que siempre los recibe
+func NewBroker(cfg config.Events) (Broker, error) {
+	switch cfg.Broker {
+	case BrokerNone, BrokerMemory, "":
+		return nil, nil
+	case BrokerNATS:
+		return NewNATS(cfg.NATS)
+	case BrokerKafka:
+		return NewKafka(cfg.Kafka), nil
+	default:
+		return nil, fmt.Errorf("broker de eventos desconocido: %s", cfg.Broker)
+	}
+}
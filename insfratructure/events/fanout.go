@@ -0,0 +1,27 @@
+package events
+
+import (
+	"context"
+	"errors"
+)
+
+// Fanout publica cada evento en todos los brokers; si alguno falla el relay reintenta
+// en todos, lo que mantiene la entrega at-least-once en cada uno
+type Fanout []Broker
+
+func (f Fanout) Publish(ctx context.Context, event Event) error {
+	for _, broker := range f {
+		if err := broker.Publish(ctx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f Fanout) Close() error {
+	var errs []error
+	for _, broker := range f {
+		errs = append(errs, broker.Close())
+	}
+	return errors.Join(errs...)
+}
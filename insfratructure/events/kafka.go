@@ -0,0 +1,44 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/safe_msvc_city/insfratructure/config"
+	"github.com/segmentio/kafka-go"
+)
+
+// Kafka publica los eventos en un topic; la clave es la entidad y su id para que los
+// cambios de una misma ciudad o barrio conserven el orden dentro de la partición
+type Kafka struct {
+	writer *kafka.Writer
+}
+
+func NewKafka(cfg config.Kafka) *Kafka {
+	return &Kafka{writer: &kafka.Writer{
+		Addr:         kafka.TCP(cfg.Brokers...),
+		Topic:        cfg.Topic,
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireAll,
+	}}
+}
+
+func (b *Kafka) Publish(ctx context.Context, event Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return b.writer.WriteMessages(ctx, kafka.Message{
+		Key:   []byte(fmt.Sprintf("%s:%d", event.Entity, event.EntityId)),
+		Value: data,
+		Headers: []kafka.Header{
+			{Key: "event_id", Value: []byte(event.Id)},
+			{Key: "event_type", Value: []byte(event.Type)},
+		},
+	})
+}
+
+func (b *Kafka) Close() error {
+	return b.writer.Close()
+}
@@ -0,0 +1,44 @@
+package events
+
+import (
+	"context"
+	"sync"
+)
+
+// Handler procesa un evento entregado en el proceso
+type Handler func(ctx context.Context, event Event) error
+
+// InProcess entrega los eventos a los suscriptores del mismo proceso; sirve para
+// pruebas y para despliegues sin broker externo
+type InProcess struct {
+	mux      sync.RWMutex
+	handlers []Handler
+}
+
+func NewInProcess() *InProcess {
+	return &InProcess{}
+}
+
+// Subscribe agrega un suscriptor que recibirá todos los eventos publicados
+func (b *InProcess) Subscribe(handler Handler) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+	b.handlers = append(b.handlers, handler)
+}
+
+// Publish llama a los suscriptores en orden; si alguno falla el evento se reintenta
+// más tarde, por lo que los suscriptores deben tolerar duplicados
+func (b *InProcess) Publish(ctx context.Context, event Event) error {
+	b.mux.RLock()
+	defer b.mux.RUnlock()
+	for _, handler := range b.handlers {
+		if err := handler(ctx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *InProcess) Close() error {
+	return nil
+}
@@ -0,0 +1,72 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/safe_msvc_city/insfratructure/config"
+)
+
+// natsSetupTimeout limita la creación del stream al conectar
+const natsSetupTimeout = 10 * time.Second
+
+// NATS publica cada evento por JetStream en el subject <prefijo>.<tipo> y espera el
+// PubAck: solo entonces el evento quedó guardado en el stream aunque no haya
+// suscriptores conectados. Nats-Msg-Id deja que JetStream descarte los reenvíos del relay
+type NATS struct {
+	conn   *nats.Conn
+	js     jetstream.JetStream
+	prefix string
+}
+
+// NewNATS conecta al servidor de NATS y crea el stream si no existe; la librería
+// reconecta sola si la conexión se cae
+func NewNATS(cfg config.NATS) (*NATS, error) {
+	conn, err := nats.Connect(cfg.URL, nats.Name("safe_msvc_city"), nats.MaxReconnects(-1))
+	if err != nil {
+		return nil, fmt.Errorf("no se pudo conectar a NATS: %w", err)
+	}
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("no se pudo abrir JetStream: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), natsSetupTimeout)
+	defer cancel()
+	if _, err := js.Stream(ctx, cfg.Stream); errors.Is(err, jetstream.ErrStreamNotFound) {
+		_, err = js.CreateStream(ctx, jetstream.StreamConfig{
+			Name:     cfg.Stream,
+			Subjects: []string{cfg.SubjectPrefix + ".>"},
+			Storage:  jetstream.FileStorage,
+		})
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("no se pudo crear el stream %s: %w", cfg.Stream, err)
+		}
+	} else if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("no se pudo consultar el stream %s: %w", cfg.Stream, err)
+	}
+	return &NATS{conn: conn, js: js, prefix: cfg.SubjectPrefix}, nil
+}
+
+func (b *NATS) Publish(ctx context.Context, event Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	msg := nats.NewMsg(b.prefix + "." + event.Type)
+	msg.Header.Set(jetstream.MsgIDHeader, event.Id)
+	msg.Data = data
+	_, err = b.js.PublishMsg(ctx, msg)
+	return err
+}
+
+func (b *NATS) Close() error {
+	return b.conn.Drain()
+}
@@ -0,0 +1,208 @@
+// Package geonames lee los volcados de GeoNames (cities*.txt, allCountries.txt y
+// admin1CodesASCII.txt) desde archivos locales, sin acceder a la red
+package geonames
+
+import (
+	"archive/zip"
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Columnas del formato geoname, separadas por tabulador
+const (
+	colGeonameId      = 0
+	colName           = 1
+	colAsciiName      = 2
+	colAlternateNames = 3
+	colLatitude       = 4
+	colLongitude      = 5
+	colFeatureClass   = 6
+	colCountryCode    = 8
+	colAdmin1Code     = 10
+	colPopulation     = 14
+	colTimezone       = 17
+	geonameColumns    = 19
+)
+
+// populatedPlace es la clase de entidad de ciudades y pueblos; allCountries trae
+// además ríos, montañas y demás, que se descartan
+const populatedPlace = "P"
+
+// maxLine admite las líneas más largas de allCountries, que pueden traer miles de
+// nombres alternativos
+const maxLine = 1 << 20
+
+// Place es una ciudad del volcado
+type Place struct {
+	GeonameId      uint64
+	Name           string
+	AsciiName      string
+	AlternateNames []string
+	Latitude       float64
+	Longitude      float64
+	CountryCode    string
+	Admin1Code     string
+	Population     int64
+	Timezone       string
+}
+
+// Filter limita las ciudades leídas; Countries vacío acepta todos los países
+type Filter struct {
+	Countries     map[string]bool
+	MinPopulation int64
+}
+
+func (f Filter) accepts(country string, population int64) bool {
+	if len(f.Countries) > 0 && !f.Countries[country] {
+		return false
+	}
+	return population >= f.MinPopulation
+}
+
+// Open abre un volcado; si la ruta es un .zip como los que publica GeoNames, lee el
+// .txt de mismo nombre o el primero que encuentre dentro
+func Open(path string) (io.ReadCloser, error) {
+	if !strings.EqualFold(filepath.Ext(path), ".zip") {
+		return os.Open(path)
+	}
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	want := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)) + ".txt"
+	var chosen *zip.File
+	for _, file := range archive.File {
+		if file.Name == want {
+			chosen = file
+			break
+		}
+		if chosen == nil && strings.HasSuffix(file.Name, ".txt") {
+			chosen = file
+		}
+	}
+	if chosen == nil {
+		archive.Close()
+		return nil, fmt.Errorf("%s no contiene ningún .txt", path)
+	}
+	reader, err := chosen.Open()
+	if err != nil {
+		archive.Close()
+		return nil, err
+	}
+	return zipEntry{ReadCloser: reader, archive: archive}, nil
+}
+
+type zipEntry struct {
+	io.ReadCloser
+	archive *zip.ReadCloser
+}
+
+func (z zipEntry) Close() error {
+	return errors.Join(z.ReadCloser.Close(), z.archive.Close())
+}
+
+// ReadAdmin1 lee admin1CodesASCII.txt y devuelve el nombre de cada división por su
+// clave PAÍS.CÓDIGO, por ejemplo CO.02
+func ReadAdmin1(r io.Reader) (map[string]string, error) {
+	names := map[string]string{}
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := scanner.Text()
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Split(text, "\t")
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("admin1 línea %d: se esperaban al menos 2 columnas", line)
+		}
+		names[fields[0]] = fields[1]
+	}
+	return names, scanner.Err()
+}
+
+// Read recorre el volcado línea a línea y llama a fn con cada lugar poblado que pasa
+// el filtro, sin cargar el archivo completo en memoria
+func Read(r io.Reader, filter Filter, fn func(Place) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLine)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := scanner.Text()
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Split(text, "\t")
+		if len(fields) < geonameColumns {
+			return fmt.Errorf("línea %d: se esperaban %d columnas y hay %d", line, geonameColumns, len(fields))
+		}
+		if fields[colFeatureClass] != populatedPlace {
+			continue
+		}
+		var population int64
+		if value := fields[colPopulation]; value != "" {
+			parsed, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return fmt.Errorf("línea %d: población inválida %q", line, value)
+			}
+			population = parsed
+		}
+		if !filter.accepts(fields[colCountryCode], population) {
+			continue
+		}
+		place, err := parsePlace(fields, population)
+		if err != nil {
+			return fmt.Errorf("línea %d: %w", line, err)
+		}
+		if err := fn(place); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+func parsePlace(fields []string, population int64) (Place, error) {
+	id, err := strconv.ParseUint(fields[colGeonameId], 10, 64)
+	if err != nil {
+		return Place{}, fmt.Errorf("geonameid inválido %q", fields[colGeonameId])
+	}
+	latitude, err := strconv.ParseFloat(fields[colLatitude], 64)
+	if err != nil {
+		return Place{}, fmt.Errorf("latitud inválida %q", fields[colLatitude])
+	}
+	longitude, err := strconv.ParseFloat(fields[colLongitude], 64)
+	if err != nil {
+		return Place{}, fmt.Errorf("longitud inválida %q", fields[colLongitude])
+	}
+	place := Place{
+		GeonameId:   id,
+		Name:        strings.TrimSpace(fields[colName]),
+		AsciiName:   strings.TrimSpace(fields[colAsciiName]),
+		Latitude:    latitude,
+		Longitude:   longitude,
+		CountryCode: fields[colCountryCode],
+		Admin1Code:  fields[colAdmin1Code],
+		Population:  population,
+		Timezone:    fields[colTimezone],
+	}
+	if place.Name == "" {
+		return Place{}, errors.New("lugar sin nombre")
+	}
+	seen := map[string]bool{place.Name: true}
+	for _, name := range strings.Split(fields[colAlternateNames], ",") {
+		name = strings.TrimSpace(name)
+		if name != "" && !seen[name] {
+			seen[name] = true
+			place.AlternateNames = append(place.AlternateNames, name)
+		}
+	}
+	return place, nil
+}
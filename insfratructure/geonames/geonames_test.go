@@ -0,0 +1,134 @@
+package geonames
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// row arma una línea del volcado con las columnas que lee Read
+func row(id string, name string, alternate string, class string, country string, admin1 string, population string) string {
+	fields := make([]string, geonameColumns)
+	fields[colGeonameId] = id
+	fields[colName] = name
+	fields[colAsciiName] = name
+	fields[colAlternateNames] = alternate
+	fields[colLatitude] = "6.25184"
+	fields[colLongitude] = "-75.56359"
+	fields[colFeatureClass] = class
+	fields[colCountryCode] = country
+	fields[colAdmin1Code] = admin1
+	fields[colPopulation] = population
+	fields[colTimezone] = "America/Bogota"
+	return strings.Join(fields, "\t")
+}
+
+func TestRead(t *testing.T) {
+	dump := strings.Join([]string{
+		"# comentario",
+		row("3674962", "Medellín", "Medellin,Medellín, MDE ,", "P", "CO", "02", "1999979"),
+		"",
+		row("3686110", "Río Cauca", "", "H", "CO", "02", "0"),
+		row("3688689", "Bogotá", "", "P", "CO", "34", "7674366"),
+		row("3936456", "Lima", "", "P", "PE", "15", "7737002"),
+		row("3682385", "Guatapé", "", "P", "CO", "02", ""),
+	}, "\n")
+	tests := []struct {
+		name   string
+		filter Filter
+		want   []uint64
+	}{
+		{"sin filtro", Filter{}, []uint64{3674962, 3688689, 3936456, 3682385}},
+		{"por país", Filter{Countries: map[string]bool{"CO": true}}, []uint64{3674962, 3688689, 3682385}},
+		{"por población", Filter{MinPopulation: 2000000}, []uint64{3688689, 3936456}},
+		{"país y población", Filter{Countries: map[string]bool{"PE": true}, MinPopulation: 2000000}, []uint64{3936456}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []uint64
+			err := Read(strings.NewReader(dump), tt.filter, func(place Place) error {
+				got = append(got, place.GeonameId)
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("= %v, se esperaba %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadPlace(t *testing.T) {
+	var place Place
+	err := Read(strings.NewReader(row("3674962", " Medellín ", "Medellin,Medellín, MDE ,Medellin", "P", "CO", "02", "1999979")), Filter{},
+		func(p Place) error { place = p; return nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Place{
+		GeonameId:      3674962,
+		Name:           "Medellín",
+		AsciiName:      "Medellín",
+		AlternateNames: []string{"Medellin", "MDE"},
+		Latitude:       6.25184,
+		Longitude:      -75.56359,
+		CountryCode:    "CO",
+		Admin1Code:     "02",
+		Population:     1999979,
+		Timezone:       "America/Bogota",
+	}
+	if !reflect.DeepEqual(place, want) {
+		t.Errorf("= %+v, se esperaba %+v", place, want)
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		wantErr string
+	}{
+		{"pocas columnas", "1\tMedellín", "se esperaban 19 columnas"},
+		{"población", row("1", "Medellín", "", "P", "CO", "02", "mucha"), "población inválida"},
+		{"geonameid", row("x", "Medellín", "", "P", "CO", "02", "1"), "geonameid inválido"},
+		{"sin nombre", row("1", " ", "", "P", "CO", "02", "1"), "lugar sin nombre"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Read(strings.NewReader(tt.line), Filter{}, func(Place) error { return nil })
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, se esperaba %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReadStopsOnCallbackError(t *testing.T) {
+	stop := errors.New("basta")
+	calls := 0
+	dump := row("1", "A", "", "P", "CO", "02", "1") + "\n" + row("2", "B", "", "P", "CO", "02", "1")
+	err := Read(strings.NewReader(dump), Filter{}, func(Place) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) || calls != 1 {
+		t.Errorf("error = %v tras %d llamadas, se esperaba %v tras 1", err, calls, stop)
+	}
+}
+
+func TestReadAdmin1(t *testing.T) {
+	names, err := ReadAdmin1(strings.NewReader("# admin1\nCO.02\tAntioquia\tAntioquia\t3689815\n\nCO.34\tBogota D.C.\tBogota D.C.\t3688685\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"CO.02": "Antioquia", "CO.34": "Bogota D.C."}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("= %v, se esperaba %v", names, want)
+	}
+	if _, err := ReadAdmin1(strings.NewReader("CO.02\n")); err == nil {
+		t.Error("se esperaba un error con una sola columna")
+	}
+}
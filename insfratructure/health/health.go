@@ -0,0 +1,126 @@
+package health
+
+import (
+	"context"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Version y Commit se definen al compilar con
+// -ldflags "-X github.com/safe_msvc_city/insfratructure/health.Version=... -X ...Commit=..."
+var (
+	Version = "dev"
+	Commit  = ""
+)
+
+// Check verifica una dependencia; devuelve nil si está disponible
+type Check func(ctx context.Context) error
+
+// Result es el resultado de ejecutar un Check
+type Result struct {
+	Name    string        `json:"name"`
+	Healthy bool          `json:"healthy"`
+	Latency time.Duration `json:"-"`
+	// LatencyMs es Latency en milisegundos para las respuestas JSON
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+var (
+	checksMux sync.RWMutex
+	checks    = map[string]Check{}
+	ready     atomic.Bool
+	started   = time.Now()
+)
+
+// Register agrega un chequeo de readiness con el nombre indicado
+func Register(name string, check Check) {
+	checksMux.Lock()
+	defer checksMux.Unlock()
+	checks[name] = check
+}
+
+// SetReady marca si el servicio acepta tráfico; se usa al arrancar y al apagar
+func SetReady(value bool) {
+	ready.Store(value)
+}
+
+// Ready indica si el servicio terminó de arrancar y no se está apagando
+func Ready() bool {
+	return ready.Load()
+}
+
+// Uptime devuelve el tiempo transcurrido desde que arrancó el proceso
+func Uptime() time.Duration {
+	return time.Since(started)
+}
+
+// Run ejecuta todos los chequeos registrados en paralelo, cada uno con timeout
+func Run(ctx context.Context, timeout time.Duration) []Result {
+	checksMux.RLock()
+	names := make([]string, 0, len(checks))
+	for name := range checks {
+		names = append(names, name)
+	}
+	checksMux.RUnlock()
+	sort.Strings(names)
+
+	results := make([]Result, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		checksMux.RLock()
+		check := checks[name]
+		checksMux.RUnlock()
+
+		wg.Add(1)
+		go func(i int, name string, check Check) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			start := time.Now()
+			err := check(ctx)
+			latency := time.Since(start)
+			results[i] = Result{
+				Name:      name,
+				Healthy:   err == nil,
+				Latency:   latency,
+				LatencyMs: float64(latency.Microseconds()) / 1000,
+			}
+			if err != nil {
+				results[i].Error = err.Error()
+			}
+		}(i, name, check)
+	}
+	wg.Wait()
+	return results
+}
+
+// Healthy indica si todos los resultados son saludables
+func Healthy(results []Result) bool {
+	for _, result := range results {
+		if !result.Healthy {
+			return false
+		}
+	}
+	return true
+}
+
+// BuildInfo devuelve la versión y el commit; si no se definieron al compilar usa
+// la información de VCS que Go incluye en el binario
+func BuildInfo() (string, string) {
+	commit := Commit
+	if commit == "" {
+		commit = "unknown"
+		if info, ok := debug.ReadBuildInfo(); ok {
+			for _, setting := range info.Settings {
+				if setting.Key == "vcs.revision" {
+					commit = setting.Value
+				}
+			}
+		}
+	}
+	return Version, commit
+}
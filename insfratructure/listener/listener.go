@@ -0,0 +1,95 @@
+package listener
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Channel es el canal de NOTIFY que usan los triggers de cities y states
+const Channel = "catalogue_changes"
+
+// Límites del backoff de reconexión
+const (
+	initialBackoff = time.Second
+	maxBackoff     = 30 * time.Second
+)
+
+// Change es el payload que envían los triggers al modificar una fila
+type Change struct {
+	// Entity es city o state
+	Entity string `json:"entity"`
+	// Operation es insert, update o delete
+	Operation string `json:"operation"`
+	Id        uint   `json:"id"`
+	// CityId y OldCityId solo se envían para barrios; OldCityId es la ciudad antes del cambio
+	CityId    uint `json:"city_id"`
+	OldCityId uint `json:"old_city_id"`
+}
+
+// Handler recibe los cambios y las reconexiones del listener
+type Handler interface {
+	// Changed se llama por cada NOTIFY recibido
+	Changed(ctx context.Context, change Change)
+	// Reconnected se llama al recuperar la conexión; los NOTIFY enviados mientras
+	// estuvo caída se perdieron
+	Reconnected(ctx context.Context)
+}
+
+// Start abre una conexión dedicada con LISTEN y entrega los cambios a handler hasta
+// que ctx termine. Si la conexión se cae se reabre con backoff exponencial
+func Start(ctx context.Context, dsn string, handler Handler) {
+	go func() {
+		backoff := initialBackoff
+		connected := false
+		for ctx.Err() == nil {
+			err := listen(ctx, dsn, func() {
+				if connected {
+					slog.Info("change listener reconnected")
+					handler.Reconnected(ctx)
+				}
+				connected = true
+				backoff = initialBackoff
+			}, handler)
+			if ctx.Err() != nil {
+				return
+			}
+			slog.Warn("change listener disconnected", "error", err, "retry_in", backoff.String())
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			backoff = min(backoff*2, maxBackoff)
+		}
+	}()
+}
+
+// listen mantiene una conexión hasta que falle; onListen se llama cuando el LISTEN queda activo
+func listen(ctx context.Context, dsn string, onListen func(), handler Handler) error {
+	conn, err := pgx.Connect(ctx, dsn)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+Channel); err != nil {
+		return err
+	}
+	onListen()
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		var change Change
+		if err := json.Unmarshal([]byte(notification.Payload), &change); err != nil {
+			slog.Warn("invalid change notification", "payload", notification.Payload, "error", err)
+			continue
+		}
+		handler.Changed(ctx, change)
+	}
+}
@@ -0,0 +1,76 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/safe_msvc_city/insfratructure/config"
+)
+
+// redacted reemplaza el valor de los atributos sensibles
+const redacted = "[REDACTED]"
+
+// sensitiveKeys son las claves (en minúsculas) que nunca se escriben en los logs
+var sensitiveKeys = map[string]bool{
+	"authorization": true,
+	"password":      true,
+	"jwt_secret":    true,
+	"cookie":        true,
+	"set-cookie":    true,
+}
+
+type contextKey struct{}
+
+// Setup crea el logger según la configuración y lo deja como slog.Default
+func Setup(cfg config.Logging) (*slog.Logger, error) {
+	logger, err := New(os.Stdout, cfg)
+	if err != nil {
+		return nil, err
+	}
+	slog.SetDefault(logger)
+	return logger, nil
+}
+
+// New crea un logger JSON (o de texto) con el nivel indicado y redacción de secretos
+func New(w io.Writer, cfg config.Logging) (*slog.Logger, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(cfg.Level)); err != nil {
+		return nil, fmt.Errorf("nivel de log inválido %q: %w", cfg.Level, err)
+	}
+	options := &slog.HandlerOptions{Level: level, ReplaceAttr: redact}
+	switch cfg.Format {
+	case "json", "":
+		return slog.New(slog.NewJSONHandler(w, options)), nil
+	case "text":
+		return slog.New(slog.NewTextHandler(w, options)), nil
+	default:
+		return nil, fmt.Errorf("formato de log desconocido: %s", cfg.Format)
+	}
+}
+
+// redact oculta el valor de los atributos sensibles, incluidos los encabezados
+func redact(_ []string, attr slog.Attr) slog.Attr {
+	if sensitiveKeys[strings.ToLower(attr.Key)] {
+		return slog.String(attr.Key, redacted)
+	}
+	return attr
+}
+
+// WithLogger devuelve un contexto que lleva el logger indicado
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// FromContext devuelve el logger de la petición o slog.Default si no hay uno
+func FromContext(ctx context.Context) *slog.Logger {
+	if ctx != nil {
+		if logger, ok := ctx.Value(contextKey{}).(*slog.Logger); ok {
+			return logger
+		}
+	}
+	return slog.Default()
+}
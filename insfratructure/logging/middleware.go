@@ -0,0 +1,70 @@
+package logging
+
+import (
+	"log/slog"
+	"regexp"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// HeaderRequestID es el encabezado que correlaciona una petición entre servicios
+const HeaderRequestID = "X-Request-ID"
+
+// validRequestID limita los ids aceptados del cliente para no inyectar basura en los logs
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// Middleware acepta o genera el X-Request-ID, lo devuelve en la respuesta, deja en
+// c.UserContext() un logger con request_id y trace_id y escribe el access log
+func Middleware(c *fiber.Ctx) error {
+	start := time.Now()
+	requestID := c.Get(HeaderRequestID)
+	if !validRequestID.MatchString(requestID) {
+		requestID = uuid.NewString()
+	}
+	c.Set(HeaderRequestID, requestID)
+
+	ctx := c.UserContext()
+	attrs := []any{slog.String("request_id", requestID)}
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		attrs = append(attrs, slog.String("trace_id", span.SpanContext().TraceID().String()))
+		span.SetAttributes(attribute.String("request.id", requestID))
+	}
+	logger := FromContext(ctx).With(attrs...)
+	c.SetUserContext(WithLogger(ctx, logger))
+
+	err := c.Next()
+
+	status := c.Response().StatusCode()
+	if fiberErr, ok := err.(*fiber.Error); ok {
+		status = fiberErr.Code
+	} else if err != nil {
+		status = fiber.StatusInternalServerError
+	}
+	level := slog.LevelInfo
+	switch {
+	case status >= fiber.StatusInternalServerError:
+		level = slog.LevelError
+	case status >= fiber.StatusBadRequest:
+		level = slog.LevelWarn
+	}
+	headers := make([]any, 0)
+	c.Request().Header.VisitAll(func(key, value []byte) {
+		headers = append(headers, slog.String(string(key), string(value)))
+	})
+	logger.LogAttrs(c.UserContext(), level, "http request",
+		slog.String("method", c.Method()),
+		slog.String("route", c.Route().Path),
+		slog.String("path", c.Path()),
+		slog.Int("status", status),
+		slog.Float64("duration_ms", float64(time.Since(start).Microseconds())/1000),
+		slog.Int("bytes", len(c.Response().Body())),
+		slog.String("ip", c.IP()),
+		slog.Group("headers", headers...),
+	)
+	return err
+}
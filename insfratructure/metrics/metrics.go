@@ -0,0 +1,166 @@
+package metrics
+
+import (
+	"database/sql"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "safe_city"
+
+// Entidades y operaciones usadas en las etiquetas de negocio
+const (
+	EntityCity  = "city"
+	EntityState = "state"
+
+	OperationCreated = "created"
+	OperationUpdated = "updated"
+	OperationDeleted = "deleted"
+)
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Peticiones HTTP atendidas por método, ruta y código de estado.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Latencia de las peticiones HTTP por método, ruta y código de estado.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	repositoryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "repository_call_duration_seconds",
+		Help:      "Latencia de las llamadas a los repositorios de core por método.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"repository", "method"})
+
+	catalogueChanges = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "catalogue_changes_total",
+		Help:      "Ciudades y barrios creados, actualizados o eliminados.",
+	}, []string{"entity", "operation"})
+
+	validationFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "validation_failures_total",
+		Help:      "Peticiones rechazadas por validación, por entidad y código.",
+	}, []string{"entity", "code"})
+
+	cacheRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_requests_total",
+		Help:      "Lecturas de la caché por repositorio y resultado (hit o miss).",
+	}, []string{"cache", "result"})
+
+	eventsPublished = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "events_published_total",
+		Help:      "Eventos de dominio publicados en el broker por tipo.",
+	}, []string{"type"})
+
+	eventPublishFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "event_publish_failures_total",
+		Help:      "Intentos fallidos de publicar eventos de dominio por tipo.",
+	}, []string{"type"})
+
+	webhookDeliveries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "webhook_deliveries_total",
+		Help:      "Intentos de entrega de webhooks por resultado (delivered, retry o dead).",
+	}, []string{"result"})
+
+	nameConflicts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "name_conflicts_total",
+		Help:      "Peticiones rechazadas porque el nombre ya existe.",
+	}, []string{"entity"})
+)
+
+// Middleware registra el conteo y la latencia de cada petición. La ruta se toma del
+// patrón registrado (/api/cities/:id) para no crear una serie por cada id
+func Middleware(c *fiber.Ctx) error {
+	start := time.Now()
+	err := c.Next()
+
+	status := c.Response().StatusCode()
+	if err != nil {
+		if fiberErr, ok := err.(*fiber.Error); ok {
+			status = fiberErr.Code
+		} else {
+			status = fiber.StatusInternalServerError
+		}
+	}
+	labels := prometheus.Labels{
+		"method": c.Method(),
+		"route":  c.Route().Path,
+		"status": strconv.Itoa(status),
+	}
+	httpRequests.With(labels).Inc()
+	httpDuration.With(labels).Observe(time.Since(start).Seconds())
+	return err
+}
+
+// ObserveRepository mide una llamada a un repositorio; se usa como
+// defer metrics.ObserveRepository("city", "GetCityFindAll")()
+func ObserveRepository(repository string, method string) func() {
+	start := time.Now()
+	return func() {
+		repositoryDuration.WithLabelValues(repository, method).Observe(time.Since(start).Seconds())
+	}
+}
+
+// CatalogueChanged cuenta una creación, actualización o eliminación
+func CatalogueChanged(entity string, operation string) {
+	catalogueChanges.WithLabelValues(entity, operation).Inc()
+}
+
+// ValidationFailed cuenta una petición rechazada por validación
+func ValidationFailed(entity string, code string) {
+	validationFailures.WithLabelValues(entity, code).Inc()
+}
+
+// NameConflict cuenta una petición rechazada por nombre duplicado
+func NameConflict(entity string) {
+	nameConflicts.WithLabelValues(entity).Inc()
+}
+
+// CacheHit cuenta una lectura servida desde la caché
+func CacheHit(cache string) {
+	cacheRequests.WithLabelValues(cache, "hit").Inc()
+}
+
+// CacheMiss cuenta una lectura que tuvo que ir a la base de datos
+func CacheMiss(cache string) {
+	cacheRequests.WithLabelValues(cache, "miss").Inc()
+}
+
+// EventPublished cuenta un evento confirmado por el broker
+func EventPublished(eventType string) {
+	eventsPublished.WithLabelValues(eventType).Inc()
+}
+
+// EventPublishFailed cuenta un intento fallido de publicar un evento
+func EventPublishFailed(eventType string) {
+	eventPublishFailures.WithLabelValues(eventType).Inc()
+}
+
+// WebhookDelivery cuenta un intento de entrega de webhook por resultado
+func WebhookDelivery(result string) {
+	webhookDeliveries.WithLabelValues(result).Inc()
+}
+
+// RegisterDBStats publica las estadísticas del pool de database/sql como gauges
+func RegisterDBStats(db *sql.DB) error {
+	return prometheus.Register(collectors.NewDBStatsCollector(db, "postgres"))
+}
@@ -1,16 +1,68 @@
 package middleware
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
 
 	constants "github.com/flabio/safe_constants"
+	"github.com/safe_msvc_city/insfratructure/config"
+	"github.com/safe_msvc_city/insfratructure/logging"
 )
 
+// claimsKey es la clave de c.Locals con los claims del token verificado
+const claimsKey = "claims"
+
+// roleMissing es el mensaje cuando el token es válido pero no trae el rol pedido
+const roleMissing = "el token no tiene el rol %s"
+
+var jwtSecret []byte
+
+// Configure fija la clave con la que se verifican los tokens; sin clave ValidateToken
+// rechaza todas las peticiones
+func Configure(cfg config.Auth) {
+	jwtSecret = []byte(cfg.JWTSecret)
+}
+
+// ValidateToken verifica la firma HS256 y la vigencia del token Bearer, tal como los
+// genera el comando user-token, y deja sus claims en c.Locals
 func ValidateToken(c *fiber.Ctx) error {
-	token := c.Get(constants.AUTHORIZATION)
-	if len(token) > 7 && token[:7] == constants.BEARER {
+	header := c.Get(constants.AUTHORIZATION)
+	if len(jwtSecret) == 0 || !strings.HasPrefix(header, constants.BEARER) {
+		return unauthorized(c)
+	}
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(strings.TrimPrefix(header, constants.BEARER), claims,
+		func(*jwt.Token) (interface{}, error) { return jwtSecret, nil },
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		logging.FromContext(c.UserContext()).Warn("token rejected", "error", err)
+		return unauthorized(c)
+	}
+	c.Locals(claimsKey, claims)
+	return c.Next()
+}
+
+// RequireRole deja pasar solo los tokens cuyo claim role es role; va después de
+// ValidateToken
+func RequireRole(role string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		claims, _ := c.Locals(claimsKey).(jwt.MapClaims)
+		if value, _ := claims["role"].(string); value != role {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				constants.STATUS:  fiber.StatusForbidden,
+				constants.MESSAGE: fmt.Sprintf(roleMissing, role),
+			})
+		}
 		return c.Next()
 	}
+}
+
+func unauthorized(c *fiber.Ctx) error {
 	return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 		constants.STATUS:  fiber.StatusUnauthorized,
 		constants.MESSAGE: constants.TOKEN_INVALID,
@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+
+	constants "github.com/flabio/safe_constants"
+	"github.com/safe_msvc_city/insfratructure/config"
+)
+
+const testSecret = "secreto-de-prueba"
+
+func sign(t *testing.T, method jwt.SigningMethod, key interface{}, claims jwt.MapClaims) string {
+	t.Helper()
+	signed, err := jwt.NewWithClaims(method, claims).SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestValidateToken(t *testing.T) {
+	future := time.Now().Add(time.Hour).Unix()
+	past := time.Now().Add(-time.Hour).Unix()
+	tests := []struct {
+		name   string
+		secret string
+		header string
+		status int
+	}{
+		{"sin clave configurada", "", constants.BEARER + sign(t, jwt.SigningMethodHS256, []byte(testSecret), jwt.MapClaims{"exp": future}), fiber.StatusUnauthorized},
+		{"sin cabecera", testSecret, "", fiber.StatusUnauthorized},
+		{"sin prefijo Bearer", testSecret, sign(t, jwt.SigningMethodHS256, []byte(testSecret), jwt.MapClaims{"exp": future}), fiber.StatusUnauthorized},
+		{"cualquier texto", testSecret, constants.BEARER + "x", fiber.StatusUnauthorized},
+		{"otra clave", testSecret, constants.BEARER + sign(t, jwt.SigningMethodHS256, []byte("otra"), jwt.MapClaims{"exp": future}), fiber.StatusUnauthorized},
+		{"vencido", testSecret, constants.BEARER + sign(t, jwt.SigningMethodHS256, []byte(testSecret), jwt.MapClaims{"exp": past}), fiber.StatusUnauthorized},
+		{"sin exp", testSecret, constants.BEARER + sign(t, jwt.SigningMethodHS256, []byte(testSecret), jwt.MapClaims{"sub": "dev"}), fiber.StatusUnauthorized},
+		{"alg none", testSecret, constants.BEARER + sign(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, jwt.MapClaims{"exp": future}), fiber.StatusUnauthorized},
+		{"válido", testSecret, constants.BEARER + sign(t, jwt.SigningMethodHS256, []byte(testSecret), jwt.MapClaims{"exp": future}), fiber.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			Configure(config.Auth{JWTSecret: tt.secret})
+			app := fiber.New()
+			app.Get("/", ValidateToken, func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.header != "" {
+				req.Header.Set(constants.AUTHORIZATION, tt.header)
+			}
+			res, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.StatusCode != tt.status {
+				t.Errorf("status = %d, se esperaba %d", res.StatusCode, tt.status)
+			}
+		})
+	}
+}
+
+func TestRequireRole(t *testing.T) {
+	Configure(config.Auth{JWTSecret: testSecret})
+	future := time.Now().Add(time.Hour).Unix()
+	tests := []struct {
+		name   string
+		claims jwt.MapClaims
+		status int
+	}{
+		{"admin", jwt.MapClaims{"exp": future, "role": "admin"}, fiber.StatusOK},
+		{"otro rol", jwt.MapClaims{"exp": future, "role": "user"}, fiber.StatusForbidden},
+		{"sin rol", jwt.MapClaims{"exp": future}, fiber.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Get("/", ValidateToken, RequireRole("admin"), func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set(constants.AUTHORIZATION, constants.BEARER+sign(t, jwt.SigningMethodHS256, []byte(testSecret), tt.claims))
+			res, err := app.Test(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.StatusCode != tt.status {
+				t.Errorf("status = %d, se esperaba %d", res.StatusCode, tt.status)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/safe_msvc_city/insfratructure/events"
+	"gorm.io/gorm"
+)
+
+// record es una fila de la tabla outbox
+type record struct {
+	Id          uint64
+	EventId     string
+	Type        string
+	Entity      string
+	EntityId    uint
+	CityId      uint
+	Payload     []byte `gorm:"type:jsonb"`
+	OccurredAt  time.Time
+	PublishedAt *time.Time
+	Attempts    int
+	LastError   *string
+}
+
+func (record) TableName() string {
+	return "outbox"
+}
+
+// Append guarda los eventos en el outbox con la transacción tx, de modo que solo se
+// publican si el cambio que los generó se confirma
+func Append(tx *gorm.DB, list ...events.Event) error {
+	if len(list) == 0 {
+		return nil
+	}
+	records := make([]record, len(list))
+	for i, event := range list {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		records[i] = record{
+			EventId:    event.Id,
+			Type:       event.Type,
+			Entity:     event.Entity,
+			EntityId:   event.EntityId,
+			CityId:     event.CityId,
+			Payload:    payload,
+			OccurredAt: event.OccurredAt,
+		}
+	}
+	return tx.Create(&records).Error
+}
+
+// Entry es un evento del outbox con su posición; el id crece con cada evento y sirve
+// como cursor para leer los cambios en orden
+type Entry struct {
+	Id    uint64
+	Event events.Event
+}
+
+// After devuelve hasta limit eventos con id mayor que afterId, publicados o no
+func After(ctx context.Context, db *gorm.DB, afterId uint64, limit int) ([]Entry, error) {
+	var records []record
+	err := db.WithContext(ctx).Where("id > ?", afterId).Order("id").Limit(limit).Find(&records).Error
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]Entry, len(records))
+	for i, row := range records {
+		entries[i].Id = row.Id
+		if err := json.Unmarshal(row.Payload, &entries[i].Event); err != nil {
+			return nil, err
+		}
+	}
+	return entries, nil
+}
+
+// Latest devuelve los últimos limit eventos en orden ascendente
+func Latest(ctx context.Context, db *gorm.DB, limit int) ([]Entry, error) {
+	var last uint64
+	err := db.WithContext(ctx).Model(&record{}).Select("COALESCE(MAX(id), 0)").Scan(&last).Error
+	if err != nil {
+		return nil, err
+	}
+	return After(ctx, db, max(last, uint64(limit))-uint64(limit), limit)
+}
@@ -0,0 +1,109 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/safe_msvc_city/insfratructure/config"
+	"github.com/safe_msvc_city/insfratructure/events"
+	"github.com/safe_msvc_city/insfratructure/metrics"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// pruneInterval es cada cuánto se borran los eventos publicados más viejos que la retención
+const pruneInterval = time.Hour
+
+// Relay publica en el broker los eventos pendientes del outbox. Un evento se marca
+// como publicado solo después de que el broker lo confirma, así que puede entregarse
+// más de una vez (at-least-once) pero nunca se pierde. Las filas se toman con
+// FOR UPDATE SKIP LOCKED para que varias réplicas puedan correr el relay a la vez
+type Relay struct {
+	db        *gorm.DB
+	broker    events.Broker
+	cfg       config.Events
+	lastPrune time.Time
+}
+
+func NewRelay(db *gorm.DB, broker events.Broker, cfg config.Events) *Relay {
+	return &Relay{db: db, broker: broker, cfg: cfg}
+}
+
+// Run publica los eventos pendientes cada cfg.RelayInterval hasta que ctx termine
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.cfg.RelayInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.tick(ctx)
+		}
+	}
+}
+
+func (r *Relay) tick(ctx context.Context) {
+	for {
+		published, err := r.Flush(ctx)
+		if err != nil {
+			slog.Warn("outbox relay failed", "error", err)
+			return
+		}
+		if published < r.cfg.BatchSize {
+			break
+		}
+	}
+	if r.cfg.Retention > 0 && time.Since(r.lastPrune) > pruneInterval {
+		r.lastPrune = time.Now()
+		result := r.db.WithContext(ctx).
+			Where("published_at < ?", time.Now().Add(-r.cfg.Retention)).
+			Delete(&record{})
+		if result.Error != nil {
+			slog.Warn("outbox prune failed", "error", result.Error)
+		} else if result.RowsAffected > 0 {
+			slog.Info("outbox pruned", "events", result.RowsAffected)
+		}
+	}
+}
+
+// Flush publica un lote de eventos pendientes en orden y devuelve cuántos publicó. Se
+// detiene en el primer fallo para no adelantar eventos posteriores de la misma entidad
+func (r *Relay) Flush(ctx context.Context) (int, error) {
+	published := 0
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var pending []record
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("published_at IS NULL").
+			Order("id").
+			Limit(r.cfg.BatchSize).
+			Find(&pending).Error
+		if err != nil {
+			return err
+		}
+		for _, row := range pending {
+			var event events.Event
+			if err := json.Unmarshal(row.Payload, &event); err != nil {
+				return err
+			}
+			if err := r.broker.Publish(ctx, event); err != nil {
+				metrics.EventPublishFailed(event.Type)
+				message := err.Error()
+				slog.Warn("event publish failed", "event_id", event.Id, "type", event.Type, "attempts", row.Attempts+1, "error", err)
+				return tx.Model(&record{}).Where("id = ?", row.Id).Updates(map[string]any{
+					"attempts":   gorm.Expr("attempts + 1"),
+					"last_error": message,
+				}).Error
+			}
+			if err := tx.Model(&record{}).Where("id = ?", row.Id).Update("published_at", time.Now()).Error; err != nil {
+				return err
+			}
+			metrics.EventPublished(event.Type)
+			published++
+		}
+		return nil
+	})
+	return published, err
+}
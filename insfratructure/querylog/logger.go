@@ -0,0 +1,94 @@
+package querylog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/safe_msvc_city/insfratructure/logging"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+type callerKey struct{}
+
+// WithCaller marca el contexto con el método del repositorio que hace la consulta
+func WithCaller(ctx context.Context, caller string) context.Context {
+	return context.WithValue(ctx, callerKey{}, caller)
+}
+
+func callerFrom(ctx context.Context) string {
+	caller, _ := ctx.Value(callerKey{}).(string)
+	return caller
+}
+
+// DefaultStats acumula las consultas de todo el proceso; lo lee /admin/query-stats
+var DefaultStats = NewStats()
+
+// Logger implementa logger.Interface de GORM: escribe en slog las consultas que
+// superan el umbral y las que fallan, y agrega todas a las estadísticas
+type Logger struct {
+	threshold time.Duration
+	level     gormlogger.LogLevel
+	stats     *Stats
+}
+
+// New crea el logger de GORM con el umbral de consulta lenta indicado
+func New(threshold time.Duration, stats *Stats) *Logger {
+	return &Logger{threshold: threshold, level: gormlogger.Warn, stats: stats}
+}
+
+func (l *Logger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	copied := *l
+	copied.level = level
+	return &copied
+}
+
+func (l *Logger) Info(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= gormlogger.Info {
+		logging.FromContext(ctx).Info(fmt.Sprintf(msg, args...))
+	}
+}
+
+func (l *Logger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= gormlogger.Warn {
+		logging.FromContext(ctx).Warn(fmt.Sprintf(msg, args...))
+	}
+}
+
+func (l *Logger) Error(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= gormlogger.Error {
+		logging.FromContext(ctx).Error(fmt.Sprintf(msg, args...))
+	}
+}
+
+func (l *Logger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	duration := time.Since(begin)
+	sql, rows := fc()
+	normalized := Normalize(sql)
+	caller := callerFrom(ctx)
+	failed := err != nil && !errors.Is(err, gorm.ErrRecordNotFound)
+	slow := l.threshold > 0 && duration >= l.threshold
+	l.stats.Record(normalized, caller, duration, rows, failed, slow)
+
+	if l.level == gormlogger.Silent {
+		return
+	}
+	attrs := []any{
+		slog.String("sql", normalized),
+		slog.Float64("duration_ms", milliseconds(duration)),
+		slog.Int64("rows", rows),
+		slog.String("caller", caller),
+	}
+	logger := logging.FromContext(ctx)
+	switch {
+	case failed && l.level >= gormlogger.Error:
+		logger.Error("query failed", append(attrs, slog.Any("error", err))...)
+	case slow && l.level >= gormlogger.Warn:
+		logger.Warn("slow query", append(attrs, slog.Float64("threshold_ms", milliseconds(l.threshold)))...)
+	case l.level >= gormlogger.Info:
+		logger.Debug("query", attrs...)
+	}
+}
@@ -0,0 +1,27 @@
+package querylog
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	stringLiteral  = regexp.MustCompile(`'(?:[^']|'')*'`)
+	numberLiteral  = regexp.MustCompile(`\b\d+(?:\.\d+)?\b`)
+	placeholder    = regexp.MustCompile(`\$\d+`)
+	inList         = regexp.MustCompile(`(?i)\bIN\s*\((?:\s*\?\s*,)*\s*\?\s*\)`)
+	valuesList     = regexp.MustCompile(`(?i)\bVALUES\s*(?:\((?:[^()]*)\)\s*,?\s*)+`)
+	repeatedSpaces = regexp.MustCompile(`\s+`)
+)
+
+// Normalize reemplaza literales y parámetros por ? y colapsa listas IN y VALUES,
+// de modo que las consultas que solo difieren en sus valores se agrupen juntas
+func Normalize(sql string) string {
+	sql = stringLiteral.ReplaceAllString(sql, "?")
+	sql = placeholder.ReplaceAllString(sql, "?")
+	sql = numberLiteral.ReplaceAllString(sql, "?")
+	sql = inList.ReplaceAllString(sql, "IN (...)")
+	sql = valuesList.ReplaceAllString(sql, "VALUES (...) ")
+	sql = repeatedSpaces.ReplaceAllString(sql, " ")
+	return strings.TrimSpace(sql)
+}
@@ -0,0 +1,145 @@
+package querylog
+
+import (
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+// reservoirSize es la cantidad de latencias que se conservan por sentencia para
+// estimar los percentiles (muestreo de reservorio uniforme desde el arranque)
+const reservoirSize = 1024
+
+// StatementStats resume las ejecuciones de una sentencia normalizada
+type StatementStats struct {
+	Statement string   `json:"statement"`
+	Callers   []string `json:"callers"`
+	Count     int64    `json:"count"`
+	Errors    int64    `json:"errors"`
+	Slow      int64    `json:"slow"`
+	Rows      int64    `json:"rows"`
+	TotalMs   float64  `json:"total_ms"`
+	MeanMs    float64  `json:"mean_ms"`
+	MaxMs     float64  `json:"max_ms"`
+	P50Ms     float64  `json:"p50_ms"`
+	P95Ms     float64  `json:"p95_ms"`
+	P99Ms     float64  `json:"p99_ms"`
+}
+
+type statement struct {
+	callers map[string]struct{}
+	count   int64
+	errors  int64
+	slow    int64
+	rows    int64
+	total   time.Duration
+	max     time.Duration
+	samples []time.Duration
+}
+
+// Stats acumula las estadísticas por sentencia normalizada
+type Stats struct {
+	mux        sync.Mutex
+	statements map[string]*statement
+	rand       *rand.Rand
+	since      time.Time
+}
+
+// NewStats crea un acumulador vacío
+func NewStats() *Stats {
+	return &Stats{
+		statements: map[string]*statement{},
+		rand:       rand.New(rand.NewSource(time.Now().UnixNano())),
+		since:      time.Now(),
+	}
+}
+
+// Record agrega una ejecución
+func (s *Stats) Record(normalized string, caller string, duration time.Duration, rows int64, failed bool, slow bool) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	st, ok := s.statements[normalized]
+	if !ok {
+		st = &statement{callers: map[string]struct{}{}}
+		s.statements[normalized] = st
+	}
+	if caller != "" {
+		st.callers[caller] = struct{}{}
+	}
+	st.count++
+	if failed {
+		st.errors++
+	}
+	if slow {
+		st.slow++
+	}
+	if rows > 0 {
+		st.rows += rows
+	}
+	st.total += duration
+	st.max = max(st.max, duration)
+	if len(st.samples) < reservoirSize {
+		st.samples = append(st.samples, duration)
+	} else if i := s.rand.Int63n(st.count); i < reservoirSize {
+		st.samples[i] = duration
+	}
+}
+
+// Since devuelve el momento desde el que se acumulan estadísticas
+func (s *Stats) Since() time.Time {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return s.since
+}
+
+// Snapshot devuelve las estadísticas ordenadas por tiempo total descendente
+func (s *Stats) Snapshot() []StatementStats {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	result := make([]StatementStats, 0, len(s.statements))
+	for normalized, st := range s.statements {
+		samples := make([]time.Duration, len(st.samples))
+		copy(samples, st.samples)
+		sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+
+		callers := make([]string, 0, len(st.callers))
+		for caller := range st.callers {
+			callers = append(callers, caller)
+		}
+		sort.Strings(callers)
+
+		result = append(result, StatementStats{
+			Statement: normalized,
+			Callers:   callers,
+			Count:     st.count,
+			Errors:    st.errors,
+			Slow:      st.slow,
+			Rows:      st.rows,
+			TotalMs:   milliseconds(st.total),
+			MeanMs:    milliseconds(st.total / time.Duration(st.count)),
+			MaxMs:     milliseconds(st.max),
+			P50Ms:     milliseconds(percentile(samples, 0.50)),
+			P95Ms:     milliseconds(percentile(samples, 0.95)),
+			P99Ms:     milliseconds(percentile(samples, 0.99)),
+		})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].TotalMs > result[j].TotalMs })
+	return result
+}
+
+// percentile usa el método del rango más cercano sobre muestras ordenadas
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(p*float64(len(sorted))+0.5) - 1
+	rank = min(max(rank, 0), len(sorted)-1)
+	return sorted[rank]
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
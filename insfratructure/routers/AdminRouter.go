@@ -0,0 +1,15 @@
+package routers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/safe_msvc_city/handler"
+	"github.com/safe_msvc_city/insfratructure/middleware"
+)
+
+func NewAdminRouter(app *fiber.App) {
+	hadlerAdmin := handler.NewAdminHandler()
+	api := app.Group("/admin", middleware.ValidateToken, middleware.RequireRole("admin"))
+	api.Get("/query-stats", func(c *fiber.Ctx) error {
+		return hadlerAdmin.GetQueryStats(c)
+	})
+}
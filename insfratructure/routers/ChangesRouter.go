@@ -0,0 +1,14 @@
+package routers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/safe_msvc_city/handler"
+)
+
+func NewChangesRouter(app *fiber.App) {
+	hadlerChanges := handler.NewChangesHandler()
+	api := app.Group("/api/changes")
+	api.Get("/stream", func(c *fiber.Ctx) error {
+		return hadlerChanges.Stream(c)
+	})
+}
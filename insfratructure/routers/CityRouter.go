@@ -3,13 +3,11 @@ package routers
 import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/safe_msvc_city/handler"
-)
-
-var (
-	hadlerCity = handler.NewCityHandler()
+	"github.com/safe_msvc_city/insfratructure/middleware"
 )
 
 func NewCityRouter(app *fiber.App) {
+	hadlerCity := handler.NewCityHandler()
 	api := app.Group("/api/cities")
 	api.Get("/", func(c *fiber.Ctx) error {
 		return hadlerCity.GetCityFindAll(c)
@@ -17,6 +15,10 @@ func NewCityRouter(app *fiber.App) {
 		return hadlerCity.GetCityFindById(c)
 	}).Post("/", func(c *fiber.Ctx) error {
 		return hadlerCity.CreateCity(c)
+	}).Post("/bulk", func(c *fiber.Ctx) error {
+		return hadlerCity.BulkCities(c)
+	}).Post("/merge", middleware.ValidateToken, func(c *fiber.Ctx) error {
+		return hadlerCity.MergeCities(c)
 	}).Put("/:id", func(c *fiber.Ctx) error {
 		return hadlerCity.UpdateCity(c)
 	}).Delete("/:id", func(c *fiber.Ctx) error {
@@ -0,0 +1,14 @@
+package routers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/safe_msvc_city/handler"
+)
+
+func NewExportRouter(app *fiber.App) {
+	hadlerExport := handler.NewExportHandler()
+	api := app.Group("/api/export")
+	api.Get("/", func(c *fiber.Ctx) error {
+		return hadlerExport.Export(c)
+	})
+}
@@ -0,0 +1,20 @@
+package routers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/safe_msvc_city/handler"
+	"github.com/safe_msvc_city/insfratructure/middleware"
+)
+
+func NewHealthRouter(app *fiber.App) {
+	hadlerHealth := handler.NewHealthHandler()
+	app.Get("/healthz", func(c *fiber.Ctx) error {
+		return hadlerHealth.Liveness(c)
+	})
+	app.Get("/readyz", func(c *fiber.Ctx) error {
+		return hadlerHealth.Readiness(c)
+	})
+	app.Get("/health/details", middleware.ValidateToken, func(c *fiber.Ctx) error {
+		return hadlerHealth.Details(c)
+	})
+}
@@ -0,0 +1,17 @@
+package routers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/safe_msvc_city/handler"
+	"github.com/safe_msvc_city/insfratructure/middleware"
+)
+
+func NewImportRouter(app *fiber.App) {
+	hadlerImport := handler.NewImportHandler()
+	api := app.Group("/api/import", middleware.ValidateToken)
+	api.Post("/cities", func(c *fiber.Ctx) error {
+		return hadlerImport.ImportCities(c)
+	}).Post("/states", func(c *fiber.Ctx) error {
+		return hadlerImport.ImportStates(c)
+	})
+}
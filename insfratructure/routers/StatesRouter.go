@@ -3,13 +3,11 @@ package routers
 import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/safe_msvc_city/handler"
-)
-
-var (
-	hadlerStates = handler.NewStatesHandler()
+	"github.com/safe_msvc_city/insfratructure/middleware"
 )
 
 func NewStatesRouter(app *fiber.App) {
+	hadlerStates := handler.NewStatesHandler()
 	api := app.Group("/api/states")
 	api.Get("/", func(c *fiber.Ctx) error {
 		return hadlerStates.GetStatesFindAll(c)
@@ -17,8 +15,16 @@ func NewStatesRouter(app *fiber.App) {
 		return hadlerStates.GetStatesFindById(c)
 	}).Get("/city/:id", func(c *fiber.Ctx) error {
 		return hadlerStates.GetStatesFindByIdOfCity(c)
+	}).Get("/:id/moves", func(c *fiber.Ctx) error {
+		return hadlerStates.GetStateMoves(c)
 	}).Post("/", func(c *fiber.Ctx) error {
 		return hadlerStates.CreateState(c)
+	}).Post("/bulk", func(c *fiber.Ctx) error {
+		return hadlerStates.BulkStates(c)
+	}).Post("/merge", middleware.ValidateToken, func(c *fiber.Ctx) error {
+		return hadlerStates.MergeStates(c)
+	}).Post("/:id/move", func(c *fiber.Ctx) error {
+		return hadlerStates.MoveState(c)
 	}).Put("/:id", func(c *fiber.Ctx) error {
 		return hadlerStates.UpdateState(c)
 	}).Delete("/:id", func(c *fiber.Ctx) error {
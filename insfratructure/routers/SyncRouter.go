@@ -0,0 +1,14 @@
+package routers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/safe_msvc_city/handler"
+)
+
+func NewSyncRouter(app *fiber.App) {
+	hadlerSync := handler.NewSyncHandler()
+	api := app.Group("/api/sync")
+	api.Get("/", func(c *fiber.Ctx) error {
+		return hadlerSync.GetChanges(c)
+	})
+}
@@ -0,0 +1,30 @@
+package routers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/safe_msvc_city/handler"
+	"github.com/safe_msvc_city/insfratructure/middleware"
+)
+
+func NewWebhookRouter(app *fiber.App) {
+	hadlerWebhook := handler.NewWebhookHandler()
+	api := app.Group("/api/webhooks", middleware.ValidateToken)
+	admin := middleware.RequireRole("admin")
+	api.Get("/dead-letters", admin, func(c *fiber.Ctx) error {
+		return hadlerWebhook.GetDeadDeliveries(c)
+	}).Post("/deliveries/:id/replay", admin, func(c *fiber.Ctx) error {
+		return hadlerWebhook.ReplayDelivery(c)
+	}).Get("/", func(c *fiber.Ctx) error {
+		return hadlerWebhook.GetWebhookFindAll(c)
+	}).Get("/:id", func(c *fiber.Ctx) error {
+		return hadlerWebhook.GetWebhookFindById(c)
+	}).Get("/:id/deliveries", func(c *fiber.Ctx) error {
+		return hadlerWebhook.GetWebhookDeliveries(c)
+	}).Post("/", func(c *fiber.Ctx) error {
+		return hadlerWebhook.CreateWebhook(c)
+	}).Put("/:id", func(c *fiber.Ctx) error {
+		return hadlerWebhook.UpdateWebhook(c)
+	}).Delete("/:id", func(c *fiber.Ctx) error {
+		return hadlerWebhook.DeleteWebhook(c)
+	})
+}
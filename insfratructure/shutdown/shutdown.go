@@ -0,0 +1,43 @@
+package shutdown
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+)
+
+// Hook vacía o cierra un componente durante el apagado
+type Hook func(ctx context.Context) error
+
+type namedHook struct {
+	name string
+	hook Hook
+}
+
+var (
+	hooksMux sync.Mutex
+	hooks    []namedHook
+)
+
+// Register agrega un hook que se ejecuta al apagar el servicio, después de drenar
+// las peticiones HTTP y antes de cerrar la base de datos. Los componentes que
+// guardan eventos o auditoría en memoria lo usan para vaciar sus buffers
+func Register(name string, hook Hook) {
+	hooksMux.Lock()
+	defer hooksMux.Unlock()
+	hooks = append(hooks, namedHook{name: name, hook: hook})
+}
+
+// Run ejecuta los hooks en orden inverso al de registro; un error no detiene a los demás
+func Run(ctx context.Context) {
+	hooksMux.Lock()
+	pending := make([]namedHook, len(hooks))
+	copy(pending, hooks)
+	hooksMux.Unlock()
+
+	for i := len(pending) - 1; i >= 0; i-- {
+		if err := pending[i].hook(ctx); err != nil {
+			slog.Error("shutdown hook failed", "hook", pending[i].name, "error", err)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package snapshot
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	constants "github.com/flabio/safe_constants"
+	"github.com/gofiber/fiber/v2"
+	"github.com/safe_msvc_city/insfratructure/database"
+)
+
+// Encabezados de las respuestas servidas desde el snapshot
+const (
+	HeaderStale     = "X-Data-Stale"
+	HeaderStaleTime = "X-Data-Snapshot-Time"
+	staleWarning    = `110 - "Response is Stale"`
+)
+
+type staleKey struct{}
+
+// MarkStale indica que la respuesta de la petición se armó con el snapshot tomado en takenAt
+func MarkStale(ctx context.Context, takenAt time.Time) {
+	if stale, ok := ctx.Value(staleKey{}).(*atomic.Pointer[time.Time]); ok {
+		stale.Store(&takenAt)
+	}
+}
+
+// Middleware rechaza con 503 las escrituras mientras la base de datos no está
+// disponible y marca con Warning y X-Data-Stale las lecturas servidas desde el snapshot
+func Middleware(c *fiber.Ctx) error {
+	switch c.Method() {
+	case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions:
+	default:
+		if !database.Health().Healthy {
+			return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+				constants.STATUS:  fiber.StatusServiceUnavailable,
+				constants.MESSAGE: "la base de datos no está disponible, solo se permiten consultas",
+			})
+		}
+		return c.Next()
+	}
+
+	stale := &atomic.Pointer[time.Time]{}
+	c.SetUserContext(context.WithValue(c.UserContext(), staleKey{}, stale))
+	err := c.Next()
+	if takenAt := stale.Load(); takenAt != nil {
+		c.Set(fiber.HeaderWarning, staleWarning)
+		c.Set(HeaderStale, "true")
+		c.Set(HeaderStaleTime, takenAt.UTC().Format(time.RFC3339))
+	}
+	return err
+}
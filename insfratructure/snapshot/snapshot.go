@@ -0,0 +1,86 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/safe_msvc_city/insfratructure/entities"
+)
+
+// Snapshot es la última copia conocida del catálogo; se usa para responder lecturas
+// cuando la base de datos no está disponible
+type Snapshot struct {
+	TakenAt time.Time         `json:"taken_at"`
+	Cities  []entities.City   `json:"cities"`
+	States  []entities.States `json:"states"`
+}
+
+// Info resume el snapshot actual para /health
+type Info struct {
+	TakenAt time.Time `json:"taken_at"`
+	Cities  int       `json:"cities"`
+	States  int       `json:"states"`
+}
+
+var current atomic.Pointer[Snapshot]
+
+// Set reemplaza el snapshot actual
+func Set(snapshot *Snapshot) {
+	current.Store(snapshot)
+}
+
+// Current devuelve el snapshot actual; false si todavía no hay ninguno
+func Current() (*Snapshot, bool) {
+	snapshot := current.Load()
+	return snapshot, snapshot != nil
+}
+
+// Describe devuelve el resumen del snapshot actual; false si todavía no hay ninguno
+func Describe() (Info, bool) {
+	snapshot, ok := Current()
+	if !ok {
+		return Info{}, false
+	}
+	return Info{TakenAt: snapshot.TakenAt, Cities: len(snapshot.Cities), States: len(snapshot.States)}, true
+}
+
+// Save escribe el snapshot en path de forma atómica (archivo temporal y rename)
+func Save(path string, snapshot *Snapshot) error {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("no se pudo guardar el snapshot: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("no se pudo guardar el snapshot: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("no se pudo guardar el snapshot: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("no se pudo guardar el snapshot: %w", err)
+	}
+	return nil
+}
+
+// Load lee un snapshot guardado con Save
+func Load(path string) (*Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var snapshot Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("snapshot %s inválido: %w", path, err)
+	}
+	return &snapshot, nil
+}
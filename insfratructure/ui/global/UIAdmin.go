@@ -0,0 +1,7 @@
+package global
+
+import "github.com/gofiber/fiber/v2"
+
+type UIAdmin interface {
+	GetQueryStats(c *fiber.Ctx) error
+}
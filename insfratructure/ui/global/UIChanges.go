@@ -0,0 +1,7 @@
+package global
+
+import "github.com/gofiber/fiber/v2"
+
+type UIChanges interface {
+	Stream(c *fiber.Ctx) error
+}
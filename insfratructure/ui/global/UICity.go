@@ -8,4 +8,6 @@ type UICity interface {
 	CreateCity(c *fiber.Ctx) error
 	UpdateCity(c *fiber.Ctx) error
 	DeleteCity(c *fiber.Ctx) error
+	BulkCities(c *fiber.Ctx) error
+	MergeCities(c *fiber.Ctx) error
 }
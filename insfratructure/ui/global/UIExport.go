@@ -0,0 +1,7 @@
+package global
+
+import "github.com/gofiber/fiber/v2"
+
+type UIExport interface {
+	Export(c *fiber.Ctx) error
+}
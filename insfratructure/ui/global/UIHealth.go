@@ -0,0 +1,9 @@
+package global
+
+import "github.com/gofiber/fiber/v2"
+
+type UIHealth interface {
+	Liveness(c *fiber.Ctx) error
+	Readiness(c *fiber.Ctx) error
+	Details(c *fiber.Ctx) error
+}
@@ -0,0 +1,8 @@
+package global
+
+import "github.com/gofiber/fiber/v2"
+
+type UIImport interface {
+	ImportCities(c *fiber.Ctx) error
+	ImportStates(c *fiber.Ctx) error
+}
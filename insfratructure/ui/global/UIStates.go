@@ -9,4 +9,8 @@ type UIStates interface {
 	CreateState(c *fiber.Ctx) error
 	UpdateState(c *fiber.Ctx) error
 	DeleteState(c *fiber.Ctx) error
+	BulkStates(c *fiber.Ctx) error
+	MoveState(c *fiber.Ctx) error
+	GetStateMoves(c *fiber.Ctx) error
+	MergeStates(c *fiber.Ctx) error
 }
@@ -0,0 +1,14 @@
+package global
+
+import "github.com/gofiber/fiber/v2"
+
+type UIWebhook interface {
+	GetWebhookFindAll(c *fiber.Ctx) error
+	GetWebhookFindById(c *fiber.Ctx) error
+	CreateWebhook(c *fiber.Ctx) error
+	UpdateWebhook(c *fiber.Ctx) error
+	DeleteWebhook(c *fiber.Ctx) error
+	GetWebhookDeliveries(c *fiber.Ctx) error
+	GetDeadDeliveries(c *fiber.Ctx) error
+	ReplayDelivery(c *fiber.Ctx) error
+}
@@ -0,0 +1,40 @@
+package uicore
+
+import (
+	"context"
+
+	"github.com/safe_msvc_city/insfratructure/entities"
+)
+
+// Operaciones de los endpoints bulk
+const (
+	BulkCreate = "create"
+	BulkUpdate = "update"
+	BulkDelete = "delete"
+)
+
+// BulkCityOperation es una operación ya validada; en delete solo se usa City.Id
+type BulkCityOperation struct {
+	Op   string
+	City entities.City
+}
+
+// BulkStateOperation es una operación ya validada; en delete solo se usa State.Id
+type BulkStateOperation struct {
+	Op    string
+	State entities.States
+}
+
+type UIBulkCore interface {
+	GetCitiesByIds(ctx context.Context, ids []uint) ([]entities.City, error)
+	GetCitiesByNames(ctx context.Context, names []string) ([]entities.City, error)
+	GetStatesByIds(ctx context.Context, ids []uint) ([]entities.States, error)
+	GetStatesByNames(ctx context.Context, names []string) ([]entities.States, error)
+	// BulkCities aplica las operaciones y completa el id de las creadas. Con atomic un
+	// fallo revierte todo y se devuelve como error; sin atomic, si el lote falla se
+	// reintenta partido en mitades hasta aislar las operaciones que fallan y errs trae
+	// el fallo de cada una
+	BulkCities(ctx context.Context, ops []BulkCityOperation, atomic bool) (errs []error, err error)
+	// BulkStates es el equivalente de BulkCities para barrios
+	BulkStates(ctx context.Context, ops []BulkStateOperation, atomic bool) (errs []error, err error)
+}
@@ -1,12 +1,20 @@
 package uicore
 
-import "github.com/safe_msvc_city/insfratructure/entities"
+import (
+	"context"
+
+	"github.com/safe_msvc_city/insfratructure/entities"
+)
 
 type UICityCore interface {
-	GetCityFindAll() ([]entities.City, error)
-	GetCityFindById(id uint) (entities.City, error)
-	GetCityFindByName(id uint, name string) (bool, error)
-	CreateCity(city entities.City) (entities.City, error)
-	UpdateCity(id uint, city entities.City) (entities.City, error)
-	DeleteCity(id uint) (bool, error)
+	GetCityFindAll(ctx context.Context) ([]entities.City, error)
+	GetCityFindById(ctx context.Context, id uint) (entities.City, error)
+	GetCityFindByName(ctx context.Context, id uint, name string) (bool, error)
+	CreateCity(ctx context.Context, city entities.City) (entities.City, error)
+	UpdateCity(ctx context.Context, id uint, city entities.City) (entities.City, error)
+	DeleteCity(ctx context.Context, id uint) (bool, error)
+	// MergeCities pasa los barrios de source a target y deja source fusionada en target
+	MergeCities(ctx context.Context, sourceId uint, targetId uint) (entities.City, error)
+	// GetCityMergedInto devuelve la ciudad en la que se fusionó id, o cero
+	GetCityMergedInto(ctx context.Context, id uint) (uint, error)
 }
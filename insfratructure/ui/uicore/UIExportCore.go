@@ -0,0 +1,20 @@
+package uicore
+
+import (
+	"context"
+
+	"github.com/safe_msvc_city/insfratructure/entities"
+)
+
+// ExportFilter limita lo que se exporta; CityIds vacío no filtra por ciudad
+type ExportFilter struct {
+	ActiveOnly bool
+	CityIds    []uint
+}
+
+type UIExportCore interface {
+	// StreamCities llama a fn por cada ciudad leída del cursor, en orden de id
+	StreamCities(ctx context.Context, filter ExportFilter, fn func(entities.City) error) error
+	// StreamStates llama a fn por cada barrio con el nombre de su ciudad, en orden de id
+	StreamStates(ctx context.Context, filter ExportFilter, fn func(entities.StateExport) error) error
+}
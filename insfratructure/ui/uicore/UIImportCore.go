@@ -0,0 +1,18 @@
+package uicore
+
+import (
+	"context"
+
+	"github.com/safe_msvc_city/insfratructure/entities"
+)
+
+type UIImportCore interface {
+	// ApplyCities crea y actualiza las ciudades en una sola transacción
+	ApplyCities(ctx context.Context, created []entities.City, updated []entities.City) ([]entities.City, error)
+	// ApplyOfficialCities es ApplyCities escribiendo también código y departamento
+	ApplyOfficialCities(ctx context.Context, created []entities.City, updated []entities.City) ([]entities.City, error)
+	// ApplyGeoCities es ApplyCities escribiendo también los datos de GeoNames
+	ApplyGeoCities(ctx context.Context, created []entities.City, updated []entities.City) ([]entities.City, error)
+	// ApplyStates crea y actualiza los barrios en una sola transacción
+	ApplyStates(ctx context.Context, created []entities.States, updated []entities.States) ([]entities.States, error)
+}
@@ -1,13 +1,24 @@
 package uicore
 
-import "github.com/safe_msvc_city/insfratructure/entities"
+import (
+	"context"
+
+	"github.com/safe_msvc_city/insfratructure/entities"
+)
 
 type UIStatesCore interface {
-	GetStatesFindAll() ([]entities.States, error)
-	GetStatesFindById(id uint) (entities.States, error)
-	GetStatesFindByIdOfCity(id uint) ([]entities.States, error)
-	GetStatesFindByName(id uint, name string) (bool, error)
-	CreateStates(states entities.States) (entities.States, error)
-	UpdateStates(id uint, states entities.States) (entities.States, error)
-	DeleteStates(id uint) (bool, error)
+	GetStatesFindAll(ctx context.Context) ([]entities.States, error)
+	GetStatesFindById(ctx context.Context, id uint) (entities.States, error)
+	GetStatesFindByIdOfCity(ctx context.Context, id uint) ([]entities.States, error)
+	GetStatesFindByName(ctx context.Context, id uint, name string) (bool, error)
+	CreateStates(ctx context.Context, states entities.States) (entities.States, error)
+	UpdateStates(ctx context.Context, id uint, states entities.States) (entities.States, error)
+	DeleteStates(ctx context.Context, id uint) (bool, error)
+	// MoveState cambia el barrio a la ciudad cityId y guarda el cambio en el historial
+	MoveState(ctx context.Context, id uint, cityId uint, reason string) (entities.States, error)
+	GetStateMoves(ctx context.Context, id uint) ([]entities.StateMove, error)
+	// MergeStates deja source fusionado en target
+	MergeStates(ctx context.Context, sourceId uint, targetId uint) (entities.States, error)
+	// GetStateMergedInto devuelve el barrio en el que se fusionó id, o cero
+	GetStateMergedInto(ctx context.Context, id uint) (uint, error)
 }
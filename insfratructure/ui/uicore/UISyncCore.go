@@ -0,0 +1,17 @@
+package uicore
+
+import (
+	"context"
+	"time"
+
+	"github.com/safe_msvc_city/insfratructure/entities"
+)
+
+type UISyncCore interface {
+	// Now devuelve la hora de la base de datos, que es la referencia de los tokens
+	Now(ctx context.Context) (time.Time, error)
+	GetCitiesChangedSince(ctx context.Context, since time.Time) ([]entities.City, error)
+	GetStatesChangedSince(ctx context.Context, since time.Time) ([]entities.States, error)
+	GetDeletionsSince(ctx context.Context, since time.Time) ([]entities.Deletion, error)
+	PruneDeletions(ctx context.Context, before time.Time) (int64, error)
+}
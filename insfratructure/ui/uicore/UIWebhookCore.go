@@ -0,0 +1,25 @@
+package uicore
+
+import (
+	"context"
+	"time"
+
+	"github.com/safe_msvc_city/insfratructure/entities"
+)
+
+type UIWebhookCore interface {
+	GetWebhookFindAll(ctx context.Context) ([]entities.WebhookSubscription, error)
+	GetWebhookFindById(ctx context.Context, id uint) (entities.WebhookSubscription, error)
+	GetWebhookFindActive(ctx context.Context) ([]entities.WebhookSubscription, error)
+	CreateWebhook(ctx context.Context, subscription entities.WebhookSubscription) (entities.WebhookSubscription, error)
+	UpdateWebhook(ctx context.Context, id uint, subscription entities.WebhookSubscription) (entities.WebhookSubscription, error)
+	DeleteWebhook(ctx context.Context, id uint) (bool, error)
+
+	EnqueueDeliveries(ctx context.Context, deliveries []entities.WebhookDelivery) error
+	GetDeliveryFindById(ctx context.Context, id uint) (entities.WebhookDelivery, error)
+	GetDeliveriesBySubscription(ctx context.Context, subscriptionId uint, limit int) ([]entities.WebhookDelivery, error)
+	GetDeadDeliveries(ctx context.Context, limit int) ([]entities.WebhookDelivery, error)
+	ReplayDelivery(ctx context.Context, id uint) (bool, error)
+	ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]entities.WebhookDelivery, error)
+	RecordDeliveryAttempt(ctx context.Context, delivery entities.WebhookDelivery, attempt entities.WebhookAttempt) error
+}
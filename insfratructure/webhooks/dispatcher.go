@@ -0,0 +1,201 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/safe_msvc_city/insfratructure/config"
+	"github.com/safe_msvc_city/insfratructure/entities"
+	"github.com/safe_msvc_city/insfratructure/events"
+	"github.com/safe_msvc_city/insfratructure/metrics"
+	"github.com/safe_msvc_city/insfratructure/ui/uicore"
+)
+
+// Matches indica si la suscripción recibe el evento; las listas vacías aceptan todo
+func Matches(subscription entities.WebhookSubscription, event events.Event) bool {
+	if len(subscription.EventTypes) > 0 && !slices.Contains(subscription.EventTypes, event.Type) {
+		return false
+	}
+	if len(subscription.CityIds) > 0 && !slices.Contains(subscription.CityIds, event.CityId) {
+		return false
+	}
+	return true
+}
+
+// Enqueue devuelve el suscriptor del bus de eventos que crea una entrega pendiente
+// por cada suscripción que coincide con el evento
+func Enqueue(repository uicore.UIWebhookCore) events.Handler {
+	return func(ctx context.Context, event events.Event) error {
+		subscriptions, err := repository.GetWebhookFindActive(ctx)
+		if err != nil {
+			return err
+		}
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		var deliveries []entities.WebhookDelivery
+		for _, subscription := range subscriptions {
+			if !Matches(subscription, event) {
+				continue
+			}
+			deliveries = append(deliveries, entities.WebhookDelivery{
+				SubscriptionId: subscription.Id,
+				EventId:        event.Id,
+				EventType:      event.Type,
+				Payload:        payload,
+				Status:         entities.DeliveryPending,
+				NextAttemptAt:  time.Now(),
+			})
+		}
+		return repository.EnqueueDeliveries(ctx, deliveries)
+	}
+}
+
+// Worker envía las entregas pendientes y reprograma las fallidas con backoff exponencial
+type Worker struct {
+	repository uicore.UIWebhookCore
+	client     *http.Client
+	cfg        config.Webhooks
+}
+
+func NewWorker(repository uicore.UIWebhookCore, cfg config.Webhooks) *Worker {
+	return &Worker{
+		repository: repository,
+		client:     newClient(cfg.Timeout),
+		cfg:        cfg,
+	}
+}
+
+// newClient crea el cliente de las entregas: sin proxy, para que dialControl vea la IP
+// del partner, y con dialControl en cada conexión, incluidas las de las redirecciones
+func newClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout, Control: dialControl}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: timeout,
+			MaxIdleConnsPerHost: 2,
+		},
+	}
+}
+
+// Run envía las entregas vencidas cada cfg.Interval hasta que ctx termine
+func (w *Worker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.tick(ctx)
+		}
+	}
+}
+
+func (w *Worker) tick(ctx context.Context) {
+	for ctx.Err() == nil {
+		// La reserva cubre el envío de todo el lote aunque cada petición agote el timeout
+		lease := w.cfg.Timeout*time.Duration(w.cfg.BatchSize) + w.cfg.Interval
+		deliveries, err := w.repository.ClaimDueDeliveries(ctx, w.cfg.BatchSize, lease)
+		if err != nil {
+			slog.Warn("webhook claim failed", "error", err)
+			return
+		}
+		for _, delivery := range deliveries {
+			w.deliver(ctx, delivery)
+		}
+		if len(deliveries) < w.cfg.BatchSize {
+			return
+		}
+	}
+}
+
+func (w *Worker) deliver(ctx context.Context, delivery entities.WebhookDelivery) {
+	start := time.Now()
+	statusCode, err := w.send(ctx, delivery)
+	attempt := entities.WebhookAttempt{
+		DurationMs:  float64(time.Since(start).Microseconds()) / 1000,
+		AttemptedAt: start,
+	}
+	if statusCode > 0 {
+		attempt.StatusCode = &statusCode
+	}
+	if err != nil {
+		message := err.Error()
+		attempt.Error = &message
+	}
+
+	delivery.Attempts++
+	delivery.LastStatusCode = attempt.StatusCode
+	delivery.LastError = attempt.Error
+	result := "retry"
+	switch {
+	case err == nil:
+		result = "delivered"
+		delivery.Status = entities.DeliveryDelivered
+		delivery.DeliveredAt = &start
+	case delivery.Attempts >= w.cfg.MaxAttempts:
+		result = "dead"
+		delivery.Status = entities.DeliveryDead
+		slog.Warn("webhook delivery dead", "delivery_id", delivery.Id, "subscription_id", delivery.SubscriptionId, "attempts", delivery.Attempts, "error", err)
+	default:
+		delivery.Status = entities.DeliveryPending
+		delivery.NextAttemptAt = time.Now().Add(w.backoff(delivery.Attempts))
+	}
+	metrics.WebhookDelivery(result)
+	if err := w.repository.RecordDeliveryAttempt(ctx, delivery, attempt); err != nil {
+		slog.Error("webhook attempt not recorded", "delivery_id", delivery.Id, "error", err)
+	}
+}
+
+// backoff es la espera antes del intento attempts+1
+func (w *Worker) backoff(attempts int) time.Duration {
+	wait := w.cfg.InitialBackoff
+	for i := 1; i < attempts && wait < w.cfg.MaxBackoff; i++ {
+		wait *= 2
+	}
+	return min(wait, w.cfg.MaxBackoff)
+}
+
+// send hace el POST firmado; cualquier respuesta fuera de 2xx cuenta como fallo
+func (w *Worker) send(ctx context.Context, delivery entities.WebhookDelivery) (int, error) {
+	subscription := delivery.Subscription
+	if subscription.Id == 0 {
+		return 0, fmt.Errorf("la suscripción %d no existe", delivery.SubscriptionId)
+	}
+	timestamp := time.Now().Unix()
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.Url, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", "safe_msvc_city-webhooks")
+	request.Header.Set(HeaderEvent, delivery.EventType)
+	request.Header.Set(HeaderEventId, delivery.EventId)
+	request.Header.Set(HeaderDelivery, strconv.FormatUint(uint64(delivery.Id), 10))
+	request.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	request.Header.Set(HeaderSignature, Sign(subscription.Secret, timestamp, delivery.Payload))
+
+	response, err := w.client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(response.Body, 64<<10))
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return response.StatusCode, fmt.Errorf("respuesta %d del partner", response.StatusCode)
+	}
+	return response.StatusCode, nil
+}
@@ -0,0 +1,67 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"syscall"
+)
+
+// ErrForbiddenTarget se devuelve cuando la URL de una suscripción apunta a la propia
+// máquina, a la red interna o a metadatos de la nube (169.254.169.254)
+var ErrForbiddenTarget = errors.New("la url apunta a una dirección local, privada o de enlace local")
+
+// AllowedIP indica si se puede entregar a ip: descarta loopback, RFC 1918 y ULA,
+// enlace local, multicast y la dirección no especificada
+func AllowedIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast())
+}
+
+// CheckURL resuelve el host de rawURL y falla con ErrForbiddenTarget si alguna de sus
+// direcciones no está permitida. Es el filtro del registro; el envío vuelve a
+// comprobar la IP al conectar porque el DNS puede cambiar entre ambos momentos
+func CheckURL(ctx context.Context, rawURL string) error {
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	host := target.Hostname()
+	if strings.EqualFold(host, "localhost") || strings.HasSuffix(strings.ToLower(host), ".localhost") {
+		return ErrForbiddenTarget
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		if !AllowedIP(ip) {
+			return ErrForbiddenTarget
+		}
+		return nil
+	}
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return fmt.Errorf("no se pudo resolver %s: %w", host, err)
+	}
+	for _, addr := range addrs {
+		if !AllowedIP(addr.IP) {
+			return ErrForbiddenTarget
+		}
+	}
+	return nil
+}
+
+// dialControl rechaza la conexión si la IP ya resuelta no está permitida; cubre los
+// cambios de DNS posteriores al registro y las redirecciones
+func dialControl(network string, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !AllowedIP(ip) {
+		return fmt.Errorf("%w: %s", ErrForbiddenTarget, address)
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// verify es lo que hace el partner: recalcular la firma con su secreto y compararla
+func verify(secret string, timestamp string, body []byte, signature string) bool {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return hmac.Equal([]byte("sha256="+hex.EncodeToString(mac.Sum(nil))), []byte(signature))
+}
+
+func TestSign(t *testing.T) {
+	body := []byte(`{"type":"city.created"}`)
+	signature := Sign("secreto", 1700000000, body)
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp string
+		body      []byte
+		want      bool
+	}{
+		{"misma entrada", "secreto", "1700000000", body, true},
+		{"otro secreto", "otro", "1700000000", body, false},
+		{"otro timestamp", "secreto", "1700000001", body, false},
+		{"cuerpo alterado", "secreto", "1700000000", []byte(`{"type":"city.deleted"}`), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := verify(tt.secret, tt.timestamp, tt.body, signature); got != tt.want {
+				t.Errorf("verify = %v, se esperaba %v", got, tt.want)
+			}
+		})
+	}
+	if Sign("secreto", 1700000000, body) != signature {
+		t.Error("la firma no es determinista")
+	}
+}
+
+func TestNewSecret(t *testing.T) {
+	first, err := NewSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, _ := NewSecret()
+	if len(first) != 64 || first == second {
+		t.Errorf("secretos %q y %q, se esperaban 64 caracteres hex distintos", first, second)
+	}
+}
+
+func TestAllowedIP(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"0.0.0.0", false},
+		{"::", false},
+		{"224.0.0.1", false},
+		{"::ffff:127.0.0.1", false},
+		{"8.8.8.8", true},
+		{"172.32.0.1", true},
+		{"2001:4860:4860::8888", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			if got := AllowedIP(net.ParseIP(tt.ip)); got != tt.want {
+				t.Errorf("AllowedIP(%s) = %v, se esperaba %v", tt.ip, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckURL(t *testing.T) {
+	tests := []struct {
+		url       string
+		forbidden bool
+	}{
+		{"http://localhost/hook", true},
+		{"http://LOCALHOST:8080/hook", true},
+		{"http://api.localhost/hook", true},
+		{"http://127.0.0.1/hook", true},
+		{"http://[::1]:9000/hook", true},
+		{"http://169.254.169.254/latest/meta-data", true},
+		{"https://10.0.0.5/hook", true},
+		{"http://0.0.0.0/hook", true},
+		{"https://93.184.216.34/hook", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			err := CheckURL(context.Background(), tt.url)
+			if got := errors.Is(err, ErrForbiddenTarget); got != tt.forbidden {
+				t.Errorf("CheckURL(%s) = %v", tt.url, err)
+			}
+		})
+	}
+}
+
+func TestClientRejectsLocalTargets(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	_, err := newClient(time.Second).Post(server.URL, "application/json", nil)
+	if !errors.Is(err, ErrForbiddenTarget) {
+		t.Fatalf("error = %v, se esperaba ErrForbiddenTarget al conectar a %s", err, server.URL)
+	}
+}
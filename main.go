@@ -1,13 +1,11 @@
 package main
 
 import (
-	"github.com/gofiber/fiber/v2"
-	"github.com/safe_msvc_city/insfratructure/routers"
+	"os"
+
+	"github.com/safe_msvc_city/insfratructure/commands"
 )
 
 func main() {
-	app := fiber.New()
-	routers.NewCityRouter(app)
-	routers.NewStatesRouter(app)
-	app.Listen(":3014")
+	os.Exit(commands.Run(os.Args[1:]))
 }
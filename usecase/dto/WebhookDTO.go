@@ -0,0 +1,10 @@
+package dto
+
+type WebhookDTO struct {
+	Url string `json:"url"`
+	// Secret es opcional; si no se envía al crear se genera uno
+	Secret     string   `json:"secret"`
+	EventTypes []string `json:"event_types"`
+	CityIds    []uint   `json:"city_ids"`
+	Active     *bool    `json:"active"`
+}
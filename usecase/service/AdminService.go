@@ -0,0 +1,32 @@
+package service
+
+import (
+	constants "github.com/flabio/safe_constants"
+	"github.com/gofiber/fiber/v2"
+	"github.com/safe_msvc_city/insfratructure/querylog"
+	"github.com/safe_msvc_city/insfratructure/ui/global"
+)
+
+type adminService struct {
+	stats *querylog.Stats
+}
+
+func NewAdminService() global.UIAdmin {
+	return &adminService{stats: querylog.DefaultStats}
+}
+
+// GetQueryStats devuelve las estadísticas por sentencia normalizada desde el arranque,
+// ordenadas por tiempo total; ?limit=N limita el número de sentencias
+func (s *adminService) GetQueryStats(c *fiber.Ctx) error {
+	statements := s.stats.Snapshot()
+	if limit := c.QueryInt("limit"); limit > 0 && limit < len(statements) {
+		statements = statements[:limit]
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		constants.STATUS: fiber.StatusOK,
+		constants.DATA: fiber.Map{
+			"since":      s.stats.Since(),
+			"statements": statements,
+		},
+	})
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	constants "github.com/flabio/safe_constants"
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/safe_msvc_city/insfratructure/changes"
+	"github.com/safe_msvc_city/insfratructure/events"
+	"github.com/safe_msvc_city/insfratructure/logging"
+	"github.com/safe_msvc_city/insfratructure/outbox"
+	"github.com/safe_msvc_city/insfratructure/ui/global"
+)
+
+// Mensajes de validación del stream
+const (
+	changesEntityInvalid = "entity debe ser city o state"
+	changesCityInvalid   = "city_id debe ser un número positivo"
+	changesLastInvalid   = "Last-Event-ID debe ser un número"
+	changesUnavailable   = "el stream de cambios no está disponible"
+)
+
+type changesService struct {
+	hub *changes.Hub
+}
+
+func NewChangesService() global.UIChanges {
+	return &changesService{hub: changes.Default()}
+}
+
+// Stream envía por Server-Sent Events los cambios de ciudades y barrios. Acepta
+// ?entity=city|state y ?city_id=N, y reanuda desde Last-Event-ID (encabezado o
+// ?last_event_id) con los eventos que sigan en el buffer; si el id ya salió del
+// buffer envía un evento reset para que el cliente recargue el catálogo
+func (s *changesService) Stream(c *fiber.Ctx) error {
+	if s.hub == nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			constants.STATUS:  fiber.StatusServiceUnavailable,
+			constants.MESSAGE: changesUnavailable,
+		})
+	}
+	filter := changes.Filter{Entity: c.Query("entity")}
+	if filter.Entity != "" && filter.Entity != events.EntityCity && filter.Entity != events.EntityState {
+		return changesBadRequest(c, changesEntityInvalid)
+	}
+	if value := c.Query("city_id"); value != "" {
+		cityId, err := strconv.ParseUint(value, 10, 64)
+		if err != nil || cityId == 0 {
+			return changesBadRequest(c, changesCityInvalid)
+		}
+		filter.CityId = uint(cityId)
+	}
+	var lastId uint64
+	if value := c.Get("Last-Event-ID", c.Query("last_event_id")); value != "" {
+		id, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return changesBadRequest(c, changesLastInvalid)
+		}
+		lastId = id
+	}
+
+	subscription, replay, complete := s.hub.Subscribe(lastId, filter)
+	logger := logging.FromContext(c.UserContext())
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer s.hub.Unsubscribe(subscription)
+		fmt.Fprint(w, "retry: 3000\n\n")
+		if !complete {
+			fmt.Fprint(w, "event: reset\ndata: {}\n\n")
+		}
+		for _, entry := range replay {
+			if err := writeChange(w, entry); err != nil {
+				return
+			}
+		}
+		if err := w.Flush(); err != nil {
+			return
+		}
+		ticker := time.NewTicker(s.hub.Heartbeat())
+		defer ticker.Stop()
+		for {
+			select {
+			case entry, ok := <-subscription.C:
+				if !ok {
+					return
+				}
+				if err := writeChange(w, entry); err != nil {
+					return
+				}
+			case <-ticker.C:
+				fmt.Fprint(w, ": keep-alive\n\n")
+			}
+			if err := w.Flush(); err != nil {
+				logger.Debug("change stream closed", "error", err)
+				return
+			}
+		}
+	})
+	return nil
+}
+
+// writeChange escribe un evento SSE con el id del outbox y el tipo de evento
+func writeChange(w *bufio.Writer, entry outbox.Entry) error {
+	data, err := json.Marshal(entry.Event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", entry.Id, entry.Event.Type, data)
+	return err
+}
+
+func changesBadRequest(c *fiber.Ctx, msg string) error {
+	return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+		constants.STATUS:  fiber.StatusBadRequest,
+		constants.MESSAGE: msg,
+	})
+}
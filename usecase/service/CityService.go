@@ -1,9 +1,13 @@
 package service
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
+	"maps"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 
@@ -13,6 +17,9 @@ import (
 	"github.com/safe_msvc_city/core"
 	"github.com/safe_msvc_city/insfratructure/entities"
 	"github.com/safe_msvc_city/insfratructure/helpers"
+	"github.com/safe_msvc_city/insfratructure/logging"
+	"github.com/safe_msvc_city/insfratructure/metrics"
+	"github.com/safe_msvc_city/insfratructure/tracing"
 
 	"github.com/safe_msvc_city/insfratructure/ui/global"
 	"github.com/safe_msvc_city/insfratructure/ui/uicore"
@@ -20,18 +27,34 @@ import (
 )
 
 type cityService struct {
-	cityRepository uicore.UICityCore
+	cityRepository   uicore.UICityCore
+	statesRepository uicore.UIStatesCore
+	bulkRepository   uicore.UIBulkCore
 }
 
 func NewCityService() global.UICity {
 	return &cityService{
-		cityRepository: core.GetCityInstance(),
+		cityRepository:   core.GetCityInstance(),
+		statesRepository: core.GetStatesInstance(),
+		bulkRepository:   core.GetBulkInstance(),
 	}
 }
 
+const (
+	expandStates       = "states"
+	expandInvalid      = "expand solo admite states: %s"
+	nestedStateError   = "states[%d]: %s"
+	nestedStateRepeat  = "%s (igual que states[%d])"
+	nestedStateCityId  = "city_id no se indica en los barrios anidados; toman el de la ciudad"
+	nestedStatePending = uint(1)
+)
+
 func (s *cityService) GetCityFindAll(c *fiber.Ctx) error {
-	result, err := s.cityRepository.GetCityFindAll()
+	ctx, span := tracing.Start(c.UserContext(), "cityService.GetCityFindAll")
+	defer span.End()
+	result, err := s.cityRepository.GetCityFindAll(ctx)
 	if err != nil {
+		logging.FromContext(ctx).Error("cityService.GetCityFindAll failed", "error", err)
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			constants.STATUS:  fiber.StatusBadRequest,
 			constants.MESSAGE: constants.ERROR_QUERY,
@@ -42,45 +65,98 @@ func (s *cityService) GetCityFindAll(c *fiber.Ctx) error {
 		constants.DATA:   result,
 	})
 }
+
+// GetCityFindById devuelve la ciudad; con ?expand=states trae también sus barrios. Un id
+// fusionado en otra ciudad responde 301 hacia la ciudad vigente
 func (s *cityService) GetCityFindById(c *fiber.Ctx) error {
+	ctx, span := tracing.Start(c.UserContext(), "cityService.GetCityFindById")
+	defer span.End()
 	id, _ := strconv.Atoi(c.Params(constants.ID))
-	result, err := s.cityRepository.GetCityFindById(uint(id))
+	withStates, msg := parseCityExpand(c.Query("expand"))
+	if msg != constants.EMPTY {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			constants.STATUS:  http.StatusBadRequest,
+			constants.MESSAGE: msg,
+		})
+	}
+	result, err := s.cityRepository.GetCityFindById(ctx, uint(id))
 	if err != nil {
+		logging.FromContext(ctx).Error("cityService.GetCityFindById failed", "error", err)
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			constants.STATUS:  fiber.StatusBadRequest,
 			constants.MESSAGE: constants.ERROR_QUERY,
 		})
 	}
 	if result.Id == 0 {
+		target, err := s.cityRepository.GetCityMergedInto(ctx, uint(id))
+		if err != nil {
+			logging.FromContext(ctx).Error("cityService.GetCityFindById failed", "error", err)
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				constants.STATUS:  fiber.StatusBadRequest,
+				constants.MESSAGE: constants.ERROR_QUERY,
+			})
+		}
+		if target > 0 {
+			return mergedRedirect(c, target)
+		}
 		return c.Status(http.StatusNotFound).JSON(fiber.Map{
 			constants.STATUS:  http.StatusNotFound,
 			constants.MESSAGE: constants.ID_NO_EXIST,
 		})
 	}
+	if withStates {
+		states, err := s.statesRepository.GetStatesFindByIdOfCity(ctx, result.Id)
+		if err != nil {
+			logging.FromContext(ctx).Error("cityService.GetCityFindById failed", "error", err)
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				constants.STATUS:  fiber.StatusBadRequest,
+				constants.MESSAGE: constants.ERROR_QUERY,
+			})
+		}
+		result.States = &states
+	}
 
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		constants.STATUS: http.StatusOK,
 		constants.DATA:   result,
 	})
 }
+
+// CreateCity crea la ciudad y, si el cuerpo trae el arreglo states, sus barrios en la
+// misma transacción; la ciudad y todos los barrios se validan antes de escribir
 func (s *cityService) CreateCity(c *fiber.Ctx) error {
+	ctx, span := tracing.Start(c.UserContext(), "cityService.CreateCity")
+	defer span.End()
 	var cityCreate entities.City
 
-	cityDto, msgError := validateCity(0, s, c)
+	cityDto, msgError := validateCity(ctx, 0, s, c)
+	var states []entities.States
+	if msgError == "" {
+		states, msgError = validateNestedStates(ctx, s, c)
+	}
 	if msgError != "" {
+		recordValidationFailure(metrics.EntityCity, msgError)
 		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
 			constants.STATUS:  http.StatusBadRequest,
 			constants.MESSAGE: msgError,
 		})
 	}
 	deepcopier.Copy(cityDto).To(&cityCreate)
-	result, err := s.cityRepository.CreateCity(cityCreate)
+	if len(states) > 0 {
+		cityCreate.States = &states
+	}
+	result, err := s.cityRepository.CreateCity(ctx, cityCreate)
 	if err != nil {
+		logging.FromContext(ctx).Error("cityService.CreateCity failed", "error", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			constants.STATUS:  fiber.StatusInternalServerError,
 			constants.MESSAGE: constants.ERROR_CREATE,
 		})
 	}
+	metrics.CatalogueChanged(metrics.EntityCity, metrics.OperationCreated)
+	for range states {
+		metrics.CatalogueChanged(metrics.EntityState, metrics.OperationCreated)
+	}
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
 		constants.STATUS:  http.StatusCreated,
 		constants.DATA:    result,
@@ -89,16 +165,20 @@ func (s *cityService) CreateCity(c *fiber.Ctx) error {
 }
 
 func (s *cityService) UpdateCity(c *fiber.Ctx) error {
+	ctx, span := tracing.Start(c.UserContext(), "cityService.UpdateCity")
+	defer span.End()
 	id, _ := strconv.Atoi(c.Params(constants.ID))
-	cityDto, msgError := validateCity(uint(id), s, c)
+	cityDto, msgError := validateCity(ctx, uint(id), s, c)
 	if msgError != "" {
+		recordValidationFailure(metrics.EntityCity, msgError)
 		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
 			constants.STATUS:  http.StatusBadRequest,
 			constants.MESSAGE: msgError,
 		})
 	}
-	city, err := s.cityRepository.GetCityFindById(uint(id))
+	city, err := s.cityRepository.GetCityFindById(ctx, uint(id))
 	if err != nil {
+		logging.FromContext(ctx).Error("cityService.UpdateCity failed", "error", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			constants.STATUS:  fiber.StatusInternalServerError,
 			constants.MESSAGE: constants.ERROR_QUERY,
@@ -111,13 +191,15 @@ func (s *cityService) UpdateCity(c *fiber.Ctx) error {
 		})
 	}
 	deepcopier.Copy(cityDto).To(&city)
-	result, err := s.cityRepository.UpdateCity(uint(id), city)
+	result, err := s.cityRepository.UpdateCity(ctx, uint(id), city)
 	if err != nil {
+		logging.FromContext(ctx).Error("cityService.UpdateCity failed", "error", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			constants.STATUS:  fiber.StatusInternalServerError,
 			constants.MESSAGE: constants.ERROR_UPDATE,
 		})
 	}
+	metrics.CatalogueChanged(metrics.EntityCity, metrics.OperationUpdated)
 	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
 		constants.STATUS:  http.StatusAccepted,
 		constants.DATA:    result,
@@ -125,9 +207,12 @@ func (s *cityService) UpdateCity(c *fiber.Ctx) error {
 	})
 }
 func (s *cityService) DeleteCity(c *fiber.Ctx) error {
+	ctx, span := tracing.Start(c.UserContext(), "cityService.DeleteCity")
+	defer span.End()
 	id, _ := strconv.Atoi(c.Params(constants.ID))
-	city, err := s.cityRepository.GetCityFindById(uint(id))
+	city, err := s.cityRepository.GetCityFindById(ctx, uint(id))
 	if err != nil {
+		logging.FromContext(ctx).Error("cityService.DeleteCity failed", "error", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			constants.STATUS:  fiber.StatusInternalServerError,
 			constants.MESSAGE: constants.ERROR_QUERY,
@@ -139,13 +224,15 @@ func (s *cityService) DeleteCity(c *fiber.Ctx) error {
 			constants.MESSAGE: constants.ID_NO_EXIST,
 		})
 	}
-	result, err := s.cityRepository.DeleteCity(uint(id))
+	result, err := s.cityRepository.DeleteCity(ctx, uint(id))
 	if err != nil {
+		logging.FromContext(ctx).Error("cityService.DeleteCity failed", "error", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			constants.STATUS:  fiber.StatusInternalServerError,
 			constants.MESSAGE: constants.ERROR_DELETE,
 		})
 	}
+	metrics.CatalogueChanged(metrics.EntityCity, metrics.OperationDeleted)
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		constants.STATUS:  http.StatusOK,
 		constants.MESSAGE: constants.REMOVED,
@@ -153,7 +240,186 @@ func (s *cityService) DeleteCity(c *fiber.Ctx) error {
 	})
 }
 
-func validateCity(id uint, s *cityService, c *fiber.Ctx) (dto.CityDTO, string) {
+// BulkCities crea, actualiza y elimina ciudades en un lote. Todas las operaciones se
+// validan antes de escribir, con las reglas de validateCity y consultando ids y nombres
+// una sola vez para todo el lote. Con mode=all_or_nothing un error rechaza el lote
+// completo; con best_effort se aplican las válidas y cada resultado trae su error
+func (s *cityService) BulkCities(c *fiber.Ctx) error {
+	ctx, span := tracing.Start(c.UserContext(), "cityService.BulkCities")
+	defer span.End()
+	request, msg := parseBulk(c)
+	if msg != constants.EMPTY {
+		return bulkBadRequest(c, msg)
+	}
+	results := checkBulkShape(request)
+	cities := make([]entities.City, len(request.Operations))
+	var ids []uint
+	var names []string
+	deleted := map[uint]bool{}
+	for i, op := range request.Operations {
+		if results[i].failed() {
+			continue
+		}
+		cities[i].Id = op.Id
+		if op.Op != uicore.BulkCreate {
+			ids = append(ids, op.Id)
+		}
+		if op.Op == uicore.BulkDelete {
+			deleted[op.Id] = true
+			continue
+		}
+		cityDto, msg := validateBulkCity(op.Data)
+		if msg != constants.EMPTY {
+			recordValidationFailure(metrics.EntityCity, msg)
+			results[i].fail(http.StatusBadRequest, msg)
+			continue
+		}
+		cities[i].Name, cities[i].Active = cityDto.Name, cityDto.Active
+		names = append(names, cityDto.Name)
+	}
+
+	existing, err := s.bulkRepository.GetCitiesByIds(ctx, ids)
+	if err == nil {
+		var named []entities.City
+		named, err = s.bulkRepository.GetCitiesByNames(ctx, names)
+		existing = append(existing, named...)
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("cityService.BulkCities failed", "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			constants.STATUS:  fiber.StatusInternalServerError,
+			constants.MESSAGE: constants.ERROR_QUERY,
+		})
+	}
+	found := map[uint]bool{}
+	owners := map[string]uint{}
+	for _, city := range existing {
+		found[city.Id] = true
+		owners[city.Name] = city.Id
+	}
+	seen := map[string]int{}
+	for i, op := range request.Operations {
+		switch {
+		case results[i].failed():
+			continue
+		case op.Op != uicore.BulkCreate && !found[op.Id]:
+			results[i].fail(http.StatusNotFound, constants.ID_NO_EXIST)
+			continue
+		case op.Op == uicore.BulkDelete:
+			continue
+		}
+		name := cities[i].Name
+		if owner, ok := owners[name]; ok && owner != op.Id && !deleted[owner] {
+			recordValidationFailure(metrics.EntityCity, constants.NAME_ALREADY_EXIST)
+			results[i].fail(http.StatusBadRequest, constants.NAME_ALREADY_EXIST)
+		} else if previous, ok := seen[name]; ok {
+			recordValidationFailure(metrics.EntityCity, constants.NAME_ALREADY_EXIST)
+			results[i].fail(http.StatusBadRequest, fmt.Sprintf(bulkRepeatedName, constants.NAME_ALREADY_EXIST, previous))
+		}
+		seen[name] = i
+	}
+
+	var ops []uicore.BulkCityOperation
+	var index []int
+	for i, op := range request.Operations {
+		if !results[i].failed() {
+			ops = append(ops, uicore.BulkCityOperation{Op: op.Op, City: cities[i]})
+			index = append(index, i)
+		}
+	}
+	atomic := request.Mode == bulkAllOrNothing
+	if atomic && len(ops) < len(results) {
+		bulkRejectAll(results)
+		return bulkResponse(c, request.Mode, results)
+	}
+	if len(ops) == 0 {
+		return bulkResponse(c, request.Mode, results)
+	}
+	errs, err := s.bulkRepository.BulkCities(ctx, ops, atomic)
+	if err != nil {
+		logging.FromContext(ctx).Error("cityService.BulkCities failed", "error", err)
+		return bulkApplyFailed(c, results)
+	}
+	for j, i := range index {
+		if errs[j] != nil {
+			logging.FromContext(ctx).Error("cityService.BulkCities failed", "index", i, "error", errs[j])
+			results[i].fail(http.StatusInternalServerError, bulkErrorMessage(ops[j].Op))
+			continue
+		}
+		results[i].Status, results[i].Id = bulkStatus(ops[j].Op), ops[j].City.Id
+		metrics.CatalogueChanged(metrics.EntityCity, bulkOperationMetric(ops[j].Op))
+	}
+	return bulkResponse(c, request.Mode, results)
+}
+
+// MergeCities fusiona la ciudad source_id en target_id: sus barrios pasan a target_id y
+// source_id queda borrada con una redirección, de modo que GET /api/cities/<source_id>
+// responde 301 hacia target_id
+func (s *cityService) MergeCities(c *fiber.Ctx) error {
+	ctx, span := tracing.Start(c.UserContext(), "cityService.MergeCities")
+	defer span.End()
+	request, msg := parseMerge(c)
+	if msg != constants.EMPTY {
+		recordValidationFailure(metrics.EntityCity, msg)
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			constants.STATUS:  http.StatusBadRequest,
+			constants.MESSAGE: msg,
+		})
+	}
+	source, err := s.cityRepository.GetCityFindById(ctx, request.SourceId)
+	if err != nil {
+		logging.FromContext(ctx).Error("cityService.MergeCities failed", "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			constants.STATUS:  fiber.StatusInternalServerError,
+			constants.MESSAGE: constants.ERROR_QUERY,
+		})
+	}
+	if source.Id == 0 {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{
+			constants.STATUS:  http.StatusNotFound,
+			constants.MESSAGE: constants.ID_NO_EXIST,
+		})
+	}
+	target, err := s.cityRepository.GetCityFindById(ctx, request.TargetId)
+	if err != nil {
+		logging.FromContext(ctx).Error("cityService.MergeCities failed", "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			constants.STATUS:  fiber.StatusInternalServerError,
+			constants.MESSAGE: constants.ERROR_QUERY,
+		})
+	}
+	switch {
+	case target.Id == 0:
+		msg = fmt.Sprintf(mergeTargetNotExist, request.TargetId)
+	case !target.Active:
+		msg = fmt.Sprintf(mergeTargetInactive, request.TargetId)
+	}
+	if msg != constants.EMPTY {
+		recordValidationFailure(metrics.EntityCity, msg)
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			constants.STATUS:  http.StatusBadRequest,
+			constants.MESSAGE: msg,
+		})
+	}
+	result, err := s.cityRepository.MergeCities(ctx, source.Id, target.Id)
+	if err != nil {
+		logging.FromContext(ctx).Error("cityService.MergeCities failed", "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			constants.STATUS:  fiber.StatusInternalServerError,
+			constants.MESSAGE: constants.ERROR_UPDATE,
+		})
+	}
+	metrics.CatalogueChanged(metrics.EntityCity, metrics.OperationDeleted)
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		constants.STATUS:  http.StatusOK,
+		constants.DATA:    result,
+		constants.MESSAGE: fmt.Sprintf(mergeCityDone, source.Id, target.Id),
+	})
+}
+
+func validateCity(ctx context.Context, id uint, s *cityService, c *fiber.Ctx) (dto.CityDTO, string) {
+	ctx, span := tracing.Start(ctx, "validateCity")
+	defer span.End()
 	var cityDto dto.CityDTO
 	var msg string = ""
 	b := c.Body()
@@ -174,9 +440,92 @@ func validateCity(id uint, s *cityService, c *fiber.Ctx) (dto.CityDTO, string) {
 	if msgRequired != constants.EMPTY {
 		return dto.CityDTO{}, msgRequired
 	}
-	existName, _ := s.cityRepository.GetCityFindByName(id, cityDto.Name)
+	existName, _ := s.cityRepository.GetCityFindByName(ctx, id, cityDto.Name)
 	if existName {
 		msg = constants.NAME_ALREADY_EXIST
 	}
 	return cityDto, msg
 }
+
+// validateBulkCity aplica las reglas de validateCity a data; la unicidad del nombre se
+// revisa aparte para todo el lote
+func validateBulkCity(dataMap map[string]interface{}) (dto.CityDTO, string) {
+	if msg := helpers.ValidateFieldCity(dataMap); msg != constants.EMPTY {
+		return dto.CityDTO{}, msg
+	}
+	_, isName := dataMap[constants.NAME].(string)
+	_, isActive := dataMap[constants.ACTIVE].(bool)
+	if !isName || !isActive {
+		return dto.CityDTO{}, bulkInvalidData
+	}
+	var cityDto dto.CityDTO
+	helpers.MapToStruct(&cityDto, dataMap)
+	return cityDto, helpers.ValidateRequiredCity(cityDto)
+}
+
+// parseCityExpand lee ?expand; por ahora solo existe states
+func parseCityExpand(expand string) (bool, string) {
+	withStates := false
+	for _, value := range strings.Split(expand, ",") {
+		switch value = strings.TrimSpace(value); value {
+		case constants.EMPTY:
+		case expandStates:
+			withStates = true
+		default:
+			return false, fmt.Sprintf(expandInvalid, value)
+		}
+	}
+	return withStates, constants.EMPTY
+}
+
+// validateNestedStates valida el arreglo opcional states de POST /api/cities con las
+// reglas de validateState, incluido el nombre único entre todos los barrios
+func validateNestedStates(ctx context.Context, s *cityService, c *fiber.Ctx) ([]entities.States, string) {
+	var body struct {
+		States []map[string]interface{} `json:"states"`
+	}
+	if err := json.Unmarshal(c.Body(), &body); err != nil {
+		return nil, err.Error()
+	}
+	states := make([]entities.States, len(body.States))
+	names := make([]string, len(body.States))
+	seen := map[string]int{}
+	for i, dataMap := range body.States {
+		stateDto, msg := validateNestedState(dataMap)
+		if msg == constants.EMPTY {
+			if previous, ok := seen[strings.ToLower(stateDto.Name)]; ok {
+				msg = fmt.Sprintf(nestedStateRepeat, constants.NAME_ALREADY_EXIST, previous)
+			}
+		}
+		if msg != constants.EMPTY {
+			return nil, fmt.Sprintf(nestedStateError, i, msg)
+		}
+		seen[strings.ToLower(stateDto.Name)] = i
+		names[i] = stateDto.Name
+		states[i] = entities.States{Name: stateDto.Name, ZipCode: stateDto.ZipCode, Active: stateDto.Active}
+	}
+	existing, err := s.bulkRepository.GetStatesByNames(ctx, names)
+	if err != nil {
+		logging.FromContext(ctx).Error("validateNestedStates failed", "error", err)
+		return nil, constants.ERROR_QUERY
+	}
+	if len(existing) > 0 {
+		return nil, fmt.Sprintf(nestedStateError, seen[strings.ToLower(existing[0].Name)], constants.NAME_ALREADY_EXIST)
+	}
+	return states, constants.EMPTY
+}
+
+// validateNestedState aplica validateBulkState a un barrio anidado, que no trae city_id
+// porque toma el de la ciudad que se está creando
+func validateNestedState(dataMap map[string]interface{}) (dto.StatesDTO, string) {
+	if _, ok := dataMap[constants.CITY_ID]; ok {
+		return dto.StatesDTO{}, nestedStateCityId
+	}
+	// validateField exige city_id; se completa con un valor provisional que no se guarda
+	withCity := maps.Clone(dataMap)
+	if withCity == nil {
+		withCity = map[string]interface{}{}
+	}
+	withCity[constants.CITY_ID] = nestedStatePending
+	return validateBulkState(withCity)
+}
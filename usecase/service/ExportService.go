@@ -0,0 +1,302 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	constants "github.com/flabio/safe_constants"
+	"github.com/gofiber/fiber/v2"
+	"github.com/xuri/excelize/v2"
+
+	"github.com/safe_msvc_city/core"
+	"github.com/safe_msvc_city/insfratructure/entities"
+	"github.com/safe_msvc_city/insfratructure/logging"
+	"github.com/safe_msvc_city/insfratructure/tracing"
+	"github.com/safe_msvc_city/insfratructure/ui/global"
+	"github.com/safe_msvc_city/insfratructure/ui/uicore"
+)
+
+// Formatos de exportación
+const (
+	exportCsv    = "csv"
+	exportNdjson = "ndjson"
+	exportXlsx   = "xlsx"
+)
+
+// Entidades que se pueden exportar
+const (
+	exportCities = "cities"
+	exportStates = "states"
+)
+
+const (
+	exportFormatInvalid = "format debe ser csv, ndjson o xlsx"
+	exportEntityInvalid = "entity debe ser cities o states"
+	exportCityInvalid   = "city_id debe ser una lista de ids separados por comas"
+)
+
+// exportFlushEvery es cada cuántas filas se vacía el buffer hacia el cliente
+const exportFlushEvery = 500
+
+var (
+	cityExportHeader  = []string{"id", "name", "active", "created_at", "updated_at"}
+	stateExportHeader = []string{"id", "name", "zip_code", "city_id", "city_name", "active", "created_at", "updated_at"}
+)
+
+type exportService struct {
+	exportRepository uicore.UIExportCore
+}
+
+func NewExportService() global.UIExport {
+	return &exportService{
+		exportRepository: core.GetExportInstance(),
+	}
+}
+
+// exportRow convierte una fila en el registro del CSV y en las celdas del Excel, con
+// números y booleanos tipados; en NDJSON se serializa con sus etiquetas json
+type exportRow interface {
+	record() []string
+	cells() []interface{}
+}
+
+type cityExport entities.City
+
+func (c cityExport) record() []string {
+	return []string{
+		strconv.FormatUint(uint64(c.Id), 10),
+		c.Name,
+		strconv.FormatBool(c.Active),
+		c.CreatedAt.Format(time.RFC3339),
+		exportTime(c.UpdatedAt),
+	}
+}
+
+type stateExport entities.StateExport
+
+func (s stateExport) record() []string {
+	return []string{
+		strconv.FormatUint(uint64(s.Id), 10),
+		s.Name,
+		s.ZipCode,
+		strconv.FormatUint(uint64(s.CityId), 10),
+		s.CityName,
+		strconv.FormatBool(s.Active),
+		s.CreatedAt.Format(time.RFC3339),
+		exportTime(s.UpdatedAt),
+	}
+}
+
+func (c cityExport) cells() []interface{} {
+	return []interface{}{c.Id, c.Name, c.Active, c.CreatedAt, exportCellTime(c.UpdatedAt)}
+}
+
+func (s stateExport) cells() []interface{} {
+	return []interface{}{s.Id, s.Name, s.ZipCode, s.CityId, s.CityName, s.Active, s.CreatedAt, exportCellTime(s.UpdatedAt)}
+}
+
+func exportCellTime(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return *t
+}
+
+func exportTime(t *time.Time) string {
+	if t == nil {
+		return constants.EMPTY
+	}
+	return t.Format(time.RFC3339)
+}
+
+// Export descarga el catálogo en ?format=csv|ndjson|xlsx (csv por defecto).
+// ?entity=cities|states elige qué se exporta (states por defecto, con el nombre de la
+// ciudad), ?active=true deja solo los activos y ?city_id=1,2 limita a esas ciudades.
+// CSV y NDJSON se escriben mientras se lee el cursor; si la lectura falla a mitad el
+// archivo queda incompleto y el error solo se registra en el log. El Excel se arma
+// completo antes de responder
+func (s *exportService) Export(c *fiber.Ctx) error {
+	ctx, span := tracing.Start(c.UserContext(), "exportService.Export")
+	defer span.End()
+	format := strings.ToLower(c.Query("format", exportCsv))
+	if format != exportCsv && format != exportNdjson && format != exportXlsx {
+		return exportBadRequest(c, exportFormatInvalid)
+	}
+	entity := strings.ToLower(c.Query("entity", exportStates))
+	if entity != exportCities && entity != exportStates {
+		return exportBadRequest(c, exportEntityInvalid)
+	}
+	filter := uicore.ExportFilter{ActiveOnly: c.QueryBool("active")}
+	if value := c.Query("city_id"); value != constants.EMPTY {
+		for _, part := range strings.Split(value, ",") {
+			id, err := strconv.ParseUint(strings.TrimSpace(part), 10, 64)
+			if err != nil || id == 0 {
+				return exportBadRequest(c, exportCityInvalid)
+			}
+			filter.CityIds = append(filter.CityIds, uint(id))
+		}
+	}
+
+	stream := s.streamer(entity, filter)
+	header := stateExportHeader
+	if entity == exportCities {
+		header = cityExportHeader
+	}
+	filename := fmt.Sprintf("%s-%s.%s", entity, time.Now().Format("20060102"), format)
+	disposition := fmt.Sprintf(`attachment; filename="%s"`, filename)
+
+	if format == exportXlsx {
+		file, err := exportWorkbook(ctx, entity, header, stream)
+		if err != nil {
+			logging.FromContext(ctx).Error("exportService.Export failed", "error", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				constants.STATUS:  fiber.StatusInternalServerError,
+				constants.MESSAGE: constants.ERROR_QUERY,
+			})
+		}
+		defer file.Close()
+		c.Set(fiber.HeaderContentType, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+		c.Set(fiber.HeaderContentDisposition, disposition)
+		_, err = file.WriteTo(c.Response().BodyWriter())
+		return err
+	}
+
+	logger := logging.FromContext(ctx)
+	if format == exportNdjson {
+		c.Set(fiber.HeaderContentType, "application/x-ndjson")
+	} else {
+		c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+	}
+	c.Set(fiber.HeaderContentDisposition, disposition)
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		var err error
+		if format == exportNdjson {
+			err = exportNdjsonRows(ctx, w, stream)
+		} else {
+			err = exportCsvRows(ctx, w, header, stream)
+		}
+		if err != nil {
+			logger.Error("exportService.Export failed", "error", err)
+		}
+	})
+	return nil
+}
+
+// exportStream recorre las filas de la entidad elegida
+type exportStream func(ctx context.Context, fn func(exportRow) error) error
+
+func (s *exportService) streamer(entity string, filter uicore.ExportFilter) exportStream {
+	if entity == exportCities {
+		return func(ctx context.Context, fn func(exportRow) error) error {
+			return s.exportRepository.StreamCities(ctx, filter, func(city entities.City) error {
+				return fn(cityExport(city))
+			})
+		}
+	}
+	return func(ctx context.Context, fn func(exportRow) error) error {
+		return s.exportRepository.StreamStates(ctx, filter, func(state entities.StateExport) error {
+			return fn(stateExport(state))
+		})
+	}
+}
+
+func exportCsvRows(ctx context.Context, w *bufio.Writer, header []string, stream exportStream) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	rows := 0
+	err := stream(ctx, func(row exportRow) error {
+		if err := writer.Write(row.record()); err != nil {
+			return err
+		}
+		return exportFlush(w, writer.Flush, &rows)
+	})
+	writer.Flush()
+	if err != nil {
+		return err
+	}
+	return writer.Error()
+}
+
+func exportNdjsonRows(ctx context.Context, w *bufio.Writer, stream exportStream) error {
+	encoder := json.NewEncoder(w)
+	rows := 0
+	err := stream(ctx, func(row exportRow) error {
+		if err := encoder.Encode(row); err != nil {
+			return err
+		}
+		return exportFlush(w, func() {}, &rows)
+	})
+	if err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// exportFlush envía lo escrito al cliente cada exportFlushEvery filas; un error aquí
+// suele ser que el cliente cerró la conexión y detiene la lectura del cursor
+func exportFlush(w *bufio.Writer, flush func(), rows *int) error {
+	*rows++
+	if *rows%exportFlushEvery != 0 {
+		return nil
+	}
+	flush()
+	return w.Flush()
+}
+
+// exportWorkbook escribe las filas con el StreamWriter de excelize, que las guarda en
+// disco en lugar de mantener todas las celdas en memoria
+func exportWorkbook(ctx context.Context, sheet string, header []string, stream exportStream) (*excelize.File, error) {
+	file := excelize.NewFile()
+	if err := file.SetSheetName("Sheet1", sheet); err != nil {
+		file.Close()
+		return nil, err
+	}
+	writer, err := file.NewStreamWriter(sheet)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	line := 1
+	writeRow := func(cells []interface{}) error {
+		cell, err := excelize.CoordinatesToCellName(1, line)
+		if err != nil {
+			return err
+		}
+		line++
+		return writer.SetRow(cell, cells)
+	}
+	titles := make([]interface{}, len(header))
+	for i, title := range header {
+		titles[i] = title
+	}
+	if err := writeRow(titles); err != nil {
+		file.Close()
+		return nil, err
+	}
+	err = stream(ctx, func(row exportRow) error {
+		return writeRow(row.cells())
+	})
+	if err == nil {
+		err = writer.Flush()
+	}
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return file, nil
+}
+
+func exportBadRequest(c *fiber.Ctx, msg string) error {
+	return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+		constants.STATUS:  fiber.StatusBadRequest,
+		constants.MESSAGE: msg,
+	})
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"time"
+
+	constants "github.com/flabio/safe_constants"
+	"github.com/gofiber/fiber/v2"
+	"github.com/safe_msvc_city/insfratructure/database"
+	"github.com/safe_msvc_city/insfratructure/health"
+	"github.com/safe_msvc_city/insfratructure/snapshot"
+	"github.com/safe_msvc_city/insfratructure/ui/global"
+)
+
+// checkTimeout es el tiempo máximo de cada chequeo de dependencias
+const checkTimeout = 2 * time.Second
+
+type healthService struct{}
+
+func NewHealthService() global.UIHealth {
+	return &healthService{}
+}
+
+// Liveness responde 200 mientras el proceso esté vivo
+func (s *healthService) Liveness(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		constants.STATUS: fiber.StatusOK,
+		"alive":          true,
+	})
+}
+
+// Readiness responde 200 solo si el servicio terminó de arrancar y todas las
+// dependencias registradas están disponibles
+func (s *healthService) Readiness(c *fiber.Ctx) error {
+	if !health.Ready() {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			constants.STATUS: fiber.StatusServiceUnavailable,
+			"ready":          false,
+		})
+	}
+	results := health.Run(c.UserContext(), checkTimeout)
+	status := fiber.StatusOK
+	if !health.Healthy(results) {
+		status = fiber.StatusServiceUnavailable
+	}
+	return c.Status(status).JSON(fiber.Map{
+		constants.STATUS: status,
+		"ready":          status == fiber.StatusOK,
+		"checks":         results,
+	})
+}
+
+// Details devuelve el estado de cada dependencia, las estadísticas del pool y la versión
+func (s *healthService) Details(c *fiber.Ctx) error {
+	results := health.Run(c.UserContext(), checkTimeout)
+	version, commit := health.BuildInfo()
+	data := fiber.Map{
+		"ready":    health.Ready() && health.Healthy(results),
+		"checks":   results,
+		"database": database.Health(),
+		"version":  version,
+		"commit":   commit,
+		"uptime":   health.Uptime().Round(time.Second).String(),
+	}
+	if info, ok := snapshot.Describe(); ok {
+		data["snapshot"] = info
+	}
+	if stats, ok := database.Stats(); ok {
+		data["pool"] = fiber.Map{
+			"max_open_connections": stats.MaxOpenConnections,
+			"open_connections":     stats.OpenConnections,
+			"in_use":               stats.InUse,
+			"idle":                 stats.Idle,
+			"wait_count":           stats.WaitCount,
+			"wait_duration":        stats.WaitDuration.String(),
+			"max_idle_closed":      stats.MaxIdleClosed,
+			"max_idle_time_closed": stats.MaxIdleTimeClosed,
+			"max_lifetime_closed":  stats.MaxLifetimeClosed,
+		}
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		constants.STATUS: fiber.StatusOK,
+		constants.DATA:   data,
+	})
+}
@@ -0,0 +1,560 @@
+package service
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	constants "github.com/flabio/safe_constants"
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/safe_msvc_city/core"
+	"github.com/safe_msvc_city/insfratructure/entities"
+	"github.com/safe_msvc_city/insfratructure/helpers"
+	"github.com/safe_msvc_city/insfratructure/logging"
+	"github.com/safe_msvc_city/insfratructure/metrics"
+	"github.com/safe_msvc_city/insfratructure/tracing"
+	"github.com/safe_msvc_city/insfratructure/ui/global"
+	"github.com/safe_msvc_city/insfratructure/ui/uicore"
+	"github.com/safe_msvc_city/usecase/dto"
+)
+
+// Columnas que entiende la importación además de las de la entidad: id para actualizar
+// por id y city para resolver la ciudad de un barrio por nombre
+const (
+	importColumnId   = "id"
+	importColumnCity = "city"
+)
+
+// Acciones de cada fila en el reporte
+const (
+	importCreate = "create"
+	importUpdate = "update"
+	importSkip   = "skip"
+	importError  = "error"
+)
+
+const (
+	importHasErrors     = "la importación tiene errores; no se aplicó ningún cambio"
+	importApplied       = "importación aplicada"
+	importDryRun        = "simulación; no se aplicó ningún cambio"
+	importEmptyFile     = "el archivo CSV está vacío"
+	importInvalidColumn = "columns debe tener el formato campo:Cabecera separado por comas"
+)
+
+var (
+	cityImportFields  = []string{importColumnId, constants.NAME, constants.ACTIVE}
+	stateImportFields = []string{importColumnId, constants.NAME, constants.ZIP_CODE, constants.ACTIVE, constants.CITY_ID, importColumnCity}
+)
+
+type importService struct {
+	cityRepository   uicore.UICityCore
+	statesRepository uicore.UIStatesCore
+	importRepository uicore.UIImportCore
+}
+
+func NewImportService() global.UIImport {
+	return &importService{
+		cityRepository:   core.GetCityInstance(),
+		statesRepository: core.GetStatesInstance(),
+		importRepository: core.GetImportInstance(),
+	}
+}
+
+// importRow es el resultado de una fila del CSV; line es la línea del archivo
+type importRow struct {
+	Line   int      `json:"line"`
+	Action string   `json:"action"`
+	Id     uint     `json:"id,omitempty"`
+	Name   string   `json:"name"`
+	Errors []string `json:"errors,omitempty"`
+}
+
+type importReport struct {
+	DryRun  bool        `json:"dry_run"`
+	Applied bool        `json:"applied"`
+	Total   int         `json:"total"`
+	Create  int         `json:"create"`
+	Update  int         `json:"update"`
+	Skip    int         `json:"skip"`
+	Invalid int         `json:"invalid"`
+	Rows    []importRow `json:"rows"`
+}
+
+func (r *importReport) add(row importRow) {
+	r.Total++
+	switch row.Action {
+	case importCreate:
+		r.Create++
+	case importUpdate:
+		r.Update++
+	case importSkip:
+		r.Skip++
+	default:
+		r.Invalid++
+	}
+	r.Rows = append(r.Rows, row)
+}
+
+func (row *importRow) fail(msg string) {
+	row.Action = importError
+	row.Errors = append(row.Errors, msg)
+}
+
+// importRecord es una fila del CSV con los valores ya traducidos a los campos de la
+// entidad; solo contiene las columnas presentes en la cabecera
+type importRecord struct {
+	line   int
+	values map[string]string
+}
+
+func (r importRecord) value(field string) (string, bool) {
+	value, ok := r.values[field]
+	return strings.TrimSpace(value), ok
+}
+
+// ImportCities crea o actualiza ciudades desde un CSV. Una fila con id actualiza esa
+// ciudad; sin id se busca por nombre y, si no existe, se crea. Las filas sin cambios se
+// omiten. Con ?dry_run=true solo devuelve el reporte; si alguna fila es inválida no se
+// aplica nada y todo lo demás se aplica en una sola transacción
+func (s *importService) ImportCities(c *fiber.Ctx) error {
+	ctx, span := tracing.Start(c.UserContext(), "importService.ImportCities")
+	defer span.End()
+	records, msg := readImportCsv(c, cityImportFields)
+	if msg != constants.EMPTY {
+		return importBadRequest(c, msg)
+	}
+	cities, err := s.cityRepository.GetCityFindAll(ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("importService.ImportCities failed", "error", err)
+		return importQueryFailed(c)
+	}
+	byId := map[uint]entities.City{}
+	byName := map[string]uint{}
+	for _, city := range cities {
+		byId[city.Id] = city
+		byName[city.Name] = city.Id
+	}
+
+	report := importReport{DryRun: c.QueryBool("dry_run"), Rows: []importRow{}}
+	var created, updated []entities.City
+	var createdRows []int
+	names := map[string]int{}
+	touched := map[uint]int{}
+	for _, record := range records {
+		row := importRow{Line: record.line}
+		dataMap := map[string]interface{}{}
+		if name, ok := record.value(constants.NAME); ok {
+			dataMap[constants.NAME] = name
+			row.Name = name
+		}
+		if active, ok := record.value(constants.ACTIVE); ok && active != constants.EMPTY {
+			if value, valid := parseImportBool(active); valid {
+				dataMap[constants.ACTIVE] = value
+			} else {
+				row.fail(fmt.Sprintf("active no es un valor válido: %s", active))
+			}
+		}
+		id, msg := parseImportId(record)
+		if msg != constants.EMPTY {
+			row.fail(msg)
+		}
+		if msg := validateImportCity(dataMap); msg != constants.EMPTY {
+			row.fail(msg)
+		}
+		if row.Action == importError {
+			report.add(row)
+			continue
+		}
+
+		city := entities.City{Name: row.Name, Active: dataMap[constants.ACTIVE].(bool)}
+		if id == 0 {
+			id = byName[city.Name]
+		} else if _, ok := byId[id]; !ok {
+			row.fail(constants.ID_NO_EXIST)
+		} else if owner, ok := byName[city.Name]; ok && owner != id {
+			row.fail(constants.NAME_ALREADY_EXIST)
+		}
+		if line, ok := names[city.Name]; ok {
+			row.fail(fmt.Sprintf("%s (línea %d)", constants.NAME_ALREADY_EXIST, line))
+		}
+		if line, ok := touched[id]; ok && id > 0 {
+			row.fail(fmt.Sprintf("la ciudad %d ya se importa en la línea %d", id, line))
+		}
+		names[city.Name] = record.line
+		touched[id] = record.line
+		if row.Action == importError {
+			report.add(row)
+			continue
+		}
+
+		row.Id = id
+		switch previous := byId[id]; {
+		case id == 0:
+			row.Action = importCreate
+			created = append(created, city)
+			createdRows = append(createdRows, len(report.Rows))
+		case previous.Name == city.Name && previous.Active == city.Active:
+			row.Action = importSkip
+		default:
+			row.Action = importUpdate
+			city.Id = id
+			updated = append(updated, city)
+		}
+		report.add(row)
+	}
+
+	if report.DryRun || report.Invalid > 0 {
+		return importResult(c, report)
+	}
+	result, err := s.importRepository.ApplyCities(ctx, created, updated)
+	if err != nil {
+		logging.FromContext(ctx).Error("importService.ImportCities failed", "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			constants.STATUS:  fiber.StatusInternalServerError,
+			constants.MESSAGE: constants.ERROR_CREATE,
+		})
+	}
+	for i, index := range createdRows {
+		report.Rows[index].Id = result[i].Id
+	}
+	report.Applied = true
+	importMetrics(metrics.EntityCity, report)
+	return importResult(c, report)
+}
+
+// ImportStates crea o actualiza barrios desde un CSV igual que ImportCities. La ciudad
+// se toma de city_id o, si falta, de la columna city con el nombre de la ciudad
+func (s *importService) ImportStates(c *fiber.Ctx) error {
+	ctx, span := tracing.Start(c.UserContext(), "importService.ImportStates")
+	defer span.End()
+	records, msg := readImportCsv(c, stateImportFields)
+	if msg != constants.EMPTY {
+		return importBadRequest(c, msg)
+	}
+	cities, err := s.cityRepository.GetCityFindAll(ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("importService.ImportStates failed", "error", err)
+		return importQueryFailed(c)
+	}
+	states, err := s.statesRepository.GetStatesFindAll(ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("importService.ImportStates failed", "error", err)
+		return importQueryFailed(c)
+	}
+	cityIds := map[uint]bool{}
+	cityNames := map[string]uint{}
+	for _, city := range cities {
+		cityIds[city.Id] = true
+		cityNames[strings.ToLower(city.Name)] = city.Id
+	}
+	byId := map[uint]entities.States{}
+	byName := map[string]uint{}
+	for _, state := range states {
+		byId[state.Id] = state
+		byName[strings.ToLower(state.Name)] = state.Id
+	}
+
+	report := importReport{DryRun: c.QueryBool("dry_run"), Rows: []importRow{}}
+	var created, updated []entities.States
+	var createdRows []int
+	names := map[string]int{}
+	touched := map[uint]int{}
+	for _, record := range records {
+		row := importRow{Line: record.line}
+		dataMap := map[string]interface{}{}
+		if name, ok := record.value(constants.NAME); ok {
+			dataMap[constants.NAME] = name
+			row.Name = name
+		}
+		if zipCode, ok := record.value(constants.ZIP_CODE); ok {
+			dataMap[constants.ZIP_CODE] = zipCode
+		}
+		if active, ok := record.value(constants.ACTIVE); ok && active != constants.EMPTY {
+			if value, valid := parseImportBool(active); valid {
+				dataMap[constants.ACTIVE] = value
+			} else {
+				row.fail(fmt.Sprintf("active no es un valor válido: %s", active))
+			}
+		}
+		if cityId, msg := resolveImportCity(record, cityIds, cityNames); msg != constants.EMPTY {
+			row.fail(msg)
+		} else if cityId > 0 {
+			dataMap[constants.CITY_ID] = cityId
+		}
+		id, msg := parseImportId(record)
+		if msg != constants.EMPTY {
+			row.fail(msg)
+		}
+		stateDto, msg := validateImportState(dataMap)
+		if msg != constants.EMPTY {
+			row.fail(msg)
+		}
+		if row.Action == importError {
+			report.add(row)
+			continue
+		}
+
+		state := entities.States{
+			Name:    stateDto.Name,
+			ZipCode: stateDto.ZipCode,
+			CityId:  stateDto.CityId,
+			Active:  stateDto.Active,
+		}
+		key := strings.ToLower(state.Name)
+		if id == 0 {
+			id = byName[key]
+		} else if _, ok := byId[id]; !ok {
+			row.fail(constants.ID_NO_EXIST)
+		} else if owner, ok := byName[key]; ok && owner != id {
+			row.fail(constants.NAME_ALREADY_EXIST)
+		}
+		if line, ok := names[key]; ok {
+			row.fail(fmt.Sprintf("%s (línea %d)", constants.NAME_ALREADY_EXIST, line))
+		}
+		if line, ok := touched[id]; ok && id > 0 {
+			row.fail(fmt.Sprintf("el barrio %d ya se importa en la línea %d", id, line))
+		}
+		names[key] = record.line
+		touched[id] = record.line
+		if row.Action == importError {
+			report.add(row)
+			continue
+		}
+
+		row.Id = id
+		switch previous := byId[id]; {
+		case id == 0:
+			row.Action = importCreate
+			created = append(created, state)
+			createdRows = append(createdRows, len(report.Rows))
+		case previous.Name == state.Name && previous.ZipCode == state.ZipCode &&
+			previous.CityId == state.CityId && previous.Active == state.Active:
+			row.Action = importSkip
+		default:
+			row.Action = importUpdate
+			state.Id = id
+			updated = append(updated, state)
+		}
+		report.add(row)
+	}
+
+	if report.DryRun || report.Invalid > 0 {
+		return importResult(c, report)
+	}
+	result, err := s.importRepository.ApplyStates(ctx, created, updated)
+	if err != nil {
+		logging.FromContext(ctx).Error("importService.ImportStates failed", "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			constants.STATUS:  fiber.StatusInternalServerError,
+			constants.MESSAGE: constants.ERROR_CREATE,
+		})
+	}
+	for i, index := range createdRows {
+		report.Rows[index].Id = result[i].Id
+	}
+	report.Applied = true
+	importMetrics(metrics.EntityState, report)
+	return importResult(c, report)
+}
+
+// validateImportCity aplica a una fila las mismas reglas que validateCity, salvo el
+// nombre repetido que se revisa contra el catálogo y el resto del archivo
+func validateImportCity(dataMap map[string]interface{}) string {
+	if msg := helpers.ValidateFieldCity(dataMap); msg != constants.EMPTY {
+		return msg
+	}
+	var cityDto dto.CityDTO
+	helpers.MapToStruct(&cityDto, dataMap)
+	return helpers.ValidateRequiredCity(cityDto)
+}
+
+// validateImportState es el equivalente de validateState para una fila
+func validateImportState(dataMap map[string]interface{}) (dto.StatesDTO, string) {
+	if msg := validateField(dataMap); msg != constants.EMPTY {
+		return dto.StatesDTO{}, msg
+	}
+	var stateDto dto.StatesDTO
+	if err := helpers.MapToStructState(dataMap, &stateDto); err != nil {
+		return dto.StatesDTO{}, err.Error()
+	}
+	return stateDto, validateRequired(stateDto)
+}
+
+// resolveImportCity devuelve la ciudad de la fila por city_id o por el nombre en city;
+// cero sin mensaje si la fila no trae ninguna de las dos
+func resolveImportCity(record importRecord, ids map[uint]bool, names map[string]uint) (uint, string) {
+	if value, _ := record.value(constants.CITY_ID); value != constants.EMPTY {
+		id, err := strconv.ParseUint(value, 10, 64)
+		if err != nil || id == 0 {
+			return 0, fmt.Sprintf("city_id no es un id válido: %s", value)
+		}
+		if !ids[uint(id)] {
+			return 0, fmt.Sprintf("la ciudad %d no existe", id)
+		}
+		return uint(id), constants.EMPTY
+	}
+	if value, _ := record.value(importColumnCity); value != constants.EMPTY {
+		id, ok := names[strings.ToLower(value)]
+		if !ok {
+			return 0, fmt.Sprintf("la ciudad %s no existe", value)
+		}
+		return id, constants.EMPTY
+	}
+	return 0, constants.EMPTY
+}
+
+func parseImportId(record importRecord) (uint, string) {
+	value, _ := record.value(importColumnId)
+	if value == constants.EMPTY {
+		return 0, constants.EMPTY
+	}
+	id, err := strconv.ParseUint(value, 10, 64)
+	if err != nil || id == 0 {
+		return 0, fmt.Sprintf("id no es un id válido: %s", value)
+	}
+	return uint(id), constants.EMPTY
+}
+
+// parseImportBool acepta los valores de strconv.ParseBool y si/sí/no
+func parseImportBool(value string) (bool, bool) {
+	switch strings.ToLower(value) {
+	case "si", "sí":
+		return true, true
+	case "no":
+		return false, true
+	}
+	parsed, err := strconv.ParseBool(value)
+	return parsed, err == nil
+}
+
+// readImportCsv lee el CSV del campo file de un multipart o del cuerpo de la petición.
+// La primera fila es la cabecera; por defecto cada campo se lee de la columna con su
+// mismo nombre y ?columns=name:Nombre,active:Activo cambia esa correspondencia.
+// ?delimiter=; admite archivos exportados desde hojas de cálculo en español
+func readImportCsv(c *fiber.Ctx, fields []string) ([]importRecord, string) {
+	mapping, msg := importColumns(c.Query("columns"), fields)
+	if msg != constants.EMPTY {
+		return nil, msg
+	}
+	body := c.Body()
+	if file, err := c.FormFile("file"); err == nil {
+		opened, err := file.Open()
+		if err != nil {
+			return nil, err.Error()
+		}
+		defer opened.Close()
+		if body, err = io.ReadAll(opened); err != nil {
+			return nil, err.Error()
+		}
+	}
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(body, []byte("\ufeff"))))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	if delimiter := []rune(c.Query("delimiter")); len(delimiter) == 1 {
+		reader.Comma = delimiter[0]
+	}
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, importEmptyFile
+	}
+	if err != nil {
+		return nil, err.Error()
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		if field, ok := mapping[strings.ToLower(strings.TrimSpace(name))]; ok {
+			columns[field] = i
+		}
+	}
+
+	var records []importRecord
+	for {
+		values, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err.Error()
+		}
+		line, _ := reader.FieldPos(0)
+		if strings.TrimSpace(strings.Join(values, constants.EMPTY)) == constants.EMPTY {
+			continue
+		}
+		record := importRecord{line: line, values: map[string]string{}}
+		for field, i := range columns {
+			if i < len(values) {
+				record.values[field] = values[i]
+			}
+		}
+		records = append(records, record)
+	}
+	return records, constants.EMPTY
+}
+
+// importColumns devuelve la cabecera en minúsculas que corresponde a cada campo
+func importColumns(value string, fields []string) (map[string]string, string) {
+	headers := map[string]string{}
+	for _, field := range fields {
+		headers[field] = field
+	}
+	if value != constants.EMPTY {
+		for _, pair := range strings.Split(value, ",") {
+			field, header, ok := strings.Cut(pair, ":")
+			field = strings.TrimSpace(field)
+			if _, known := headers[field]; !ok || !known || strings.TrimSpace(header) == constants.EMPTY {
+				return nil, importInvalidColumn
+			}
+			headers[field] = header
+		}
+	}
+	mapping := map[string]string{}
+	for field, header := range headers {
+		mapping[strings.ToLower(strings.TrimSpace(header))] = field
+	}
+	return mapping, constants.EMPTY
+}
+
+// importResult responde 400 si alguna fila es inválida y 200 con el reporte en otro caso
+func importResult(c *fiber.Ctx, report importReport) error {
+	status, message := fiber.StatusOK, importApplied
+	switch {
+	case report.Invalid > 0:
+		status, message = fiber.StatusBadRequest, importHasErrors
+	case report.DryRun:
+		message = importDryRun
+	}
+	return c.Status(status).JSON(fiber.Map{
+		constants.STATUS:  status,
+		constants.MESSAGE: message,
+		constants.DATA:    report,
+	})
+}
+
+func importBadRequest(c *fiber.Ctx, msg string) error {
+	return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+		constants.STATUS:  http.StatusBadRequest,
+		constants.MESSAGE: msg,
+	})
+}
+
+func importQueryFailed(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		constants.STATUS:  fiber.StatusInternalServerError,
+		constants.MESSAGE: constants.ERROR_QUERY,
+	})
+}
+
+func importMetrics(entity string, report importReport) {
+	for i := 0; i < report.Create; i++ {
+		metrics.CatalogueChanged(entity, metrics.OperationCreated)
+	}
+	for i := 0; i < report.Update; i++ {
+		metrics.CatalogueChanged(entity, metrics.OperationUpdated)
+	}
+}
@@ -1,16 +1,23 @@
 package service
 
 import (
+	"context"
 	"encoding/json"
-	"log"
+	"errors"
+	"fmt"
+	"math"
 	"net/http"
 	"strconv"
+	"strings"
 
 	constants "github.com/flabio/safe_constants"
 	"github.com/gofiber/fiber/v2"
 	"github.com/safe_msvc_city/core"
 	"github.com/safe_msvc_city/insfratructure/entities"
 	"github.com/safe_msvc_city/insfratructure/helpers"
+	"github.com/safe_msvc_city/insfratructure/logging"
+	"github.com/safe_msvc_city/insfratructure/metrics"
+	"github.com/safe_msvc_city/insfratructure/tracing"
 	"github.com/safe_msvc_city/insfratructure/ui/global"
 	"github.com/safe_msvc_city/insfratructure/ui/uicore"
 	"github.com/safe_msvc_city/usecase/dto"
@@ -19,18 +26,38 @@ import (
 
 type statesService struct {
 	states uicore.UIStatesCore
+	cities uicore.UICityCore
+	bulk   uicore.UIBulkCore
 }
 
 func NewSatatesService() global.UIStates {
 	return &statesService{
 		states: core.GetStatesInstance(),
+		cities: core.GetCityInstance(),
+		bulk:   core.GetBulkInstance(),
 	}
 
 }
 
+const (
+	stateCityNotExist  = "la ciudad %d no existe"
+	stateCityInactive  = "la ciudad %d está inactiva"
+	stateMoveSameCity  = "el barrio ya pertenece a la ciudad %d"
+	stateMoveNameTaken = "la ciudad %d ya tiene un barrio llamado %s"
+)
+
+// stateMoveRequest es el cuerpo de POST /api/states/:id/move
+type stateMoveRequest struct {
+	CityId uint   `json:"city_id"`
+	Reason string `json:"reason"`
+}
+
 func (s *statesService) GetStatesFindAll(c *fiber.Ctx) error {
-	result, err := s.states.GetStatesFindAll()
+	ctx, span := tracing.Start(c.UserContext(), "statesService.GetStatesFindAll")
+	defer span.End()
+	result, err := s.states.GetStatesFindAll(ctx)
 	if err != nil {
+		logging.FromContext(ctx).Error("statesService.GetStatesFindAll failed", "error", err)
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			constants.STATUS:  fiber.StatusBadRequest,
 			constants.MESSAGE: constants.ERROR_QUERY,
@@ -43,15 +70,29 @@ func (s *statesService) GetStatesFindAll(c *fiber.Ctx) error {
 }
 
 func (s *statesService) GetStatesFindById(c *fiber.Ctx) error {
+	ctx, span := tracing.Start(c.UserContext(), "statesService.GetStatesFindById")
+	defer span.End()
 	id, _ := strconv.Atoi(c.Params(constants.ID))
-	result, err := s.states.GetStatesFindById(uint(id))
-	if err != nil {
+	result, err := s.states.GetStatesFindById(ctx, uint(id))
+	if err != nil && !core.IsNotFound(err) {
+		logging.FromContext(ctx).Error("statesService.GetStatesFindById failed", "error", err)
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			constants.STATUS:  fiber.StatusBadRequest,
 			constants.MESSAGE: constants.ERROR_QUERY,
 		})
 	}
 	if result.Id == 0 {
+		target, err := s.states.GetStateMergedInto(ctx, uint(id))
+		if err != nil {
+			logging.FromContext(ctx).Error("statesService.GetStatesFindById failed", "error", err)
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				constants.STATUS:  fiber.StatusBadRequest,
+				constants.MESSAGE: constants.ERROR_QUERY,
+			})
+		}
+		if target > 0 {
+			return mergedRedirect(c, target)
+		}
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			constants.STATUS: fiber.StatusNotFound,
 		})
@@ -59,10 +100,13 @@ func (s *statesService) GetStatesFindById(c *fiber.Ctx) error {
 	return c.Status(http.StatusOK).JSON(result)
 }
 func (s *statesService) GetStatesFindByIdOfCity(c *fiber.Ctx) error {
+	ctx, span := tracing.Start(c.UserContext(), "statesService.GetStatesFindByIdOfCity")
+	defer span.End()
 	id, _ := strconv.Atoi(c.Params(constants.ID))
 
-	result, err := s.states.GetStatesFindByIdOfCity(uint(id))
+	result, err := s.states.GetStatesFindByIdOfCity(ctx, uint(id))
 	if err != nil {
+		logging.FromContext(ctx).Error("statesService.GetStatesFindByIdOfCity failed", "error", err)
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			constants.STATUS:  fiber.StatusBadRequest,
 			constants.MESSAGE: constants.ERROR_QUERY,
@@ -75,22 +119,34 @@ func (s *statesService) GetStatesFindByIdOfCity(c *fiber.Ctx) error {
 	})
 }
 func (s *statesService) CreateState(c *fiber.Ctx) error {
+	ctx, span := tracing.Start(c.UserContext(), "statesService.CreateState")
+	defer span.End()
 	var states entities.States
-	stateDto, msgError := validateState(0, s, c)
+	stateDto, msgError, err := validateState(ctx, entities.States{}, s, c)
+	if err != nil {
+		logging.FromContext(ctx).Error("statesService.CreateState failed", "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			constants.STATUS:  fiber.StatusInternalServerError,
+			constants.MESSAGE: constants.ERROR_QUERY,
+		})
+	}
 	if msgError != "" {
+		recordValidationFailure(metrics.EntityState, msgError)
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			constants.STATUS:  fiber.StatusBadRequest,
 			constants.MESSAGE: msgError,
 		})
 	}
 	deepcopier.Copy(stateDto).To(&states)
-	result, err := s.states.CreateStates(states)
+	result, err := s.states.CreateStates(ctx, states)
 	if err != nil {
+		logging.FromContext(ctx).Error("statesService.CreateState failed", "error", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			constants.STATUS:  fiber.StatusInternalServerError,
 			constants.MESSAGE: constants.ERROR_CREATE,
 		})
 	}
+	metrics.CatalogueChanged(metrics.EntityState, metrics.OperationCreated)
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
 		constants.STATUS:  fiber.StatusCreated,
 		constants.DATA:    result,
@@ -99,29 +155,48 @@ func (s *statesService) CreateState(c *fiber.Ctx) error {
 }
 
 func (s *statesService) UpdateState(c *fiber.Ctx) error {
+	ctx, span := tracing.Start(c.UserContext(), "statesService.UpdateState")
+	defer span.End()
 	id, _ := strconv.Atoi(c.Params(constants.ID))
-	stateDto, msgError := validateState(uint(id), s, c)
-	if msgError != "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			constants.STATUS:  http.StatusBadRequest,
-			constants.MESSAGE: msgError,
+	state, err := s.states.GetStatesFindById(ctx, uint(id))
+	if err != nil && !core.IsNotFound(err) {
+		logging.FromContext(ctx).Error("statesService.UpdateState failed", "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			constants.STATUS:  fiber.StatusInternalServerError,
+			constants.MESSAGE: constants.ERROR_QUERY,
 		})
 	}
-	state, _ := s.states.GetStatesFindById(uint(id))
 	if state.Id == 0 {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			constants.STATUS:  http.StatusNotFound,
 			constants.MESSAGE: constants.ID_NO_EXIST,
 		})
 	}
+	stateDto, msgError, err := validateState(ctx, state, s, c)
+	if err != nil {
+		logging.FromContext(ctx).Error("statesService.UpdateState failed", "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			constants.STATUS:  fiber.StatusInternalServerError,
+			constants.MESSAGE: constants.ERROR_QUERY,
+		})
+	}
+	if msgError != "" {
+		recordValidationFailure(metrics.EntityState, msgError)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			constants.STATUS:  http.StatusBadRequest,
+			constants.MESSAGE: msgError,
+		})
+	}
 	deepcopier.Copy(stateDto).To(&state)
-	result, err := s.states.UpdateStates(uint(id), state)
+	result, err := s.states.UpdateStates(ctx, uint(id), state)
 	if err != nil {
+		logging.FromContext(ctx).Error("statesService.UpdateState failed", "error", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			constants.STATUS:  fiber.StatusInternalServerError,
 			constants.MESSAGE: err.Error(),
 		})
 	}
+	metrics.CatalogueChanged(metrics.EntityState, metrics.OperationUpdated)
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		constants.STATUS:  fiber.StatusOK,
 		constants.DATA:    result,
@@ -129,21 +204,25 @@ func (s *statesService) UpdateState(c *fiber.Ctx) error {
 	})
 }
 func (s *statesService) DeleteState(c *fiber.Ctx) error {
+	ctx, span := tracing.Start(c.UserContext(), "statesService.DeleteState")
+	defer span.End()
 	id, _ := strconv.Atoi(c.Params(constants.ID))
-	state, _ := s.states.GetStatesFindById(uint(id))
+	state, _ := s.states.GetStatesFindById(ctx, uint(id))
 	if state.Id == 0 {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			constants.STATUS:  http.StatusNotFound,
 			constants.MESSAGE: constants.ID_NO_EXIST,
 		})
 	}
-	result, err := s.states.DeleteStates(uint(id))
+	result, err := s.states.DeleteStates(ctx, uint(id))
 	if err != nil {
+		logging.FromContext(ctx).Error("statesService.DeleteState failed", "error", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			constants.STATUS:  fiber.StatusInternalServerError,
 			constants.MESSAGE: constants.ERROR_DELETE,
 		})
 	}
+	metrics.CatalogueChanged(metrics.EntityState, metrics.OperationDeleted)
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		constants.STATUS:  fiber.StatusOK,
 		constants.DATA:    result,
@@ -151,10 +230,354 @@ func (s *statesService) DeleteState(c *fiber.Ctx) error {
 	})
 }
 
-func validateState(id uint, s *statesService, c *fiber.Ctx) (dto.StatesDTO, string) {
+// BulkStates crea, actualiza y elimina barrios en un lote, con las mismas reglas que
+// BulkCities; además cada barrio creado o actualizado debe pertenecer a una ciudad que
+// exista
+func (s *statesService) BulkStates(c *fiber.Ctx) error {
+	ctx, span := tracing.Start(c.UserContext(), "statesService.BulkStates")
+	defer span.End()
+	request, msg := parseBulk(c)
+	if msg != constants.EMPTY {
+		return bulkBadRequest(c, msg)
+	}
+	results := checkBulkShape(request)
+	states := make([]entities.States, len(request.Operations))
+	var ids, cityIds []uint
+	var names []string
+	deleted := map[uint]bool{}
+	for i, op := range request.Operations {
+		if results[i].failed() {
+			continue
+		}
+		states[i].Id = op.Id
+		if op.Op != uicore.BulkCreate {
+			ids = append(ids, op.Id)
+		}
+		if op.Op == uicore.BulkDelete {
+			deleted[op.Id] = true
+			continue
+		}
+		stateDto, msg := validateBulkState(op.Data)
+		if msg != constants.EMPTY {
+			recordValidationFailure(metrics.EntityState, msg)
+			results[i].fail(http.StatusBadRequest, msg)
+			continue
+		}
+		states[i].Name, states[i].ZipCode = stateDto.Name, stateDto.ZipCode
+		states[i].CityId, states[i].Active = stateDto.CityId, stateDto.Active
+		names = append(names, stateDto.Name)
+		cityIds = append(cityIds, stateDto.CityId)
+	}
+
+	existing, err := s.bulk.GetStatesByIds(ctx, ids)
+	if err == nil {
+		var named []entities.States
+		named, err = s.bulk.GetStatesByNames(ctx, names)
+		existing = append(existing, named...)
+	}
+	var cities []entities.City
+	if err == nil {
+		cities, err = s.bulk.GetCitiesByIds(ctx, cityIds)
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("statesService.BulkStates failed", "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			constants.STATUS:  fiber.StatusInternalServerError,
+			constants.MESSAGE: constants.ERROR_QUERY,
+		})
+	}
+	found := map[uint]bool{}
+	owners := map[string]uint{}
+	for _, state := range existing {
+		found[state.Id] = true
+		owners[strings.ToLower(state.Name)] = state.Id
+	}
+	cityFound := map[uint]bool{}
+	for _, city := range cities {
+		cityFound[city.Id] = true
+	}
+	seen := map[string]int{}
+	for i, op := range request.Operations {
+		switch {
+		case results[i].failed():
+			continue
+		case op.Op != uicore.BulkCreate && !found[op.Id]:
+			results[i].fail(http.StatusNotFound, constants.ID_NO_EXIST)
+			continue
+		case op.Op == uicore.BulkDelete:
+			continue
+		}
+		name := strings.ToLower(states[i].Name)
+		if owner, ok := owners[name]; ok && owner != op.Id && !deleted[owner] {
+			recordValidationFailure(metrics.EntityState, constants.NAME_ALREADY_EXIST)
+			results[i].fail(http.StatusBadRequest, constants.NAME_ALREADY_EXIST)
+		} else if previous, ok := seen[name]; ok {
+			recordValidationFailure(metrics.EntityState, constants.NAME_ALREADY_EXIST)
+			results[i].fail(http.StatusBadRequest, fmt.Sprintf(bulkRepeatedName, constants.NAME_ALREADY_EXIST, previous))
+		} else if !cityFound[states[i].CityId] {
+			msg := fmt.Sprintf(stateCityNotExist, states[i].CityId)
+			recordValidationFailure(metrics.EntityState, msg)
+			results[i].fail(http.StatusBadRequest, msg)
+		}
+		seen[name] = i
+	}
+
+	var ops []uicore.BulkStateOperation
+	var index []int
+	for i, op := range request.Operations {
+		if !results[i].failed() {
+			ops = append(ops, uicore.BulkStateOperation{Op: op.Op, State: states[i]})
+			index = append(index, i)
+		}
+	}
+	atomic := request.Mode == bulkAllOrNothing
+	if atomic && len(ops) < len(results) {
+		bulkRejectAll(results)
+		return bulkResponse(c, request.Mode, results)
+	}
+	if len(ops) == 0 {
+		return bulkResponse(c, request.Mode, results)
+	}
+	errs, err := s.bulk.BulkStates(ctx, ops, atomic)
+	if err != nil {
+		logging.FromContext(ctx).Error("statesService.BulkStates failed", "error", err)
+		return bulkApplyFailed(c, results)
+	}
+	for j, i := range index {
+		if errs[j] != nil {
+			logging.FromContext(ctx).Error("statesService.BulkStates failed", "index", i, "error", errs[j])
+			results[i].fail(http.StatusInternalServerError, bulkErrorMessage(ops[j].Op))
+			continue
+		}
+		results[i].Status, results[i].Id = bulkStatus(ops[j].Op), ops[j].State.Id
+		metrics.CatalogueChanged(metrics.EntityState, bulkOperationMetric(ops[j].Op))
+	}
+	return bulkResponse(c, request.Mode, results)
+}
+
+// MoveState cambia el barrio a otra ciudad. La ciudad de destino debe existir y estar
+// activa, y no tener ya un barrio con el mismo nombre; el cambio queda en el historial
+// y publica StateMoved
+func (s *statesService) MoveState(c *fiber.Ctx) error {
+	ctx, span := tracing.Start(c.UserContext(), "statesService.MoveState")
+	defer span.End()
+	id, _ := strconv.Atoi(c.Params(constants.ID))
+	var request stateMoveRequest
+	msg := constants.EMPTY
+	if err := json.Unmarshal(c.Body(), &request); err != nil {
+		msg = err.Error()
+	} else if request.CityId == 0 {
+		msg = constants.CITY_ID_IS_REQUIRED
+	}
+	if msg != constants.EMPTY {
+		recordValidationFailure(metrics.EntityState, msg)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			constants.STATUS:  http.StatusBadRequest,
+			constants.MESSAGE: msg,
+		})
+	}
+	state, err := s.states.GetStatesFindById(ctx, uint(id))
+	if err != nil && !core.IsNotFound(err) {
+		logging.FromContext(ctx).Error("statesService.MoveState failed", "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			constants.STATUS:  fiber.StatusInternalServerError,
+			constants.MESSAGE: constants.ERROR_QUERY,
+		})
+	}
+	if state.Id == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			constants.STATUS:  http.StatusNotFound,
+			constants.MESSAGE: constants.ID_NO_EXIST,
+		})
+	}
+	msg, err = validateMove(ctx, s, state, request.CityId)
+	if err != nil {
+		logging.FromContext(ctx).Error("statesService.MoveState failed", "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			constants.STATUS:  fiber.StatusInternalServerError,
+			constants.MESSAGE: constants.ERROR_QUERY,
+		})
+	}
+	if msg != constants.EMPTY {
+		recordValidationFailure(metrics.EntityState, msg)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			constants.STATUS:  http.StatusBadRequest,
+			constants.MESSAGE: msg,
+		})
+	}
+	result, err := s.states.MoveState(ctx, state.Id, request.CityId, request.Reason)
+	if msg := moveRejected(err, state, request.CityId); msg != constants.EMPTY {
+		recordValidationFailure(metrics.EntityState, msg)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			constants.STATUS:  http.StatusBadRequest,
+			constants.MESSAGE: msg,
+		})
+	}
+	if err != nil {
+		logging.FromContext(ctx).Error("statesService.MoveState failed", "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			constants.STATUS:  fiber.StatusInternalServerError,
+			constants.MESSAGE: constants.ERROR_UPDATE,
+		})
+	}
+	metrics.CatalogueChanged(metrics.EntityState, metrics.OperationUpdated)
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		constants.STATUS:  fiber.StatusOK,
+		constants.DATA:    result,
+		constants.MESSAGE: constants.UPDATED,
+	})
+}
+
+// GetStateMoves devuelve el historial de cambios de ciudad del barrio
+func (s *statesService) GetStateMoves(c *fiber.Ctx) error {
+	ctx, span := tracing.Start(c.UserContext(), "statesService.GetStateMoves")
+	defer span.End()
+	id, _ := strconv.Atoi(c.Params(constants.ID))
+	state, err := s.states.GetStatesFindById(ctx, uint(id))
+	if err != nil && !core.IsNotFound(err) {
+		logging.FromContext(ctx).Error("statesService.GetStateMoves failed", "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			constants.STATUS:  fiber.StatusInternalServerError,
+			constants.MESSAGE: constants.ERROR_QUERY,
+		})
+	}
+	if state.Id == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			constants.STATUS:  http.StatusNotFound,
+			constants.MESSAGE: constants.ID_NO_EXIST,
+		})
+	}
+	moves, err := s.states.GetStateMoves(ctx, state.Id)
+	if err != nil {
+		logging.FromContext(ctx).Error("statesService.GetStateMoves failed", "error", err)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			constants.STATUS:  fiber.StatusBadRequest,
+			constants.MESSAGE: constants.ERROR_QUERY,
+		})
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		constants.STATUS: fiber.StatusOK,
+		constants.DATA:   moves,
+	})
+}
+
+// MergeStates fusiona el barrio source_id en target_id; source_id queda borrado con una
+// redirección, igual que en MergeCities
+func (s *statesService) MergeStates(c *fiber.Ctx) error {
+	ctx, span := tracing.Start(c.UserContext(), "statesService.MergeStates")
+	defer span.End()
+	request, msg := parseMerge(c)
+	if msg != constants.EMPTY {
+		recordValidationFailure(metrics.EntityState, msg)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			constants.STATUS:  http.StatusBadRequest,
+			constants.MESSAGE: msg,
+		})
+	}
+	source, err := s.states.GetStatesFindById(ctx, request.SourceId)
+	if err != nil && !core.IsNotFound(err) {
+		logging.FromContext(ctx).Error("statesService.MergeStates failed", "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			constants.STATUS:  fiber.StatusInternalServerError,
+			constants.MESSAGE: constants.ERROR_QUERY,
+		})
+	}
+	if source.Id == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			constants.STATUS:  http.StatusNotFound,
+			constants.MESSAGE: constants.ID_NO_EXIST,
+		})
+	}
+	target, err := s.states.GetStatesFindById(ctx, request.TargetId)
+	if err != nil && !core.IsNotFound(err) {
+		logging.FromContext(ctx).Error("statesService.MergeStates failed", "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			constants.STATUS:  fiber.StatusInternalServerError,
+			constants.MESSAGE: constants.ERROR_QUERY,
+		})
+	}
+	switch {
+	case target.Id == 0:
+		msg = fmt.Sprintf(mergeTargetNotExist, request.TargetId)
+	case !target.Active:
+		msg = fmt.Sprintf(mergeTargetInactive, request.TargetId)
+	}
+	if msg != constants.EMPTY {
+		recordValidationFailure(metrics.EntityState, msg)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			constants.STATUS:  http.StatusBadRequest,
+			constants.MESSAGE: msg,
+		})
+	}
+	result, err := s.states.MergeStates(ctx, source.Id, target.Id)
+	if err != nil {
+		logging.FromContext(ctx).Error("statesService.MergeStates failed", "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			constants.STATUS:  fiber.StatusInternalServerError,
+			constants.MESSAGE: constants.ERROR_UPDATE,
+		})
+	}
+	metrics.CatalogueChanged(metrics.EntityState, metrics.OperationDeleted)
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		constants.STATUS:  fiber.StatusOK,
+		constants.DATA:    result,
+		constants.MESSAGE: fmt.Sprintf(mergeStateDone, source.Id, target.Id),
+	})
+}
+
+// moveRejected traduce los rechazos que MoveState detecta dentro de la transacción,
+// cuando la ciudad cambió después de validateMove; vacío si err no es uno de ellos
+func moveRejected(err error, state entities.States, cityId uint) string {
+	switch {
+	case errors.Is(err, core.ErrMoveSameCity):
+		return fmt.Sprintf(stateMoveSameCity, cityId)
+	case errors.Is(err, core.ErrMoveCityMissing):
+		return fmt.Sprintf(stateCityNotExist, cityId)
+	case errors.Is(err, core.ErrMoveCityInactive):
+		return fmt.Sprintf(stateCityInactive, cityId)
+	case errors.Is(err, core.ErrMoveNameTaken):
+		return fmt.Sprintf(stateMoveNameTaken, cityId, state.Name)
+	}
+	return constants.EMPTY
+}
+
+// validateMove revisa la ciudad de destino y que el nombre del barrio no se repita en ella
+func validateMove(ctx context.Context, s *statesService, state entities.States, cityId uint) (string, error) {
+	if state.CityId == cityId {
+		return fmt.Sprintf(stateMoveSameCity, cityId), nil
+	}
+	city, err := s.cities.GetCityFindById(ctx, cityId)
+	if err != nil {
+		return constants.EMPTY, err
+	}
+	switch {
+	case city.Id == 0:
+		return fmt.Sprintf(stateCityNotExist, cityId), nil
+	case !city.Active:
+		return fmt.Sprintf(stateCityInactive, cityId), nil
+	}
+	siblings, err := s.states.GetStatesFindByIdOfCity(ctx, cityId)
+	if err != nil {
+		return constants.EMPTY, err
+	}
+	for _, sibling := range siblings {
+		if sibling.Id != state.Id && strings.EqualFold(sibling.Name, state.Name) {
+			return fmt.Sprintf(stateMoveNameTaken, cityId, sibling.Name), nil
+		}
+	}
+	return constants.EMPTY, nil
+}
+
+// validateState lee y valida el cuerpo; current es el barrio que se actualiza, vacío al
+// crear. Si la actualización cambia city_id se aplican las reglas de validateMove. err
+// es un fallo de la base de datos al revisar el nombre o la ciudad, que no es culpa del
+// cliente
+func validateState(ctx context.Context, current entities.States, s *statesService, c *fiber.Ctx) (dto.StatesDTO, string, error) {
+	ctx, span := tracing.Start(ctx, "validateState")
+	defer span.End()
 	defer func() {
 		if r := recover(); r != nil {
-			log.Println(constants.RECOVER_PANIC, r)
+			logging.FromContext(ctx).Error("recovered from panic while validating state", "panic", r)
 		}
 	}()
 	var msg string = ""
@@ -186,19 +609,37 @@ func validateState(id uint, s *statesService, c *fiber.Ctx) (dto.StatesDTO, stri
 
 	msgValid := validateField(dataMap)
 	if msgValid != "" {
-		return dto.StatesDTO{}, msgValid
+		return dto.StatesDTO{}, msgValid, nil
 	}
 
 	helpers.MapToStructState(dataMap, &stateDto)
 	msg = validateRequired(stateDto)
 	if msg != "" {
-		return dto.StatesDTO{}, msg
+		return dto.StatesDTO{}, msg, nil
+	}
+	existName, err := s.states.GetStatesFindByName(ctx, current.Id, stateDto.Name)
+	if err != nil && !core.IsNotFound(err) {
+		return dto.StatesDTO{}, constants.EMPTY, err
 	}
-	existName, _ := s.states.GetStatesFindByName(id, stateDto.Name)
 	if existName {
-		msg = constants.NAME_ALREADY_EXIST
+		return stateDto, constants.NAME_ALREADY_EXIST, nil
+	}
+	if current.Id > 0 && current.CityId != stateDto.CityId {
+		moved := entities.States{Id: current.Id, Name: stateDto.Name, CityId: current.CityId}
+		msg, err = validateMove(ctx, s, moved, stateDto.CityId)
+		if err != nil {
+			return dto.StatesDTO{}, constants.EMPTY, err
+		}
+		return stateDto, msg, nil
 	}
-	return stateDto, msg
+	city, err := s.cities.GetCityFindById(ctx, stateDto.CityId)
+	if err != nil {
+		return dto.StatesDTO{}, constants.EMPTY, err
+	}
+	if city.Id == 0 {
+		msg = fmt.Sprintf(stateCityNotExist, stateDto.CityId)
+	}
+	return stateDto, msg, nil
 }
 
 // func MapToStructStates(stateDto *dto.StatesDTO, dataMap map[string]string) {
@@ -267,3 +708,35 @@ func validateRequired(stateDto dto.StatesDTO) string {
 	}
 	return msg
 }
+
+// validateBulkState aplica las reglas de validateState a data; la unicidad del nombre y
+// la ciudad se revisan aparte para todo el lote
+func validateBulkState(dataMap map[string]interface{}) (dto.StatesDTO, string) {
+	if msg := validateField(dataMap); msg != constants.EMPTY {
+		return dto.StatesDTO{}, msg
+	}
+	_, isName := dataMap[constants.NAME].(string)
+	_, isZipCode := dataMap[constants.ZIP_CODE].(string)
+	_, isActive := dataMap[constants.ACTIVE].(bool)
+	if !isName || !isZipCode || !isActive || !isBulkId(dataMap[constants.CITY_ID]) {
+		return dto.StatesDTO{}, bulkInvalidData
+	}
+	var stateDto dto.StatesDTO
+	if err := helpers.MapToStructState(dataMap, &stateDto); err != nil {
+		return dto.StatesDTO{}, bulkInvalidData
+	}
+	return stateDto, validateRequired(stateDto)
+}
+
+// isBulkId indica si value es un id: un número entero no negativo del JSON o el uint
+// provisional de los barrios anidados
+func isBulkId(value interface{}) bool {
+	switch id := value.(type) {
+	case float64:
+		return id >= 0 && id == math.Trunc(id) && id <= math.MaxUint32
+	case uint:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	constants "github.com/flabio/safe_constants"
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/safe_msvc_city/core"
+	"github.com/safe_msvc_city/insfratructure/config"
+	"github.com/safe_msvc_city/insfratructure/entities"
+	"github.com/safe_msvc_city/insfratructure/events"
+	"github.com/safe_msvc_city/insfratructure/logging"
+	"github.com/safe_msvc_city/insfratructure/tracing"
+	"github.com/safe_msvc_city/insfratructure/ui/global"
+	"github.com/safe_msvc_city/insfratructure/ui/uicore"
+)
+
+// syncTokenInvalid es el mensaje para un token que no se puede leer
+const syncTokenInvalid = "since no es un token de sincronización válido"
+
+// syncTokenPrefix versiona el formato del token por si cambia
+const syncTokenPrefix = "v1:"
+
+var syncConfig = config.Default(config.ProfileProd).Sync
+
+// ConfigureSync define la retención de tombstones y el solape que usa /api/sync
+func ConfigureSync(cfg config.Sync) {
+	syncConfig = cfg
+}
+
+type syncService struct {
+	syncRepository uicore.UISyncCore
+}
+
+func NewSyncService() global.UISync {
+	return &syncService{
+		syncRepository: core.GetSyncInstance(),
+	}
+}
+
+// syncChanges agrupa los cambios de una entidad
+type syncChanges[T any] struct {
+	Created []T                 `json:"created"`
+	Updated []T                 `json:"updated"`
+	Deleted []entities.Deletion `json:"deleted"`
+}
+
+// GetChanges devuelve lo creado, actualizado y eliminado desde ?since=<token> y un
+// token nuevo. Sin since, o con un token más viejo que la retención de tombstones,
+// devuelve el catálogo completo con full=true y el cliente debe reemplazar su copia.
+// Los tokens se solapan unos segundos, así que un cambio puede llegar dos veces y el
+// cliente debe aplicarlos de forma idempotente
+func (s *syncService) GetChanges(c *fiber.Ctx) error {
+	ctx, span := tracing.Start(c.UserContext(), "syncService.GetChanges")
+	defer span.End()
+	since, err := parseSyncToken(c.Query("since"))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			constants.STATUS:  http.StatusBadRequest,
+			constants.MESSAGE: syncTokenInvalid,
+		})
+	}
+	now, err := s.syncRepository.Now(ctx)
+	if err != nil {
+		return s.queryFailed(c, err)
+	}
+	full := since.IsZero() || since.Before(now.Add(-syncConfig.TombstoneRetention))
+	if full {
+		since = time.Time{}
+	} else {
+		since = since.Add(-syncConfig.Overlap)
+	}
+
+	cities, err := s.syncRepository.GetCitiesChangedSince(ctx, since)
+	if err != nil {
+		return s.queryFailed(c, err)
+	}
+	states, err := s.syncRepository.GetStatesChangedSince(ctx, since)
+	if err != nil {
+		return s.queryFailed(c, err)
+	}
+	cityChanges := syncChanges[entities.City]{Created: []entities.City{}, Updated: []entities.City{}, Deleted: []entities.Deletion{}}
+	for _, city := range cities {
+		if full || !city.CreatedAt.Before(since) {
+			cityChanges.Created = append(cityChanges.Created, city)
+		} else {
+			cityChanges.Updated = append(cityChanges.Updated, city)
+		}
+	}
+	stateChanges := syncChanges[entities.States]{Created: []entities.States{}, Updated: []entities.States{}, Deleted: []entities.Deletion{}}
+	for _, state := range states {
+		if full || !state.CreatedAt.Before(since) {
+			stateChanges.Created = append(stateChanges.Created, state)
+		} else {
+			stateChanges.Updated = append(stateChanges.Updated, state)
+		}
+	}
+	if !full {
+		deletions, err := s.syncRepository.GetDeletionsSince(ctx, since)
+		if err != nil {
+			return s.queryFailed(c, err)
+		}
+		for _, deletion := range deletions {
+			switch deletion.Entity {
+			case events.EntityCity:
+				cityChanges.Deleted = append(cityChanges.Deleted, deletion)
+			case events.EntityState:
+				stateChanges.Deleted = append(stateChanges.Deleted, deletion)
+			}
+		}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		constants.STATUS: http.StatusOK,
+		constants.DATA: fiber.Map{
+			"token":  syncToken(now),
+			"full":   full,
+			"cities": cityChanges,
+			"states": stateChanges,
+		},
+	})
+}
+
+func (s *syncService) queryFailed(c *fiber.Ctx, err error) error {
+	logging.FromContext(c.UserContext()).Error("syncService.GetChanges failed", "error", err)
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		constants.STATUS:  fiber.StatusInternalServerError,
+		constants.MESSAGE: constants.ERROR_QUERY,
+	})
+}
+
+// syncToken codifica la hora de la base de datos como un token opaco
+func syncToken(at time.Time) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(syncTokenPrefix + strconv.FormatInt(at.UnixMicro(), 10)))
+}
+
+// parseSyncToken devuelve la hora del token; un token vacío es la hora cero
+func parseSyncToken(token string) (time.Time, error) {
+	if token == "" {
+		return time.Time{}, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return time.Time{}, err
+	}
+	value, ok := strings.CutPrefix(string(raw), syncTokenPrefix)
+	if !ok {
+		return time.Time{}, errors.New("versión de token desconocida")
+	}
+	micros, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || micros <= 0 {
+		return time.Time{}, errors.New("token inválido")
+	}
+	return time.UnixMicro(micros), nil
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+)
+
+func TestSyncToken(t *testing.T) {
+	at := time.Date(2024, 5, 1, 12, 30, 0, 123456789, time.UTC)
+	got, err := parseSyncToken(syncToken(at))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Equal(at.Truncate(time.Microsecond)) {
+		t.Errorf("ida y vuelta = %v, se esperaba %v", got, at.Truncate(time.Microsecond))
+	}
+}
+
+func TestParseSyncToken(t *testing.T) {
+	encode := func(raw string) string { return base64.RawURLEncoding.EncodeToString([]byte(raw)) }
+	tests := []struct {
+		name    string
+		token   string
+		want    time.Time
+		wantErr bool
+	}{
+		{"vacío es la hora cero", "", time.Time{}, false},
+		{"válido", encode("v1:1714566600000000"), time.UnixMicro(1714566600000000), false},
+		{"no es base64", "%%%", time.Time{}, true},
+		{"base64 con relleno", base64.URLEncoding.EncodeToString([]byte("v1:1")), time.Time{}, true},
+		{"otra versión", encode("v2:1714566600000000"), time.Time{}, true},
+		{"sin versión", encode("1714566600000000"), time.Time{}, true},
+		{"no numérico", encode("v1:ayer"), time.Time{}, true},
+		{"cero", encode("v1:0"), time.Time{}, true},
+		{"negativo", encode("v1:-5"), time.Time{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSyncToken(tt.token)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("se esperaba un error, se obtuvo %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("error inesperado: %v", err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("= %v, se esperaba %v", got, tt.want)
+			}
+		})
+	}
+}
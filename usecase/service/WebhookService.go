@@ -0,0 +1,307 @@
+package service
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+
+	constants "github.com/flabio/safe_constants"
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/safe_msvc_city/core"
+	"github.com/safe_msvc_city/insfratructure/entities"
+	"github.com/safe_msvc_city/insfratructure/events"
+	"github.com/safe_msvc_city/insfratructure/logging"
+	"github.com/safe_msvc_city/insfratructure/tracing"
+	"github.com/safe_msvc_city/insfratructure/ui/global"
+	"github.com/safe_msvc_city/insfratructure/ui/uicore"
+	"github.com/safe_msvc_city/insfratructure/webhooks"
+	"github.com/safe_msvc_city/usecase/dto"
+)
+
+// Mensajes de validación de las suscripciones
+const (
+	webhookInvalidBody      = "el cuerpo de la petición no es un JSON válido"
+	webhookUrlInvalid       = "url debe ser una URL http o https absoluta"
+	webhookUrlForbidden     = "url no puede apuntar a localhost ni a una dirección privada, de enlace local o no especificada"
+	webhookUrlUnresolved    = "no se pudo resolver el host de url"
+	webhookEventTypeInvalid = "event_types contiene un tipo de evento desconocido: "
+	webhookNotDead          = "la entrega no existe o no está en la lista de muertas"
+)
+
+// Límites de los listados de entregas
+const (
+	defaultDeliveriesLimit = 50
+	maxDeliveriesLimit     = 500
+)
+
+type webhookService struct {
+	webhookRepository uicore.UIWebhookCore
+}
+
+func NewWebhookService() global.UIWebhook {
+	return &webhookService{
+		webhookRepository: core.GetWebhookInstance(),
+	}
+}
+
+func (s *webhookService) GetWebhookFindAll(c *fiber.Ctx) error {
+	ctx, span := tracing.Start(c.UserContext(), "webhookService.GetWebhookFindAll")
+	defer span.End()
+	result, err := s.webhookRepository.GetWebhookFindAll(ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("webhookService.GetWebhookFindAll failed", "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			constants.STATUS:  fiber.StatusInternalServerError,
+			constants.MESSAGE: constants.ERROR_QUERY,
+		})
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		constants.STATUS: http.StatusOK,
+		constants.DATA:   result,
+	})
+}
+
+func (s *webhookService) GetWebhookFindById(c *fiber.Ctx) error {
+	ctx, span := tracing.Start(c.UserContext(), "webhookService.GetWebhookFindById")
+	defer span.End()
+	id, _ := strconv.Atoi(c.Params(constants.ID))
+	result, err := s.webhookRepository.GetWebhookFindById(ctx, uint(id))
+	if err != nil {
+		logging.FromContext(ctx).Error("webhookService.GetWebhookFindById failed", "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			constants.STATUS:  fiber.StatusInternalServerError,
+			constants.MESSAGE: constants.ERROR_QUERY,
+		})
+	}
+	if result.Id == 0 {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{
+			constants.STATUS:  http.StatusNotFound,
+			constants.MESSAGE: constants.ID_NO_EXIST,
+		})
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		constants.STATUS: http.StatusOK,
+		constants.DATA:   result,
+	})
+}
+
+// CreateWebhook crea la suscripción; el secreto solo se devuelve en esta respuesta
+func (s *webhookService) CreateWebhook(c *fiber.Ctx) error {
+	ctx, span := tracing.Start(c.UserContext(), "webhookService.CreateWebhook")
+	defer span.End()
+	webhookDto, msgError := validateWebhook(c)
+	if msgError != "" {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			constants.STATUS:  http.StatusBadRequest,
+			constants.MESSAGE: msgError,
+		})
+	}
+	subscription := webhookFromDto(webhookDto, entities.WebhookSubscription{Active: true})
+	if subscription.Secret == "" {
+		secret, err := webhooks.NewSecret()
+		if err != nil {
+			logging.FromContext(ctx).Error("webhookService.CreateWebhook failed", "error", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				constants.STATUS:  fiber.StatusInternalServerError,
+				constants.MESSAGE: constants.ERROR_CREATE,
+			})
+		}
+		subscription.Secret = secret
+	}
+	result, err := s.webhookRepository.CreateWebhook(ctx, subscription)
+	if err != nil {
+		logging.FromContext(ctx).Error("webhookService.CreateWebhook failed", "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			constants.STATUS:  fiber.StatusInternalServerError,
+			constants.MESSAGE: constants.ERROR_CREATE,
+		})
+	}
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		constants.STATUS:  http.StatusCreated,
+		constants.DATA:    result,
+		"secret":          result.Secret,
+		constants.MESSAGE: constants.CREATED,
+	})
+}
+
+// UpdateWebhook reemplaza la suscripción; si no se envía secret se conserva el actual
+func (s *webhookService) UpdateWebhook(c *fiber.Ctx) error {
+	ctx, span := tracing.Start(c.UserContext(), "webhookService.UpdateWebhook")
+	defer span.End()
+	id, _ := strconv.Atoi(c.Params(constants.ID))
+	webhookDto, msgError := validateWebhook(c)
+	if msgError != "" {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			constants.STATUS:  http.StatusBadRequest,
+			constants.MESSAGE: msgError,
+		})
+	}
+	subscription, err := s.webhookRepository.GetWebhookFindById(ctx, uint(id))
+	if err != nil {
+		logging.FromContext(ctx).Error("webhookService.UpdateWebhook failed", "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			constants.STATUS:  fiber.StatusInternalServerError,
+			constants.MESSAGE: constants.ERROR_QUERY,
+		})
+	}
+	if subscription.Id == 0 {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{
+			constants.STATUS:  http.StatusNotFound,
+			constants.MESSAGE: constants.ID_NO_EXIST,
+		})
+	}
+	result, err := s.webhookRepository.UpdateWebhook(ctx, uint(id), webhookFromDto(webhookDto, subscription))
+	if err != nil {
+		logging.FromContext(ctx).Error("webhookService.UpdateWebhook failed", "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			constants.STATUS:  fiber.StatusInternalServerError,
+			constants.MESSAGE: constants.ERROR_UPDATE,
+		})
+	}
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		constants.STATUS:  http.StatusAccepted,
+		constants.DATA:    result,
+		constants.MESSAGE: constants.UPDATED,
+	})
+}
+
+func (s *webhookService) DeleteWebhook(c *fiber.Ctx) error {
+	ctx, span := tracing.Start(c.UserContext(), "webhookService.DeleteWebhook")
+	defer span.End()
+	id, _ := strconv.Atoi(c.Params(constants.ID))
+	result, err := s.webhookRepository.DeleteWebhook(ctx, uint(id))
+	if err != nil {
+		logging.FromContext(ctx).Error("webhookService.DeleteWebhook failed", "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			constants.STATUS:  fiber.StatusInternalServerError,
+			constants.MESSAGE: constants.ERROR_DELETE,
+		})
+	}
+	if !result {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{
+			constants.STATUS:  http.StatusNotFound,
+			constants.MESSAGE: constants.ID_NO_EXIST,
+		})
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		constants.STATUS:  http.StatusOK,
+		constants.MESSAGE: constants.REMOVED,
+		constants.DATA:    result,
+	})
+}
+
+// GetWebhookDeliveries devuelve el log de entregas de la suscripción; ?limit=N
+func (s *webhookService) GetWebhookDeliveries(c *fiber.Ctx) error {
+	ctx, span := tracing.Start(c.UserContext(), "webhookService.GetWebhookDeliveries")
+	defer span.End()
+	id, _ := strconv.Atoi(c.Params(constants.ID))
+	result, err := s.webhookRepository.GetDeliveriesBySubscription(ctx, uint(id), deliveriesLimit(c))
+	if err != nil {
+		logging.FromContext(ctx).Error("webhookService.GetWebhookDeliveries failed", "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			constants.STATUS:  fiber.StatusInternalServerError,
+			constants.MESSAGE: constants.ERROR_QUERY,
+		})
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		constants.STATUS: http.StatusOK,
+		constants.DATA:   result,
+	})
+}
+
+// GetDeadDeliveries devuelve las entregas que agotaron los reintentos; ?limit=N
+func (s *webhookService) GetDeadDeliveries(c *fiber.Ctx) error {
+	ctx, span := tracing.Start(c.UserContext(), "webhookService.GetDeadDeliveries")
+	defer span.End()
+	result, err := s.webhookRepository.GetDeadDeliveries(ctx, deliveriesLimit(c))
+	if err != nil {
+		logging.FromContext(ctx).Error("webhookService.GetDeadDeliveries failed", "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			constants.STATUS:  fiber.StatusInternalServerError,
+			constants.MESSAGE: constants.ERROR_QUERY,
+		})
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		constants.STATUS: http.StatusOK,
+		constants.DATA:   result,
+	})
+}
+
+// ReplayDelivery vuelve a encolar una entrega de la lista de muertas
+func (s *webhookService) ReplayDelivery(c *fiber.Ctx) error {
+	ctx, span := tracing.Start(c.UserContext(), "webhookService.ReplayDelivery")
+	defer span.End()
+	id, _ := strconv.Atoi(c.Params(constants.ID))
+	result, err := s.webhookRepository.ReplayDelivery(ctx, uint(id))
+	if err != nil {
+		logging.FromContext(ctx).Error("webhookService.ReplayDelivery failed", "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			constants.STATUS:  fiber.StatusInternalServerError,
+			constants.MESSAGE: constants.ERROR_UPDATE,
+		})
+	}
+	if !result {
+		return c.Status(http.StatusNotFound).JSON(fiber.Map{
+			constants.STATUS:  http.StatusNotFound,
+			constants.MESSAGE: webhookNotDead,
+		})
+	}
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		constants.STATUS:  http.StatusAccepted,
+		constants.DATA:    result,
+		constants.MESSAGE: constants.UPDATED,
+	})
+}
+
+func validateWebhook(c *fiber.Ctx) (dto.WebhookDTO, string) {
+	var webhookDto dto.WebhookDTO
+	if err := c.BodyParser(&webhookDto); err != nil {
+		return webhookDto, webhookInvalidBody
+	}
+	target, err := url.Parse(webhookDto.Url)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return webhookDto, webhookUrlInvalid
+	}
+	if err := webhooks.CheckURL(c.UserContext(), webhookDto.Url); errors.Is(err, webhooks.ErrForbiddenTarget) {
+		return webhookDto, webhookUrlForbidden
+	} else if err != nil {
+		return webhookDto, webhookUrlUnresolved
+	}
+	for _, eventType := range webhookDto.EventTypes {
+		if !slices.Contains(events.Types, eventType) {
+			return webhookDto, webhookEventTypeInvalid + eventType
+		}
+	}
+	return webhookDto, ""
+}
+
+// webhookFromDto aplica el DTO sobre subscription; secret vacío y active ausente conservan el valor actual
+func webhookFromDto(webhookDto dto.WebhookDTO, subscription entities.WebhookSubscription) entities.WebhookSubscription {
+	subscription.Url = webhookDto.Url
+	subscription.EventTypes = webhookDto.EventTypes
+	subscription.CityIds = webhookDto.CityIds
+	if subscription.EventTypes == nil {
+		subscription.EventTypes = []string{}
+	}
+	if subscription.CityIds == nil {
+		subscription.CityIds = []uint{}
+	}
+	if webhookDto.Secret != "" {
+		subscription.Secret = webhookDto.Secret
+	}
+	if webhookDto.Active != nil {
+		subscription.Active = *webhookDto.Active
+	}
+	return subscription
+}
+
+func deliveriesLimit(c *fiber.Ctx) int {
+	limit := c.QueryInt("limit", defaultDeliveriesLimit)
+	if limit <= 0 || limit > maxDeliveriesLimit {
+		return defaultDeliveriesLimit
+	}
+	return limit
+}
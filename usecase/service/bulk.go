@@ -0,0 +1,205 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	constants "github.com/flabio/safe_constants"
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/safe_msvc_city/insfratructure/metrics"
+	"github.com/safe_msvc_city/insfratructure/ui/uicore"
+)
+
+// Modos de los endpoints bulk
+const (
+	bulkAllOrNothing = "all_or_nothing"
+	bulkBestEffort   = "best_effort"
+)
+
+// bulkMaxOperations limita el tamaño de un lote
+const bulkMaxOperations = 1000
+
+const (
+	bulkInvalidMode    = "mode debe ser all_or_nothing o best_effort"
+	bulkEmpty          = "operations no puede estar vacío"
+	bulkTooLarge       = "operations admite como máximo %d operaciones"
+	bulkInvalidOp      = "op debe ser create, update o delete"
+	bulkIdRequired     = "id es obligatorio en update y delete"
+	bulkInvalidData    = "data tiene un campo con un tipo inválido"
+	bulkRepeatedId     = "el id %d ya aparece en la operación %d"
+	bulkRepeatedName   = "%s (operación %d)"
+	bulkNotApplied     = "no se aplicó porque otra operación falló"
+	bulkRejected       = "el lote tiene errores; no se aplicó ninguna operación"
+	bulkPartialApplied = "algunas operaciones no se aplicaron"
+)
+
+// bulkRequest es el cuerpo de /bulk; en update data trae todos los campos, igual que PUT
+type bulkRequest struct {
+	Mode       string          `json:"mode"`
+	Operations []bulkOperation `json:"operations"`
+}
+
+type bulkOperation struct {
+	Op   string                 `json:"op"`
+	Id   uint                   `json:"id"`
+	Data map[string]interface{} `json:"data"`
+}
+
+// bulkResult es el resultado de una operación; status usa los mismos códigos que el
+// endpoint individual equivalente
+type bulkResult struct {
+	Index  int    `json:"index"`
+	Op     string `json:"op"`
+	Status int    `json:"status"`
+	Id     uint   `json:"id,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+func (r *bulkResult) fail(status int, msg string) {
+	if r.Error == constants.EMPTY {
+		r.Status, r.Error = status, msg
+	}
+}
+
+func (r bulkResult) failed() bool {
+	return r.Error != constants.EMPTY
+}
+
+// parseBulk lee y revisa la forma del lote; mode por defecto es all_or_nothing
+func parseBulk(c *fiber.Ctx) (bulkRequest, string) {
+	var request bulkRequest
+	if err := json.Unmarshal(c.Body(), &request); err != nil {
+		return request, err.Error()
+	}
+	if request.Mode == constants.EMPTY {
+		request.Mode = bulkAllOrNothing
+	}
+	switch {
+	case request.Mode != bulkAllOrNothing && request.Mode != bulkBestEffort:
+		return request, bulkInvalidMode
+	case len(request.Operations) == 0:
+		return request, bulkEmpty
+	case len(request.Operations) > bulkMaxOperations:
+		return request, fmt.Sprintf(bulkTooLarge, bulkMaxOperations)
+	}
+	return request, constants.EMPTY
+}
+
+// checkBulkShape revisa op e id de cada operación y que ningún id se repita
+func checkBulkShape(request bulkRequest) []bulkResult {
+	results := make([]bulkResult, len(request.Operations))
+	seen := map[uint]int{}
+	for i, op := range request.Operations {
+		results[i] = bulkResult{Index: i, Op: op.Op, Id: op.Id}
+		switch op.Op {
+		case uicore.BulkCreate:
+			results[i].Id = 0
+		case uicore.BulkUpdate, uicore.BulkDelete:
+			if op.Id == 0 {
+				results[i].fail(http.StatusBadRequest, bulkIdRequired)
+				continue
+			}
+			if previous, ok := seen[op.Id]; ok {
+				results[i].fail(http.StatusBadRequest, fmt.Sprintf(bulkRepeatedId, op.Id, previous))
+				continue
+			}
+			seen[op.Id] = i
+		default:
+			results[i].fail(http.StatusBadRequest, bulkInvalidOp)
+		}
+	}
+	return results
+}
+
+// bulkStatus es el código de una operación aplicada
+func bulkStatus(op string) int {
+	if op == uicore.BulkCreate {
+		return http.StatusCreated
+	}
+	return http.StatusOK
+}
+
+// bulkOperationMetric traduce la operación a la etiqueta de las métricas
+func bulkOperationMetric(op string) string {
+	switch op {
+	case uicore.BulkCreate:
+		return metrics.OperationCreated
+	case uicore.BulkUpdate:
+		return metrics.OperationUpdated
+	}
+	return metrics.OperationDeleted
+}
+
+// bulkRejectAll marca como no aplicadas las operaciones válidas de un lote
+// all_or_nothing que no se aplicó
+func bulkRejectAll(results []bulkResult) {
+	for i := range results {
+		if !results[i].failed() {
+			results[i].Status, results[i].Error = http.StatusFailedDependency, bulkNotApplied
+			if results[i].Op == uicore.BulkCreate {
+				results[i].Id = 0
+			}
+		}
+	}
+}
+
+// bulkResponse responde 200 si todo se aplicó, 207 si en best_effort alguna falló y
+// 400 si un lote all_or_nothing se rechazó en la validación
+func bulkResponse(c *fiber.Ctx, mode string, results []bulkResult) error {
+	failed := 0
+	for _, result := range results {
+		if result.failed() {
+			failed++
+		}
+	}
+	status, message := http.StatusOK, constants.EMPTY
+	switch {
+	case failed > 0 && mode == bulkAllOrNothing:
+		status, message = http.StatusBadRequest, bulkRejected
+	case failed > 0:
+		status, message = http.StatusMultiStatus, bulkPartialApplied
+	}
+	body := fiber.Map{
+		constants.STATUS: status,
+		constants.DATA:   results,
+	}
+	if message != constants.EMPTY {
+		body[constants.MESSAGE] = message
+	}
+	return c.Status(status).JSON(body)
+}
+
+func bulkBadRequest(c *fiber.Ctx, msg string) error {
+	return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+		constants.STATUS:  http.StatusBadRequest,
+		constants.MESSAGE: msg,
+	})
+}
+
+// bulkErrorMessage es el mensaje del endpoint individual cuando falla la escritura
+func bulkErrorMessage(op string) string {
+	switch op {
+	case uicore.BulkCreate:
+		return constants.ERROR_CREATE
+	case uicore.BulkUpdate:
+		return constants.ERROR_UPDATE
+	}
+	return constants.ERROR_DELETE
+}
+
+// bulkApplyFailed responde 500 cuando un lote all_or_nothing falló al escribirse
+func bulkApplyFailed(c *fiber.Ctx, results []bulkResult) error {
+	for i := range results {
+		if results[i].Op == uicore.BulkCreate {
+			results[i].Id = 0
+		}
+		results[i].fail(http.StatusInternalServerError, bulkErrorMessage(results[i].Op))
+	}
+	return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+		constants.STATUS:  http.StatusInternalServerError,
+		constants.MESSAGE: bulkRejected,
+		constants.DATA:    results,
+	})
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	constants "github.com/flabio/safe_constants"
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/safe_msvc_city/insfratructure/ui/uicore"
+)
+
+// withBody ejecuta fn con un *fiber.Ctx cuyo cuerpo es body
+func withBody(t *testing.T, body string, fn func(c *fiber.Ctx) error) {
+	t.Helper()
+	app := fiber.New()
+	app.Post("/", fn)
+	request := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	request.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	if _, err := app.Test(request); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParseBulk(t *testing.T) {
+	many := strings.Repeat(`{"op":"delete","id":1},`, bulkMaxOperations)
+	tests := []struct {
+		name string
+		body string
+		mode string
+		msg  string
+	}{
+		{"modo por defecto", `{"operations":[{"op":"create"}]}`, bulkAllOrNothing, constants.EMPTY},
+		{"best_effort", `{"mode":"best_effort","operations":[{"op":"create"}]}`, bulkBestEffort, constants.EMPTY},
+		{"modo inválido", `{"mode":"some","operations":[{"op":"create"}]}`, "some", bulkInvalidMode},
+		{"vacío", `{"operations":[]}`, bulkAllOrNothing, bulkEmpty},
+		{"demasiadas", `{"operations":[` + many + `{"op":"delete","id":1}]}`, bulkAllOrNothing, fmt.Sprintf(bulkTooLarge, bulkMaxOperations)},
+		{"justo el máximo", `{"operations":[` + strings.TrimSuffix(many, ",") + `]}`, bulkAllOrNothing, constants.EMPTY},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withBody(t, tt.body, func(c *fiber.Ctx) error {
+				request, msg := parseBulk(c)
+				if msg != tt.msg {
+					t.Errorf("msg = %q, se esperaba %q", msg, tt.msg)
+				}
+				if request.Mode != tt.mode {
+					t.Errorf("mode = %q, se esperaba %q", request.Mode, tt.mode)
+				}
+				return nil
+			})
+		})
+	}
+	withBody(t, `{"operations":`, func(c *fiber.Ctx) error {
+		if _, msg := parseBulk(c); msg == constants.EMPTY {
+			t.Error("se esperaba un error con un JSON inválido")
+		}
+		return nil
+	})
+}
+
+func TestCheckBulkShape(t *testing.T) {
+	request := bulkRequest{Operations: []bulkOperation{
+		{Op: uicore.BulkCreate, Id: 9},
+		{Op: uicore.BulkUpdate, Id: 3},
+		{Op: uicore.BulkDelete},
+		{Op: uicore.BulkDelete, Id: 3},
+		{Op: "upsert", Id: 4},
+		{Op: uicore.BulkDelete, Id: 4},
+	}}
+	want := []bulkResult{
+		{Index: 0, Op: uicore.BulkCreate},
+		{Index: 1, Op: uicore.BulkUpdate, Id: 3},
+		{Index: 2, Op: uicore.BulkDelete, Status: http.StatusBadRequest, Error: bulkIdRequired},
+		{Index: 3, Op: uicore.BulkDelete, Id: 3, Status: http.StatusBadRequest, Error: fmt.Sprintf(bulkRepeatedId, 3, 1)},
+		{Index: 4, Op: "upsert", Id: 4, Status: http.StatusBadRequest, Error: bulkInvalidOp},
+		{Index: 5, Op: uicore.BulkDelete, Id: 4},
+	}
+	got := checkBulkShape(request)
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("operación %d = %+v, se esperaba %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestValidateBulkState(t *testing.T) {
+	tests := []struct {
+		name string
+		data map[string]interface{}
+		msg  string
+	}{
+		{"válido", map[string]interface{}{"name": "Laureles", "zip_code": "050031", "city_id": float64(1), "active": true}, constants.EMPTY},
+		{"id provisional anidado", map[string]interface{}{"name": "Laureles", "zip_code": "050031", "city_id": nestedStatePending, "active": false}, constants.EMPTY},
+		{"sin active", map[string]interface{}{"name": "Laureles", "zip_code": "050031", "city_id": float64(1)}, constants.ACTIVE_FIELD_IS_REQUIRED},
+		{"sin city_id", map[string]interface{}{"name": "Laureles", "zip_code": "050031", "active": true}, constants.CITY_ID_FIELD_IS_REQUIRED},
+		{"nombre vacío", map[string]interface{}{"name": "", "zip_code": "050031", "city_id": float64(1), "active": true}, constants.NAME_FIELD_IS_REQUIRED},
+		{"nombre null", map[string]interface{}{"name": nil, "zip_code": "050031", "city_id": float64(1), "active": true}, bulkInvalidData},
+		{"nombre numérico", map[string]interface{}{"name": float64(5), "zip_code": "050031", "city_id": float64(1), "active": true}, bulkInvalidData},
+		{"zip_code null", map[string]interface{}{"name": "Laureles", "zip_code": nil, "city_id": float64(1), "active": true}, bulkInvalidData},
+		{"active texto", map[string]interface{}{"name": "Laureles", "zip_code": "050031", "city_id": float64(1), "active": "true"}, bulkInvalidData},
+		{"city_id texto", map[string]interface{}{"name": "Laureles", "zip_code": "050031", "city_id": "1", "active": true}, bulkInvalidData},
+		{"city_id decimal", map[string]interface{}{"name": "Laureles", "zip_code": "050031", "city_id": 1.5, "active": true}, bulkInvalidData},
+		{"city_id negativo", map[string]interface{}{"name": "Laureles", "zip_code": "050031", "city_id": float64(-1), "active": true}, bulkInvalidData},
+		{"city_id cero", map[string]interface{}{"name": "Laureles", "zip_code": "050031", "city_id": float64(0), "active": true}, constants.CITY_ID_IS_REQUIRED},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stateDto, msg := validateBulkState(tt.data)
+			if msg != tt.msg {
+				t.Fatalf("msg = %q, se esperaba %q", msg, tt.msg)
+			}
+			if msg == constants.EMPTY && stateDto.Name != tt.data["name"] {
+				t.Errorf("name = %q", stateDto.Name)
+			}
+		})
+	}
+}
+
+func TestValidateBulkCity(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    map[string]interface{}
+		invalid bool
+	}{
+		{"válido", map[string]interface{}{"name": "Medellín", "active": true}, false},
+		{"nombre null", map[string]interface{}{"name": nil, "active": true}, true},
+		{"active texto", map[string]interface{}{"name": "Medellín", "active": "si"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, msg := validateBulkCity(tt.data)
+			if got := msg != constants.EMPTY; got != tt.invalid {
+				t.Errorf("msg = %q", msg)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	constants "github.com/flabio/safe_constants"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	mergeSourceRequired = "source_id es obligatorio"
+	mergeTargetRequired = "target_id es obligatorio"
+	mergeSameId         = "source_id y target_id deben ser distintos"
+	mergeTargetNotExist = "target_id %d no existe"
+	mergeTargetInactive = "target_id %d está inactivo"
+	mergeCityDone       = "la ciudad %d se fusionó en %d"
+	mergeStateDone      = "el barrio %d se fusionó en %d"
+	mergedMessage       = "se fusionó en %d"
+)
+
+// mergedIntoKey es el campo de la respuesta que indica el id vigente de un id fusionado
+const mergedIntoKey = "merged_into"
+
+// mergeRequest es el cuerpo de POST /api/cities/merge y /api/states/merge
+type mergeRequest struct {
+	SourceId uint `json:"source_id"`
+	TargetId uint `json:"target_id"`
+}
+
+// parseMerge lee el cuerpo y revisa que los dos ids vengan y sean distintos
+func parseMerge(c *fiber.Ctx) (mergeRequest, string) {
+	var request mergeRequest
+	if err := json.Unmarshal(c.Body(), &request); err != nil {
+		return request, err.Error()
+	}
+	switch {
+	case request.SourceId == 0:
+		return request, mergeSourceRequired
+	case request.TargetId == 0:
+		return request, mergeTargetRequired
+	case request.SourceId == request.TargetId:
+		return request, mergeSameId
+	}
+	return request, constants.EMPTY
+}
+
+// mergedRedirect responde 301 hacia el mismo recurso con el id vigente, conservando la
+// consulta, para que los servicios que guardan ids viejos sigan funcionando
+func mergedRedirect(c *fiber.Ctx, target uint) error {
+	location := strings.TrimSuffix(c.Path(), c.Params(constants.ID)) + strconv.FormatUint(uint64(target), 10)
+	if query := c.Request().URI().QueryString(); len(query) > 0 {
+		location += "?" + string(query)
+	}
+	c.Location(location)
+	return c.Status(http.StatusMovedPermanently).JSON(fiber.Map{
+		constants.STATUS:  http.StatusMovedPermanently,
+		constants.MESSAGE: fmt.Sprintf(mergedMessage, target),
+		mergedIntoKey:     target,
+	})
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	constants "github.com/flabio/safe_constants"
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestParseMerge(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		request mergeRequest
+		msg     string
+	}{
+		{"válido", `{"source_id":3,"target_id":5}`, mergeRequest{SourceId: 3, TargetId: 5}, constants.EMPTY},
+		{"sin source_id", `{"target_id":5}`, mergeRequest{TargetId: 5}, mergeSourceRequired},
+		{"sin target_id", `{"source_id":3}`, mergeRequest{SourceId: 3}, mergeTargetRequired},
+		{"mismo id", `{"source_id":3,"target_id":3}`, mergeRequest{SourceId: 3, TargetId: 3}, mergeSameId},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withBody(t, tt.body, func(c *fiber.Ctx) error {
+				request, msg := parseMerge(c)
+				if msg != tt.msg || request != tt.request {
+					t.Errorf("= %+v %q, se esperaba %+v %q", request, msg, tt.request, tt.msg)
+				}
+				return nil
+			})
+		})
+	}
+	for _, body := range []string{`{"source_id":-1,"target_id":5}`, `{"source_id":"3","target_id":5}`, `{`} {
+		withBody(t, body, func(c *fiber.Ctx) error {
+			if _, msg := parseMerge(c); msg == constants.EMPTY {
+				t.Errorf("se esperaba un error con %s", body)
+			}
+			return nil
+		})
+	}
+}
+
+func TestMergedRedirect(t *testing.T) {
+	tests := []struct {
+		path     string
+		location string
+	}{
+		{"/api/cities/3", "/api/cities/9"},
+		{"/api/cities/3?expand=states", "/api/cities/9?expand=states"},
+		{"/api/states/3", "/api/states/9"},
+	}
+	app := fiber.New()
+	redirect := func(c *fiber.Ctx) error { return mergedRedirect(c, 9) }
+	app.Get("/api/cities/:id", redirect)
+	app.Get("/api/states/:id", redirect)
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			res, err := app.Test(httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.StatusCode != http.StatusMovedPermanently {
+				t.Errorf("status = %d, se esperaba 301", res.StatusCode)
+			}
+			if location := res.Header.Get(fiber.HeaderLocation); location != tt.location {
+				t.Errorf("Location = %q, se esperaba %q", location, tt.location)
+			}
+			var body map[string]interface{}
+			if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+			if body[mergedIntoKey] != float64(9) || body[constants.MESSAGE] != fmt.Sprintf(mergedMessage, 9) {
+				t.Errorf("cuerpo = %v", body)
+			}
+		})
+	}
+}
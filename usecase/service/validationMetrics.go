@@ -0,0 +1,30 @@
+package service
+
+import (
+	constants "github.com/flabio/safe_constants"
+	"github.com/safe_msvc_city/insfratructure/metrics"
+)
+
+// validationCodes traduce los mensajes de validación a códigos estables para las métricas
+var validationCodes = map[string]string{
+	constants.NAME_FIELD_IS_REQUIRED:     "name_required",
+	constants.NAME_IS_REQUIRED:           "name_required",
+	constants.ACTIVE_FIELD_IS_REQUIRED:   "active_required",
+	constants.CITY_ID_FIELD_IS_REQUIRED:  "city_id_required",
+	constants.CITY_ID_IS_REQUIRED:        "city_id_required",
+	constants.ZIP_CODE_IS_FIELD_REQUIRED: "zip_code_required",
+	constants.ZIP_CODE_IS_REQUIRED:       "zip_code_required",
+	constants.NAME_ALREADY_EXIST:         "name_conflict",
+}
+
+// recordValidationFailure cuenta un rechazo por validación y, si aplica, un conflicto de nombre
+func recordValidationFailure(entity string, msg string) {
+	code, ok := validationCodes[msg]
+	if !ok {
+		code = "invalid_body"
+	}
+	metrics.ValidationFailed(entity, code)
+	if msg == constants.NAME_ALREADY_EXIST {
+		metrics.NameConflict(entity)
+	}
+}